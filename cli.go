@@ -0,0 +1,338 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// globalOptions are the flags every command accepts, before or after the
+// command name.
+type globalOptions struct {
+	config    string
+	quiet     bool
+	lang      string
+	verbosity int
+	logFile   string
+	version   bool
+}
+
+// register defines the global flags on fs, keeping values parsed earlier as
+// the defaults.
+func (g *globalOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.config, "config", g.config, "path to the YAML configuration `file`")
+	fs.BoolVar(&g.quiet, "quiet", g.quiet, "only print errors")
+	fs.StringVar(&g.lang, "lang", g.lang, "`language` of messages: en or ja (default from $LANG)")
+	fs.BoolFunc("v", "log which clients are skipped and why", g.addVerbosity(1))
+	fs.BoolFunc("vv", "also log resolved paths and how each server compares", g.addVerbosity(2))
+	fs.StringVar(&g.logFile, "log-file", g.logFile, "append the log to `file` instead of stderr (the TUI logs only to a file)")
+}
+
+// addVerbosity returns the setter of a -v flag; -v -v counts like -vv.
+func (g *globalOptions) addVerbosity(n int) func(string) error {
+	return func(value string) error {
+		on, err := strconv.ParseBool(value)
+		if on {
+			g.verbosity += n
+		}
+		return err
+	}
+}
+
+// applyLang switches the message language when --lang was given.
+func (g *globalOptions) applyLang() error {
+	if g.lang == "" {
+		return nil
+	}
+	l, err := parseLang(g.lang)
+	if err != nil {
+		return err
+	}
+	lang = l
+	return nil
+}
+
+// cliArgs is what the positional arguments of a command are.
+type cliArgs int
+
+const (
+	argsNone cliArgs = iota
+	argsYAML
+	argsClientServer
+	argsShell
+)
+
+// cliCommand is one entry of the command tree. Help and usage are generated
+// from it and from the flags parseCommandFlags defines.
+type cliCommand struct {
+	name    string
+	args    cliArgs
+	summary string
+	details string
+}
+
+func (c *cliCommand) maxArgs() int {
+	switch c.args {
+	case argsYAML:
+		return 1
+	case argsClientServer:
+		return 2
+	case argsShell:
+		return 1
+	}
+	return 0
+}
+
+func (c *cliCommand) synopsis() string {
+	switch c.args {
+	case argsYAML:
+		return fmt.Sprintf("mcpyammy %s [flags] [yaml-file]", c.name)
+	case argsClientServer:
+		return fmt.Sprintf("mcpyammy %s [flags] [client] [server]", c.name)
+	case argsShell:
+		return fmt.Sprintf("mcpyammy %s %s", c.name, strings.Join(completionShells, "|"))
+	}
+	return fmt.Sprintf("mcpyammy %s [flags]", c.name)
+}
+
+var cliCommands = []*cliCommand{
+	{name: CommandApply, args: argsYAML, summary: "Write the servers of the YAML to every client file",
+		details: "Only mcpServers is changed; the rest of each client file keeps its formatting and comments.\n" +
+			"Use --client and --server to roll a change out to some clients or servers first.\n" +
+			"On a terminal the changes are shown and confirmed first; --dry-run only shows them, --yes skips the question."},
+	{name: CommandDiff, args: argsYAML, summary: "Show the changes apply would make to each client file",
+		details: "Nothing is written. Use --client to show a single client."},
+	{name: CommandValidate, args: argsYAML, summary: "Check the YAML for mistakes without touching any client",
+		details: "Exits with status 3 when the YAML has errors."},
+	{name: CommandImport, args: argsYAML, summary: "Merge existing client files into the YAML and print the result"},
+	{name: CommandStatus, args: argsYAML, summary: "Show which clients differ from the YAML"},
+	{name: CommandSync, args: argsYAML, summary: "Pull client-side changes into the YAML and push YAML changes to clients"},
+	{name: CommandWatch, args: argsYAML, summary: "Apply automatically whenever the YAML changes"},
+	{name: CommandTest, args: argsClientServer, summary: "Start each server and check that it answers the MCP handshake",
+		details: "Give a client, or a client and a server, to test only those."},
+	{name: CommandTools, args: argsClientServer, summary: "List the tools of each server and estimate their token cost per client"},
+	{name: CommandDoctor, args: argsYAML, summary: "Check commands, files, env variables and permissions the servers need"},
+	{name: CommandConfigRender, args: argsYAML, summary: "Print the merged configuration after include/extends"},
+	{name: CommandCompletion, args: argsShell, summary: "Print a shell completion script",
+		details: "Client and server names are read from the YAML while completing.\n" +
+			"bash: source <(mcpyammy completion bash)\n" +
+			"zsh:  source <(mcpyammy completion zsh)\n" +
+			"fish: mcpyammy completion fish | source"},
+}
+
+func findCommand(name string) *cliCommand {
+	for _, cmd := range cliCommands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// newFlagSet returns a flag set that reports errors to the caller instead of
+// printing them.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+	return fs
+}
+
+// commandFlagSet defines the flags of command, storing them in runner and
+// globals. The returned function checks values that need validation once
+// parsing is done.
+func commandFlagSet(command string, runner *CLICommandRunner, globals *globalOptions) (*flag.FlagSet, func() error) {
+	fs := newFlagSet(command)
+	globals.register(fs)
+	var onConflict, clients, servers string
+	output := string(OutputText)
+	switch command {
+	case CommandApply, CommandImport, CommandDiff, CommandStatus, CommandValidate:
+		fs.StringVar(&output, "output", string(OutputText), "output format: text or json")
+		fs.BoolFunc("json", "same as --output json", func(value string) error {
+			on, err := strconv.ParseBool(value)
+			if on {
+				output = string(OutputJSON)
+			}
+			return err
+		})
+	}
+	switch command {
+	case CommandApply:
+		fs.BoolVar(&runner.applyOptions.ForceReset, "force-reset", false, "replace client files that cannot be parsed instead of skipping them")
+		fs.StringVar(&clients, "client", "", "only apply to these comma separated `clients`")
+		fs.StringVar(&servers, "server", "", "only write these comma separated `servers`")
+		fs.BoolVar(&runner.applyOptions.DryRun, "dry-run", false, "print what would change without writing any file")
+		fs.BoolVar(&runner.applyOptions.Yes, "yes", false, "apply without asking for confirmation")
+	case CommandDiff:
+		fs.StringVar(&runner.diffOptions.Client, "client", "", "only show this `client`")
+	case CommandWatch:
+		fs.BoolVar(&runner.watchOptions.Reverse, "reverse", false, "also warn when a client file drifts away from the YAML")
+		fs.DurationVar(&runner.watchOptions.Interval, "interval", DefaultWatchInterval, "how often to check the files for changes")
+	case CommandImport:
+		fs.StringVar(&onConflict, "on-conflict", string(ConflictKeepYAML), "how to resolve servers that differ between YAML and client: yaml, client or both")
+		fs.StringVar(&clients, "client", "", "only read the files of these comma separated `clients`")
+		fs.StringVar(&servers, "server", "", "only import these comma separated `servers`")
+	case CommandSync:
+		fs.StringVar(&onConflict, "on-conflict", string(SyncAsk), "how to resolve servers changed on both sides: ask, yaml, client or skip")
+	case CommandTest:
+		fs.DurationVar(&runner.testOptions.Timeout, "timeout", DefaultTestTimeout, "how long each server gets to answer the handshake")
+	case CommandTools:
+		fs.DurationVar(&runner.toolsOptions.Timeout, "timeout", DefaultTestTimeout, "how long each server gets to answer the handshake")
+		fs.IntVar(&runner.toolsOptions.Budget, "budget", DefaultToolTokenBudget, "warn when the tools of a client exceed this many `tokens` (0 disables the check)")
+	}
+
+	finish := func() error {
+		format, err := parseOutputFormat(output)
+		if err != nil {
+			return err
+		}
+		selection := Selection{Clients: parseNameList(clients), Servers: parseNameList(servers)}
+		switch command {
+		case CommandApply:
+			runner.applyOptions.Selection = selection
+			runner.applyOptions.Output = format
+		case CommandDiff:
+			runner.diffOptions.Output = format
+		case CommandStatus:
+			runner.statusOptions.Output = format
+		case CommandValidate:
+			runner.validateOptions.Output = format
+		case CommandImport:
+			choice, err := parseConflictChoice(onConflict)
+			if err != nil {
+				return err
+			}
+			runner.importOptions.OnConflict = choice
+			runner.importOptions.Selection = selection
+			runner.importOptions.Output = format
+		case CommandSync:
+			choice, err := parseSyncChoice(onConflict)
+			if err != nil {
+				return err
+			}
+			runner.syncOptions.OnConflict = choice
+		}
+		return nil
+	}
+	return fs, finish
+}
+
+// parseCommandFlags parses the flags of command into runner and globals and
+// returns the remaining positional arguments. Flags may appear before or
+// after the positionals.
+func parseCommandFlags(command string, args []string, runner *CLICommandRunner, globals *globalOptions) ([]string, error) {
+	fs, finish := commandFlagSet(command, runner, globals)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	return positional, finish()
+}
+
+// discoveryLocations lists where a YAML file is looked for when none is
+// given.
+func discoveryLocations() []string {
+	var paths []string
+	for _, candidate := range configCandidates("") {
+		paths = append(paths, candidate.path)
+	}
+	return paths
+}
+
+func printUsage() {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, msgf("Usage:"))
+	fmt.Fprintf(w, "  mcpyammy [flags]\t%s\n", msgf("Launch the interactive TUI"))
+	fmt.Fprintln(w, "  mcpyammy <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, msgf("Commands:"))
+	for _, cmd := range cliCommands {
+		fmt.Fprintf(w, "  %s\t%s\n", cmd.name, msgf(cmd.summary))
+	}
+	fmt.Fprintf(w, "  %s\t%s\n", CommandHelp, msgf("Show help for a command"))
+	w.Flush()
+
+	fmt.Println()
+	fmt.Println(msgf("Global flags:"))
+	printFlags(os.Stdout, globalFlagSet())
+	fmt.Println()
+	fmt.Println(msgf("Without a yaml-file, --config, $%s, ./%s and the XDG config directory are tried in that order.", ConfigEnvVar, DefaultYAMLFile))
+	fmt.Println(msgf("Run 'mcpyammy help <command>' for the flags of a command."))
+}
+
+// globalFlagSet holds the flags accepted before a command, for help.
+func globalFlagSet() *flag.FlagSet {
+	fs := newFlagSet("mcpyammy")
+	(&globalOptions{}).register(fs)
+	fs.Bool("version", false, "print the version and exit")
+	return fs
+}
+
+// printHelp implements `mcpyammy help [command]`.
+func printHelp(args []string) {
+	if len(args) == 0 {
+		printUsage()
+		return
+	}
+	name := strings.Join(args, " ")
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Println(msgf("Unknown command: %s", name))
+		printUsage()
+		osExit(1)
+		return
+	}
+	printCommandHelp(cmd)
+}
+
+func printCommandHelp(cmd *cliCommand) {
+	fmt.Printf("%s\n\n%s\n", msgf("Usage: %s", cmd.synopsis()), msgf(cmd.summary))
+	if cmd.details != "" {
+		fmt.Println(msgf(cmd.details))
+	}
+	fmt.Println()
+	fmt.Println(msgf("Flags:"))
+	fs, _ := commandFlagSet(cmd.name, &CLICommandRunner{}, &globalOptions{})
+	printFlags(os.Stdout, fs)
+}
+
+// flagName is how help and completion spell a flag: short flags such as -v
+// take one dash.
+func flagName(name string) string {
+	if len(name) <= 2 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+// printFlags lists the flags of fs with their defaults.
+func printFlags(out io.Writer, fs *flag.FlagSet) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fs.VisitAll(func(f *flag.Flag) {
+		valueName, usage := flag.UnquoteUsage(f)
+		usage = msgf(usage)
+		name := flagName(f.Name)
+		if valueName != "" {
+			name += " " + valueName
+		}
+		switch f.DefValue {
+		case "", "false", "0", "0s":
+		default:
+			usage += " " + msgf("(default %s)", f.DefValue)
+		}
+		fmt.Fprintf(w, "  %s\t%s\n", name, usage)
+	})
+	w.Flush()
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MaxApplyAttempts is how often apply re-reads and merges a client file that
+// another program changed while it was being updated.
+const MaxApplyAttempts = 3
+
+// errFileChanged reports that a client file changed between reading and
+// replacing it.
+var errFileChanged = errors.New("file changed while it was being updated")
+
+// beforeReplaceHook runs right before a client file is replaced. Tests use it
+// to simulate a client writing the file at the same time.
+var beforeReplaceHook func(path string)
+
+// fileSnapshot is the state of a client file when it was read.
+type fileSnapshot struct {
+	data    []byte
+	exists  bool
+	modTime time.Time
+	size    int64
+	mode    os.FileMode
+	hash    [sha256.Size]byte
+}
+
+func readSnapshot(path string) (*fileSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &fileSnapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSnapshot{
+		data:    data,
+		exists:  true,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		mode:    info.Mode().Perm(),
+		hash:    sha256.Sum256(data),
+	}, nil
+}
+
+// unchanged reports whether the file at path is still the one that was read.
+func (s *fileSnapshot) unchanged(path string) (bool, error) {
+	current, err := readSnapshot(path)
+	if err != nil {
+		return false, err
+	}
+	if current.exists != s.exists {
+		return false, nil
+	}
+	return !s.exists || current.modTime.Equal(s.modTime) && current.size == s.size && current.hash == s.hash, nil
+}
+
+// updateClientFile merges servers into the client file at path. Clients such
+// as Claude Code rewrite their files while running, so the file is locked
+// where the platform supports it, and the new content is written to a
+// temporary file and renamed over the original only if the original has not
+// changed since it was read. Otherwise the merge is retried on the new
+// content. It reports whether the file was written.
+func updateClientFile(path string, names []string, servers map[string]interface{}, options ApplyOptions) (bool, error) {
+	return editClientFile(path, names, servers, nil, options)
+}
+
+// editClientFile is updateClientFile that also removes the servers named in
+// remove.
+func editClientFile(path string, names []string, servers map[string]interface{}, remove []string, options ApplyOptions) (bool, error) {
+	// write through symlinks so dotfile setups keep their links
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if err := os.MkdirAll(filepath.Dir(path), DirectoryMode); err != nil {
+		return false, err
+	}
+
+	for attempt := 0; attempt < MaxApplyAttempts; attempt++ {
+		written, err := tryUpdateClientFile(path, names, servers, remove, options)
+		if !errors.Is(err, errFileChanged) {
+			return written, err
+		}
+	}
+	return false, errorf("%s was modified by another program %d times while applying; close the client and try again", path, MaxApplyAttempts)
+}
+
+func tryUpdateClientFile(path string, names []string, servers map[string]interface{}, remove []string, options ApplyOptions) (bool, error) {
+	unlock, err := lockFile(path)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	snapshot, err := readSnapshot(path)
+	if err != nil {
+		return false, err
+	}
+	output, err := editClientData(path, snapshot.data, names, servers, remove, options)
+	if err != nil {
+		return false, err
+	}
+	if snapshot.exists && bytes.Equal(output, snapshot.data) {
+		return false, nil
+	}
+	return true, replaceFile(path, output, snapshot)
+}
+
+// editClientData returns data with servers merged into and remove deleted
+// from its mcpServers section.
+func editClientData(path string, data []byte, names []string, servers map[string]interface{}, remove []string, options ApplyOptions) ([]byte, error) {
+	editor, err := openClientData(path, data, options)
+	if err != nil {
+		return nil, err
+	}
+	output := editor.src
+	if len(names) > 0 {
+		if output, err = mergeClientServers(editor, names, servers); err != nil {
+			return nil, err
+		}
+	}
+	if len(remove) > 0 {
+		if output, err = removeClientServers(output, remove); err != nil {
+			return nil, err
+		}
+	}
+	return output, nil
+}
+
+// plannedClientData returns the current content of a client file and the
+// content apply would write, without writing anything.
+func plannedClientData(path string, names []string, servers map[string]interface{}, options ApplyOptions) (before, after []byte, err error) {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	snapshot, err := readSnapshot(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	after, err = editClientData(path, snapshot.data, names, servers, nil, options)
+	if err != nil {
+		return snapshot.data, nil, err
+	}
+	return snapshot.data, after, nil
+}
+
+// replaceLockedFile replaces path with data under the same lock apply takes,
+// failing with errFileChanged when the file no longer matches snapshot.
+func replaceLockedFile(path string, data []byte, snapshot *fileSnapshot) error {
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return replaceFile(path, data, snapshot)
+}
+
+// replaceFile atomically replaces path with data, keeping the file mode of
+// the original. It returns errFileChanged when the file no longer matches
+// snapshot.
+func replaceFile(path string, data []byte, snapshot *fileSnapshot) error {
+	mode := os.FileMode(SecureFileMode)
+	if snapshot.exists {
+		mode = snapshot.mode
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if beforeReplaceHook != nil {
+		beforeReplaceHook(path)
+	}
+	same, err := snapshot.unchanged(path)
+	if err != nil {
+		return err
+	}
+	if !same {
+		return errFileChanged
+	}
+	return os.Rename(tmpPath, path)
+}
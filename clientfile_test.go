@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testServers = map[string]interface{}{"a": map[string]interface{}{"command": "a"}}
+
+// TestUpdateClientFile_RetriesOnConcurrentWrite 書き込み中に他のプログラムが更新した場合に再マージするテスト
+func TestUpdateClientFile_RetriesOnConcurrentWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".claude.json")
+	require.NoError(t, os.WriteFile(path, []byte("{\n  \"count\": 1\n}\n"), 0600))
+
+	calls := 0
+	beforeReplaceHook = func(p string) {
+		calls++
+		if calls == 1 {
+			require.NoError(t, os.WriteFile(p, []byte("{\n  \"count\": 2\n}\n"), 0600))
+		}
+	}
+	defer func() { beforeReplaceHook = nil }()
+
+	written, err := updateClientFile(path, []string{"a"}, testServers, ApplyOptions{})
+	require.NoError(t, err)
+	assert.True(t, written)
+	assert.Equal(t, 2, calls)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"count\": 2,\n  \"mcpServers\": {\n    \"a\": {\n      \"command\": \"a\"\n    }\n  }\n}\n", string(data))
+}
+
+// TestUpdateClientFile_AbortsWhenAlwaysChanging 変更が続く場合に中断して元のファイルを残すテスト
+func TestUpdateClientFile_AbortsWhenAlwaysChanging(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".claude.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"count": 0}`), 0600))
+
+	count := 0
+	beforeReplaceHook = func(p string) {
+		count++
+		require.NoError(t, os.WriteFile(p, []byte(strings.Repeat(" ", count)+`{"count": 0}`), 0600))
+	}
+	defer func() { beforeReplaceHook = nil }()
+
+	_, err := updateClientFile(path, []string{"a"}, testServers, ApplyOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "modified by another program")
+	assert.Equal(t, MaxApplyAttempts, count)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "mcpServers")
+
+	// no temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+// TestUpdateClientFile_KeepsSymlinkAndMode シンボリックリンクとパーミッションを保つテスト
+func TestUpdateClientFile_KeepsSymlinkAndMode(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles.json")
+	link := filepath.Join(dir, ".claude.json")
+	require.NoError(t, os.WriteFile(target, []byte("{}\n"), 0640))
+	require.NoError(t, os.Chmod(target, 0640))
+	require.NoError(t, os.Symlink(target, link))
+
+	written, err := updateClientFile(link, []string{"a"}, testServers, ApplyOptions{})
+	require.NoError(t, err)
+	assert.True(t, written)
+
+	info, err := os.Lstat(link)
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeSymlink, info.Mode()&os.ModeSymlink)
+	info, err = os.Stat(target)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	// a second run has nothing to write
+	written, err = updateClientFile(link, []string{"a"}, testServers, ApplyOptions{})
+	require.NoError(t, err)
+	assert.False(t, written)
+}
@@ -1,25 +1,49 @@
 package main
 
-import "fmt"
-
 // CommandRunner defines the interface for command execution
 type CommandRunner interface {
-	runCommand(command, yamlFile string)
+	runCommand(command, yamlFile string) error
 }
 
 // CLICommandRunner implements CommandRunner for CLI operations
-type CLICommandRunner struct{}
+type CLICommandRunner struct {
+	applyOptions    ApplyOptions
+	importOptions   ImportOptions
+	watchOptions    WatchOptions
+	syncOptions     SyncOptions
+	testOptions     TestOptions
+	toolsOptions    ToolsOptions
+	diffOptions     DiffOptions
+	statusOptions   StatusOptions
+	validateOptions ValidateOptions
+}
 
-// runCommand executes the specified command with the given YAML file
-func (r *CLICommandRunner) runCommand(command, yamlFile string) {
+// runCommand executes the specified command with the given YAML file. The
+// error carries the exit code, see exitCode.
+func (r *CLICommandRunner) runCommand(command, yamlFile string) error {
 	switch command {
 	case CommandApply:
-		applyConfigFunc(yamlFile)
+		return applyConfigFunc(yamlFile, r.applyOptions)
+	case CommandDiff:
+		return diffFunc(yamlFile, r.diffOptions)
+	case CommandValidate:
+		return validateFunc(yamlFile, r.validateOptions)
 	case CommandImport:
-		importConfigFunc(yamlFile)
-	default:
-		fmt.Printf("Unknown command: %s\n", command)
-		printUsage()
-		osExit(1)
+		return importConfigFunc(yamlFile, r.importOptions)
+	case CommandStatus:
+		return statusFunc(yamlFile, r.statusOptions)
+	case CommandDoctor:
+		return doctorFunc(yamlFile)
+	case CommandWatch:
+		return watchFunc(yamlFile, r.watchOptions)
+	case CommandSync:
+		return syncFunc(yamlFile, r.syncOptions)
+	case CommandTest:
+		return testFunc(yamlFile, r.testOptions)
+	case CommandTools:
+		return toolsFunc(yamlFile, r.toolsOptions)
+	case CommandConfigRender:
+		return renderConfigFunc(yamlFile)
 	}
+	return errorf("Unknown command: %s", command)
 }
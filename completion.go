@@ -0,0 +1,349 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"strings"
+)
+
+const (
+	// CommandComplete is the hidden command the completion scripts call
+	// with the words typed so far. It prints one candidate per line.
+	CommandComplete = "__complete"
+
+	// completeFiles tells the completion scripts to complete file names.
+	completeFiles = ":files"
+)
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+const bashCompletion = `# bash completion for mcpyammy
+# Load it with: source <(mcpyammy completion bash)
+_mcpyammy() {
+    local line="${COMP_LINE:0:COMP_POINT}"
+    local -a words
+    read -ra words <<< "$line"
+    [[ $line == *[[:space:]] ]] && words+=("")
+    local cur="${words[${#words[@]}-1]}"
+    [[ $cur == --*=* ]] && cur="${cur#*=}"
+
+    local IFS=$'\n'
+    local -a candidates
+    candidates=($(mcpyammy __complete "${words[@]:1}" 2>/dev/null))
+    if [[ ${candidates[0]} == ":files" ]]; then
+        compopt -o filenames 2>/dev/null
+        COMPREPLY=($(compgen -f -- "$cur"))
+        return
+    fi
+    COMPREPLY=("${candidates[@]#--*=}")
+}
+complete -F _mcpyammy mcpyammy
+`
+
+const zshCompletion = `#compdef mcpyammy
+# Load it with: source <(mcpyammy completion zsh)
+# or save it as _mcpyammy in a directory of $fpath.
+_mcpyammy() {
+    local -a candidates
+    candidates=("${(@f)$(mcpyammy __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ ${candidates[1]} == ":files" ]]; then
+        _files
+        return
+    fi
+    [[ -n ${candidates[1]} ]] && compadd -Q -- "${candidates[@]}"
+}
+if [ "$funcstack[1]" = "_mcpyammy" ]; then
+    _mcpyammy "$@"
+else
+    compdef _mcpyammy mcpyammy
+fi
+`
+
+const fishCompletion = `# fish completion for mcpyammy
+# Load it with: mcpyammy completion fish | source
+function __mcpyammy_complete
+    set -l words (commandline -opc)
+    set -e words[1]
+    set -l out (mcpyammy __complete $words (commandline -ct) 2>/dev/null)
+    if test "$out[1]" = ":files"
+        __fish_complete_path (commandline -ct)
+        return
+    end
+    printf '%s\n' $out
+end
+complete -c mcpyammy -f -a '(__mcpyammy_complete)'
+`
+
+// printCompletion writes the completion script for shell.
+func printCompletion(out io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		io.WriteString(out, bashCompletion)
+	case "zsh":
+		io.WriteString(out, zshCompletion)
+	case "fish":
+		io.WriteString(out, fishCompletion)
+	default:
+		return errorf("unsupported shell %q (expected %s)", shell, strings.Join(completionShells, ", "))
+	}
+	return nil
+}
+
+// completionLine is what has been typed before the word being completed.
+type completionLine struct {
+	config     string
+	client     string
+	command    string
+	positional []string
+	// flag is the flag waiting for its value, if any
+	flag string
+}
+
+// parseCompletionLine walks the words before the cursor like main does,
+// without failing on mistakes.
+func parseCompletionLine(words []string) completionLine {
+	var line completionLine
+	for _, word := range words {
+		if line.flag != "" {
+			line.setFlag(line.flag, word)
+			line.flag = ""
+			continue
+		}
+		if strings.HasPrefix(word, "-") && word != "-" {
+			name, value, hasValue := strings.Cut(strings.TrimLeft(word, "-"), "=")
+			if hasValue {
+				line.setFlag(name, value)
+			}
+			if !hasValue && flagTakesValue(line.command, name) {
+				line.flag = name
+			}
+			continue
+		}
+		switch {
+		case line.command == "":
+			line.command = word
+		case line.command == CommandConfig && len(line.positional) == 0 && word == SubcommandRender:
+			line.command = CommandConfigRender
+		default:
+			line.positional = append(line.positional, word)
+		}
+	}
+	return line
+}
+
+// setFlag keeps the flag values completion depends on.
+func (line *completionLine) setFlag(name, value string) {
+	switch name {
+	case "config":
+		line.config = value
+	case "client":
+		line.client = value
+	}
+}
+
+// completionFlags returns the flags command accepts, or the global flags
+// before a command is given.
+func completionFlags(command string) *flag.FlagSet {
+	if findCommand(command) == nil {
+		fs := newFlagSet("mcpyammy")
+		(&globalOptions{}).register(fs)
+		fs.Bool("version", false, "")
+		return fs
+	}
+	fs, _ := commandFlagSet(command, &CLICommandRunner{}, &globalOptions{})
+	return fs
+}
+
+func flagTakesValue(command, name string) bool {
+	f := completionFlags(command).Lookup(name)
+	if f == nil {
+		return false
+	}
+	if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return false
+	}
+	return true
+}
+
+// completeWords returns the candidates for the last of words, given the
+// words typed before it. Names of clients and servers are read from the YAML
+// the command would use.
+func completeWords(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+	line := parseCompletionLine(words[:len(words)-1])
+
+	if line.flag != "" {
+		return filterCandidates(flagValues(line, line.flag), current, listPrefix(line.flag, current, ""))
+	}
+	if strings.HasPrefix(current, "-") {
+		if name, _, hasValue := strings.Cut(strings.TrimLeft(current, "-"), "="); hasValue {
+			prefix := current[:strings.Index(current, "=")+1]
+			return filterCandidates(flagValues(line, name), current, listPrefix(name, current, prefix))
+		}
+		var flags []string
+		completionFlags(line.command).VisitAll(func(f *flag.Flag) {
+			flags = append(flags, flagName(f.Name))
+		})
+		flags = append(flags, "--help")
+		return filterCandidates(flags, current, "")
+	}
+
+	var candidates []string
+	switch line.command {
+	case "":
+		seen := make(map[string]bool)
+		for _, cmd := range cliCommands {
+			name, _, _ := strings.Cut(cmd.name, " ")
+			if !seen[name] {
+				seen[name] = true
+				candidates = append(candidates, name)
+			}
+		}
+		candidates = append(candidates, CommandHelp)
+	case CommandConfig:
+		if len(line.positional) == 0 {
+			candidates = []string{SubcommandRender}
+		}
+	case CommandHelp:
+		if len(line.positional) == 0 {
+			for _, cmd := range cliCommands {
+				name, _, _ := strings.Cut(cmd.name, " ")
+				candidates = append(candidates, name)
+			}
+		}
+	default:
+		cmd := findCommand(line.command)
+		if cmd == nil || len(line.positional) >= cmd.maxArgs() {
+			return nil
+		}
+		switch cmd.args {
+		case argsYAML:
+			return []string{completeFiles}
+		case argsShell:
+			candidates = completionShells
+		case argsClientServer:
+			if len(line.positional) == 0 {
+				candidates = completionClients(line)
+			} else {
+				candidates = completionServers(line, line.positional[0])
+			}
+		}
+	}
+	return filterCandidates(candidates, current, "")
+}
+
+// flagValues returns the values a flag can take.
+func flagValues(line completionLine, name string) []string {
+	switch name {
+	case "config":
+		return []string{completeFiles}
+	case "client":
+		return completionClients(line)
+	case "server":
+		client := ""
+		if clients := parseNameList(line.client); len(clients) == 1 {
+			client = clients[0]
+		}
+		return completionServers(line, client)
+	case "output":
+		return []string{string(OutputText), string(OutputJSON)}
+	case "lang":
+		var values []string
+		for _, l := range langs {
+			values = append(values, string(l))
+		}
+		return values
+	case "on-conflict":
+		var values []string
+		if line.command == CommandSync {
+			for _, choice := range syncChoices {
+				values = append(values, string(choice))
+			}
+		} else {
+			for _, choice := range conflictChoices {
+				values = append(values, string(choice))
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// listPrefix extends prefix with the names already typed in a comma
+// separated --client or --server value.
+func listPrefix(name, current, prefix string) string {
+	if name != "client" && name != "server" {
+		return prefix
+	}
+	if i := strings.LastIndex(current, ","); i >= len(prefix) {
+		return current[:i+1]
+	}
+	return prefix
+}
+
+// filterCandidates keeps the candidates that start with the typed word,
+// after prefix, and puts prefix back in front of them.
+func filterCandidates(candidates []string, current, prefix string) []string {
+	if len(candidates) == 1 && candidates[0] == completeFiles {
+		return candidates
+	}
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(prefix+candidate, current) {
+			matches = append(matches, prefix+candidate)
+		}
+	}
+	return matches
+}
+
+// completionYAML loads the YAML the command on the line would use. Errors
+// are ignored, since completion has nowhere to report them.
+func completionYAML(line completionLine) map[string]interface{} {
+	var positional []string
+	if cmd := findCommand(line.command); cmd != nil && cmd.args == argsYAML {
+		positional = line.positional
+	}
+	yamlFile, ok := commandYAMLFile(positional, line.config)
+	if !ok {
+		return nil
+	}
+	yamlContent, err := loadEffectiveYAML(yamlFile)
+	if err != nil {
+		return nil
+	}
+	return yamlContent
+}
+
+func completionClients(line completionLine) []string {
+	var names []string
+	for _, entry := range clientEntries(completionYAML(line)) {
+		names = append(names, entry.name)
+	}
+	return names
+}
+
+// completionServers lists the servers of client, or of every client when
+// client is empty.
+func completionServers(line completionLine, client string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, entry := range clientEntries(completionYAML(line)) {
+		if client != "" && entry.name != client {
+			continue
+		}
+		config, ok := entry.config.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, name := range extractClientServerNames(config) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
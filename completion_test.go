@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompleteWords 補完候補のテスト
+func TestCompleteWords(t *testing.T) {
+	home := isolateConfigDiscovery(t)
+	require.NoError(t, os.WriteFile(filepath.Join(home, DefaultYAMLFile), []byte(`clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: fetch
+      command: uvx
+    - name: git
+      command: uvx
+  cursor:
+    path: .cursor/mcp.json
+    servers:
+    - name: fetch
+      command: uvx
+    - name: github
+      command: npx
+`), 0600))
+	other := filepath.Join(home, "other.yaml")
+	require.NoError(t, os.WriteFile(other, []byte(`clients:
+  zed:
+    path: .config/zed/settings.json
+    servers:
+    - name: time
+      command: uvx
+`), 0600))
+
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{""}, []string{"apply", "diff", "validate", "import", "status", "sync", "watch", "test", "tools", "doctor", "config", "completion", "help"}},
+		{[]string{"d"}, []string{"diff", "doctor"}},
+		{[]string{"--v"}, []string{"--version"}},
+		{[]string{"config", ""}, []string{"render"}},
+		{[]string{"help", "v"}, []string{"validate"}},
+		{[]string{"completion", ""}, []string{"bash", "zsh", "fish"}},
+		{[]string{"completion", "zsh", ""}, nil},
+		{[]string{"apply", ""}, []string{completeFiles}},
+		{[]string{"apply", "--f"}, []string{"--force-reset"}},
+		{[]string{"watch", "--interval", ""}, nil},
+		{[]string{"diff", "--client", ""}, []string{"claude", "cursor"}},
+		{[]string{"diff", "--client", "cl"}, []string{"claude"}},
+		{[]string{"diff", "--client=cu"}, []string{"--client=cursor"}},
+		{[]string{"--config", other, "diff", "--client", ""}, []string{"zed"}},
+		{[]string{"diff", "--config=" + other, "--client", ""}, []string{"zed"}},
+		{[]string{"diff", other, "--client", ""}, []string{"zed"}},
+		{[]string{"diff", "--config", ""}, []string{completeFiles}},
+		{[]string{"test", ""}, []string{"claude", "cursor"}},
+		{[]string{"test", "cursor", "g"}, []string{"github"}},
+		{[]string{"tools", "--budget", "100", "claude", ""}, []string{"fetch", "git"}},
+		{[]string{"test", "claude", "fetch", ""}, nil},
+		{[]string{"sync", "--on-conflict", ""}, []string{"ask", "yaml", "client", "skip"}},
+		{[]string{"import", "--on-conflict=b"}, []string{"--on-conflict=both"}},
+		{[]string{"apply", "--client", "claude,"}, []string{"claude,claude", "claude,cursor"}},
+		{[]string{"apply", "--client=claude,cu"}, []string{"--client=claude,cursor"}},
+		{[]string{"apply", "--client", "cursor", "--server", "g"}, []string{"github"}},
+		{[]string{"import", "--server", "f"}, []string{"fetch"}},
+		{[]string{"status", "--output", ""}, []string{"text", "json"}},
+		{[]string{"--lang=j"}, []string{"--lang=ja"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, completeWords(tt.words), "%q", tt.words)
+	}
+}
+
+// TestCompleteWords_BrokenYAML YAMLが読めない場合は候補なしのテスト
+func TestCompleteWords_BrokenYAML(t *testing.T) {
+	home := isolateConfigDiscovery(t)
+	require.NoError(t, os.WriteFile(filepath.Join(home, DefaultYAMLFile), []byte("clients: [\n"), 0600))
+	assert.Empty(t, completeWords([]string{"test", ""}))
+	assert.Equal(t, []string{"doctor"}, completeWords([]string{"doc"}))
+}
+
+// TestPrintCompletion シェルごとの補完スクリプトのテスト
+func TestPrintCompletion(t *testing.T) {
+	for _, shell := range completionShells {
+		var out bytes.Buffer
+		require.NoError(t, printCompletion(&out, shell))
+		assert.Contains(t, out.String(), "mcpyammy "+CommandComplete, shell)
+		assert.Contains(t, out.String(), completeFiles, shell)
+	}
+	assert.EqualError(t, printCompletion(&bytes.Buffer{}, "tcsh"), `unsupported shell "tcsh" (expected bash, zsh, fish)`)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+)
+
+const whenKey = "when"
+
+var (
+	goosFunc     = func() string { return runtime.GOOS }
+	hostnameFunc = os.Hostname
+	lookupEnv    = os.LookupEnv
+	lookPathFunc = exec.LookPath
+)
+
+// applyConditions removes clients and servers whose `when` conditions do not
+// match the current machine. The `when` keys themselves are stripped so they
+// never reach client configuration files.
+func applyConditions(yamlContent map[string]interface{}) error {
+	var kept []clientEntry
+	for _, entry := range clientEntries(yamlContent) {
+		config, ok := entry.config.(map[string]interface{})
+		if !ok {
+			kept = append(kept, entry)
+			continue
+		}
+		matched, err := evaluateWhen(config[whenKey])
+		if err != nil {
+			return withCode(CodeInvalidYAML, errorf("Invalid when condition (client '%s'): %v", entry.name, err))
+		}
+		if !matched {
+			logSkip(entry.name, "when does not match this machine")
+			continue
+		}
+		delete(config, whenKey)
+		kept = append(kept, entry)
+
+		servers, ok := config["servers"].([]interface{})
+		if !ok {
+			continue
+		}
+		filtered := make([]interface{}, 0, len(servers))
+		for _, serverData := range servers {
+			server, ok := serverData.(map[string]interface{})
+			if !ok {
+				filtered = append(filtered, serverData)
+				continue
+			}
+			matched, err := evaluateWhen(server[whenKey])
+			if err != nil {
+				return withCode(CodeInvalidYAML, errorf("Invalid when condition (client '%s', server '%v'): %v", entry.name, server["name"], err))
+			}
+			if !matched {
+				logger.Info("server skipped", "client", entry.name, "server", server["name"], "reason", "when does not match this machine")
+				continue
+			}
+			delete(server, whenKey)
+			filtered = append(filtered, server)
+		}
+		config["servers"] = filtered
+	}
+	setClientEntries(yamlContent, kept)
+	return nil
+}
+
+// evaluateWhen reports whether every condition in a `when` block holds.
+// A missing block always matches. Every condition is checked before any is
+// evaluated, so a mistake is reported even when another condition already
+// fails on this machine.
+func evaluateWhen(when interface{}) (bool, error) {
+	if when == nil {
+		return true, nil
+	}
+	conditions, ok := when.(map[string]interface{})
+	if !ok {
+		return false, errorf("when must be a map")
+	}
+	keys := sortedKeys(conditions)
+	parsed := make(map[string][][]string, len(keys))
+	for _, key := range keys {
+		switch key {
+		case "os", "hostname", "env", "command_exists":
+		default:
+			return false, errorf("unsupported condition: %s", key)
+		}
+		groups, err := conditionGroups(conditions[key])
+		if err != nil {
+			return false, fmt.Errorf("%s: %v", key, err)
+		}
+		parsed[key] = groups
+	}
+
+	for _, key := range keys {
+		groups := parsed[key]
+		var matched bool
+		switch key {
+		case "os":
+			matched = matchAny(flatten(groups), func(v string) bool { return v == goosFunc() })
+		case "hostname":
+			host, err := hostnameFunc()
+			if err != nil {
+				return false, errorf("could not get the host name: %v", err)
+			}
+			matched = matchAny(flatten(groups), func(pattern string) bool {
+				ok, _ := path.Match(pattern, host)
+				return ok
+			})
+		case "env":
+			matched = matchGroups(groups, envConditionHolds)
+		case "command_exists":
+			matched = matchGroups(groups, func(cmd string) bool {
+				_, err := lookPathFunc(cmd)
+				return err == nil
+			})
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// conditionGroups accepts either a scalar ("darwin|linux") or a list of
+// scalars. Each scalar becomes one group of "|"-separated alternatives.
+func conditionGroups(value interface{}) ([][]string, error) {
+	switch v := value.(type) {
+	case string:
+		return [][]string{splitAlternatives(v)}, nil
+	case []interface{}:
+		groups := make([][]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, errorf("must be a string: %v", item)
+			}
+			groups = append(groups, splitAlternatives(s))
+		}
+		return groups, nil
+	default:
+		return nil, errorf("must be a string or a list: %v", value)
+	}
+}
+
+func splitAlternatives(s string) []string {
+	var values []string
+	for _, part := range strings.Split(s, "|") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+func flatten(groups [][]string) []string {
+	var values []string
+	for _, group := range groups {
+		values = append(values, group...)
+	}
+	return values
+}
+
+// envConditionHolds supports "VAR" (set and non-empty), "!VAR" (unset or
+// empty) and "VAR=value" (exact match).
+func envConditionHolds(expr string) bool {
+	if name, found := strings.CutPrefix(expr, "!"); found {
+		value, ok := lookupEnv(name)
+		return !ok || value == ""
+	}
+	if name, want, found := strings.Cut(expr, "="); found {
+		value, ok := lookupEnv(name)
+		return ok && value == want
+	}
+	value, ok := lookupEnv(expr)
+	return ok && value != ""
+}
+
+func matchAny(values []string, fn func(string) bool) bool {
+	for _, v := range values {
+		if fn(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGroups requires every group to have at least one matching alternative.
+func matchGroups(groups [][]string, fn func(string) bool) bool {
+	for _, group := range groups {
+		if !matchAny(group, fn) {
+			return false
+		}
+	}
+	return true
+}
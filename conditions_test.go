@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApplyConditions_FiltersClientsAndServers when条件によるクライアント・サーバーの絞り込みテスト
+func TestApplyConditions_FiltersClientsAndServers(t *testing.T) {
+	originalGOOS, originalHostname, originalLookupEnv := goosFunc, hostnameFunc, lookupEnv
+	defer func() { goosFunc, hostnameFunc, lookupEnv = originalGOOS, originalHostname, originalLookupEnv }()
+
+	goosFunc = func() string { return "linux" }
+	hostnameFunc = func() (string, error) { return "work-laptop", nil }
+	lookupEnv = func(key string) (string, bool) {
+		if key == "GITHUB_TOKEN" {
+			return "secret", true
+		}
+		return "", false
+	}
+
+	yamlContent := map[string]interface{}{
+		"clients": map[string]interface{}{
+			"claude": map[string]interface{}{
+				"path": ".claude.json",
+				"servers": []interface{}{
+					map[string]interface{}{"name": "always", "command": "npx"},
+					map[string]interface{}{"name": "github", "command": "npx",
+						"when": map[string]interface{}{"env": "GITHUB_TOKEN", "hostname": "work-*"}},
+					map[string]interface{}{"name": "mac-only", "command": "npx",
+						"when": map[string]interface{}{"os": "darwin"}},
+				},
+			},
+			"amazonq": map[string]interface{}{
+				"path": ".aws/amazonq/mcp.json",
+				"when": map[string]interface{}{"os": "darwin|windows"},
+			},
+		},
+	}
+
+	require.NoError(t, applyConditions(yamlContent))
+
+	assert.Nil(t, clientConfigByName(yamlContent, "amazonq"), "OS条件に一致しないクライアントは除外されるべき")
+
+	servers := extractClientServers(clientConfigByName(yamlContent, "claude"))
+	assert.Contains(t, servers, "always")
+	assert.Contains(t, servers, "github")
+	assert.NotContains(t, servers, "mac-only")
+	assert.NotContains(t, servers["github"], "when", "when条件はクライアント設定に書き出されないべき")
+}
+
+// TestEvaluateWhen_UnknownCondition 未対応の条件キーのエラーテスト
+func TestEvaluateWhen_UnknownCondition(t *testing.T) {
+	_, err := evaluateWhen(map[string]interface{}{"arch": "arm64"})
+	assert.Error(t, err)
+}
+
+// TestEvaluateWhen_UnknownConditionWithOtherKeys 一致しない条件があっても未対応のキーを必ずエラーにするテスト
+func TestEvaluateWhen_UnknownConditionWithOtherKeys(t *testing.T) {
+	originalGOOS := goosFunc
+	defer func() { goosFunc = originalGOOS }()
+	goosFunc = func() string { return "linux" }
+
+	// map order is random, so repeat to catch order dependent results
+	for i := 0; i < 50; i++ {
+		matched, err := evaluateWhen(map[string]interface{}{"arch": "arm64", "os": "darwin"})
+		require.Error(t, err)
+		assert.Equal(t, "unsupported condition: arch", err.Error())
+		assert.False(t, matched)
+
+		_, err = evaluateWhen(map[string]interface{}{"os": "darwin", "env": 1})
+		require.Error(t, err)
+		assert.Equal(t, "env: must be a string or a list: 1", err.Error())
+	}
+}
+
+func clientConfigByName(yamlContent map[string]interface{}, name string) map[string]interface{} {
+	for _, entry := range clientEntries(yamlContent) {
+		if entry.name == name {
+			config, _ := entry.config.(map[string]interface{})
+			return config
+		}
+	}
+	return nil
+}
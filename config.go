@@ -1,104 +1,306 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/goccy/go-yaml"
+	"github.com/mattn/go-isatty"
 )
 
-func applyConfig(yamlFile string) {
-	processor := &BaseProcessor{}
+// isInteractive reports whether apply can ask for confirmation. Tests
+// replace it.
+var isInteractive = func() bool {
+	return isTerminal(os.Stdin) && isTerminal(os.Stdout)
+}
 
-	yamlContent, err := processor.loadAndValidateYAML(yamlFile)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "%v\n", err)
-		os.Exit(1)
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// promptApply asks whether to write the planned changes. Tests replace it.
+var promptApply = func(in *bufio.Reader, out io.Writer) bool {
+	fmt.Fprint(out, "\n"+msgf("Apply these changes? [y/N] "))
+	line, _ := in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
 	}
+	return false
+}
 
-	processedCount, err := processor.processClients(yamlContent, processClientConfig)
+func applyConfig(yamlFile string, options ApplyOptions) error {
+	// on a terminal the plan is shown and confirmed first, unless --yes
+	// asks for an unattended run
+	if !options.DryRun && !options.Yes && options.Output != OutputJSON && isInteractive() {
+		planOptions := options
+		planOptions.DryRun = true
+		plan, err := applyClients(yamlFile, planOptions)
+		if err != nil {
+			return err
+		}
+		if !printApplyPlan(plan) {
+			return nil
+		}
+		if !promptApply(bufio.NewReader(os.Stdin), os.Stdout) {
+			fmt.Println(msgf("Nothing was written"))
+			return nil
+		}
+		fmt.Println()
+	}
+
+	results, err := applyClients(yamlFile, options)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%v\n", err)
-		os.Exit(1)
+		return failCommand(options.Output, CommandApply, yamlFile, err)
+	}
+	if err := recordResults(results); err != nil {
+		fmt.Fprintln(os.Stderr, msgf("Warning: could not record apply state: %v", err))
+	}
+
+	exitCode := ExitOK
+	processedCount, failedCount := 0, 0
+	for _, result := range results {
+		switch {
+		case result.failed():
+			exitCode = ExitPartialFailure
+			failedCount++
+		case result.skipped():
+		case !options.DryRun || result.written:
+			processedCount++
+		}
 	}
 
-	if processedCount > 0 {
-		fmt.Printf("\nSuccessfully processed %d client(s)\n", processedCount)
+	if options.Output == OutputJSON {
+		report := newJSONReport(CommandApply, yamlFile)
+		report.ExitCode = exitCode
+		report.DryRun = options.DryRun
+		for _, result := range results {
+			report.Clients = append(report.Clients, result.jsonClient())
+			if result.failed() {
+				jsonErr := newJSONError(result.err)
+				jsonErr.Client = result.name
+				report.Errors = append(report.Errors, *jsonErr)
+			}
+		}
+		writeJSONReport(os.Stdout, report)
+	} else if options.DryRun {
+		if printApplyPlan(results) {
+			fmt.Println("\n" + msgf("Dry run: %d client(s) would be updated; nothing was written", processedCount))
+		}
 	} else {
-		fmt.Println("\nNo clients were processed")
+		for _, result := range results {
+			fmt.Println(result.line())
+		}
+		if processedCount > 0 {
+			fmt.Println("\n" + msgf("Successfully processed %d client(s)", processedCount))
+		} else {
+			fmt.Println("\n" + msgf("No clients were processed"))
+		}
+	}
+	if exitCode != ExitOK {
+		return reportedExit(exitCode, "%d client(s) could not be applied", failedCount)
+	}
+	return nil
+}
+
+// printApplyPlan writes what apply would do and reports whether any client
+// would change or fail.
+func printApplyPlan(plan []*clientResult) bool {
+	pending := false
+	for _, result := range plan {
+		for _, line := range result.planLines() {
+			fmt.Println(line)
+		}
+		if result.written || result.failed() {
+			pending = true
+		}
 	}
+	if !pending {
+		fmt.Println("\n" + msgf("All clients are up to date"))
+	}
+	return pending
 }
 
-func importConfig(yamlFile string) {
+// applyClients writes the selected clients of the YAML, or with DryRun only
+// plans them, and returns the result of each in YAML order. Callers record
+// the written clients with recordResults.
+func applyClients(yamlFile string, options ApplyOptions) ([]*clientResult, error) {
 	processor := &BaseProcessor{}
 
-	yamlContent, err := processor.loadAndValidateYAML(yamlFile)
+	yamlContent, err := processor.loadEffectiveYAML(yamlFile)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%v\n", err)
-		os.Exit(1)
+		return nil, err
+	}
+	if err := applySelection(yamlContent, yamlFile, options.Selection); err != nil {
+		return nil, err
 	}
 
-	homeDir, err := processor.getHomeDir()
+	var results []*clientResult
+	_, failures, err := processor.processClients(yamlContent, func(clientName string, config map[string]interface{}, homeDir string) error {
+		result := applyClient(clientName, config, homeDir, options)
+		results = append(results, result)
+		return result.err
+	})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error getting home directory: %v\n", err)
-		os.Exit(1)
+		return nil, err
+	}
+	// clients that are not mappings never reach processFunc
+	order := make(map[string]int)
+	for i, entry := range clientEntries(yamlContent) {
+		order[entry.name] = i
+	}
+	for _, failure := range failures {
+		if !slices.ContainsFunc(results, func(r *clientResult) bool { return r.name == failure.name }) {
+			results = append(results, &clientResult{name: failure.name, err: failure.err})
+		}
 	}
+	slices.SortStableFunc(results, func(a, b *clientResult) int { return order[a.name] - order[b.name] })
+	return results, nil
+}
 
-	clients := yamlContent["clients"].(map[string]interface{})
-	outputYaml := map[string]interface{}{
-		"clients": make(map[string]interface{}),
+// recordResults records the clients whose files apply wrote as applied now.
+func recordResults(results []*clientResult) error {
+	var applied []string
+	for _, result := range results {
+		if result.err == nil && result.written && !result.dryRun {
+			applied = append(applied, result.path)
+		}
 	}
-	outputClients := outputYaml["clients"].(map[string]interface{})
+	return recordApplied(applied)
+}
+
+func importConfig(yamlFile string, options ImportOptions) error {
+	plan, err := planImport(yamlFile, options.Selection)
+	if err != nil {
+		return failCommand(options.Output, CommandImport, yamlFile, err)
+	}
+
+	if options.OnConflict == "" {
+		options.OnConflict = ConflictKeepYAML
+	}
+	plan.resolveAll(options.OnConflict)
+
 	importedCount := 0
+	exitCode := ExitOK
+	for _, ci := range plan.clients {
+		if ci.err == nil {
+			importedCount++
+		} else if ci.failed() {
+			exitCode = ExitPartialFailure
+		}
+	}
+	if importedCount == 0 {
+		exitCode = ExitError
+	}
 
-	for clientName, clientConfig := range clients {
-		config, ok := clientConfig.(map[string]interface{})
-		if !ok {
-			fmt.Printf("Invalid configuration for client '%s'\n", clientName)
-			continue
+	var yamlBytes []byte
+	if importedCount > 0 {
+		if yamlBytes, err = plan.render(); err != nil {
+			return failCommand(options.Output, CommandImport, yamlFile, errorf("Error converting to YAML: %v", err))
+		}
+	}
+
+	if options.Output == OutputJSON {
+		report := newJSONReport(CommandImport, yamlFile)
+		report.ExitCode = exitCode
+		report.YAML = string(yamlBytes)
+		for _, ci := range plan.clients {
+			report.Clients = append(report.Clients, ci.jsonClient())
+			if ci.failed() {
+				jsonErr := newJSONError(ci.err)
+				jsonErr.Client = ci.name
+				report.Errors = append(report.Errors, *jsonErr)
+			}
 		}
+		writeJSONReport(os.Stdout, report)
+		if exitCode != ExitOK {
+			return reportedExit(exitCode, "import failed for some clients")
+		}
+		return nil
+	}
 
-		if err := processSingleClientImportWithOutput(clientName, config, homeDir, outputClients); err != nil {
+	for _, ci := range plan.clients {
+		if ci.err != nil {
+			fmt.Println(msgf("- %s: %s (path preserved)", ci.name, ci.status))
 			continue
 		}
-		importedCount++
+		fmt.Println(msgf("✓ Imported %s from %s", ci.name, ci.path))
+		for _, line := range ci.summaryLines() {
+			fmt.Println(line)
+		}
 	}
 
 	if importedCount == 0 {
-		fmt.Println("No configurations were imported")
-		os.Exit(1)
+		fmt.Println(msgf("No configurations were imported"))
+		return reportedExit(exitCode, "no configurations were imported")
 	}
 
-	yamlBytes, err := yaml.Marshal(outputYaml)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error converting to YAML: %v\n", err)
-		os.Exit(1)
+	if len(plan.conflicts) > 0 {
+		fmt.Println("\n" + msgf("%d conflict(s) resolved with --on-conflict=%s", len(plan.conflicts), options.OnConflict))
 	}
 
-	fmt.Printf("\n--- Imported YAML configuration ---\n")
+	fmt.Println("\n" + msgf("--- Imported YAML configuration ---"))
 	fmt.Println(string(yamlBytes))
+	if exitCode != ExitOK {
+		return reportedExit(exitCode, "import failed for some clients")
+	}
+	return nil
+}
+
+func renderConfig(yamlFile string) error {
+	tree, err := loadConfigTree(yamlFile)
+	if err != nil {
+		return err
+	}
+
+	yamlBytes, err := yaml.Marshal(tree)
+	if err != nil {
+		return errorf("Error converting to YAML: %v", err)
+	}
+	fmt.Print(string(yamlBytes))
+	return nil
 }
 
 func loadAndValidateYAML(yamlFile string) (map[string]interface{}, error) {
-	yamlData, err := os.ReadFile(yamlFile)
+	tree, err := loadConfigTree(yamlFile)
 	if err != nil {
-		return nil, fmt.Errorf("YAMLファイル読み込みエラー: %v", err)
+		return nil, err
+	}
+	clients, ok := mapSliceValue(tree, "clients").(yaml.MapSlice)
+	if !ok {
+		return nil, withCode(CodeInvalidYAML, errorf("YAML has no clients section"))
+	}
+	// clients stay ordered so every command walks them in YAML order
+	yamlContent := toPlainValue(tree).(map[string]interface{})
+	entries := make([]clientEntry, 0, len(clients))
+	for _, item := range clients {
+		entries = append(entries, clientEntry{name: fmt.Sprint(item.Key), config: toPlainValue(item.Value)})
 	}
-	var yamlContent map[string]interface{}
-	if err := parseYAMLSafely(yamlData, MaxYAMLSize, &yamlContent); err != nil {
-		return nil, fmt.Errorf("YAML検証エラー: %v", err)
+	setClientEntries(yamlContent, entries)
+	return yamlContent, nil
+}
+
+// loadEffectiveYAML loads the YAML and drops clients and servers whose `when`
+// conditions do not match this machine.
+func loadEffectiveYAML(yamlFile string) (map[string]interface{}, error) {
+	yamlContent, err := loadAndValidateYAML(yamlFile)
+	if err != nil {
+		return nil, err
 	}
-	if _, ok := yamlContent["clients"].(map[string]interface{}); !ok {
-		return nil, fmt.Errorf("YAMLにclientsセクションが見つかりません")
+	if err := applyConditions(yamlContent); err != nil {
+		return nil, err
 	}
 	return yamlContent, nil
 }
 
 func validateSafePath(pathStr, homeDir string) (string, error) {
 	if pathStr == "" {
-		return "", fmt.Errorf("パスが指定されていません")
+		return "", errorf("no path given")
 	}
 
 	cleanPath := filepath.Clean(pathStr)
@@ -113,14 +315,15 @@ func validateSafePath(pathStr, homeDir string) (string, error) {
 	}
 	relPath, err := filepath.Rel(homeDir, resolvedPath)
 	if err != nil {
-		return "", fmt.Errorf("パスの検証に失敗しました: %v", err)
+		return "", errorf("could not check the path: %v", err)
 	}
 	if strings.HasPrefix(relPath, "..") {
-		return "", fmt.Errorf("ホームディレクトリ外へのアクセスは許可されていません: %s", pathStr)
+		return "", newError(ErrPathOutsideHome, nil, "access outside the home directory is not allowed: %s", pathStr)
 	}
 	absPath, err := filepath.Abs(resolvedPath)
 	if err != nil {
-		return "", fmt.Errorf("絶対パスの取得に失敗しました: %v", err)
+		return "", errorf("could not get the absolute path: %v", err)
 	}
+	logger.Debug("path resolved", "path", pathStr, "resolved", absPath)
 	return absPath, nil
 }
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// diffContext is how many unchanged lines surround each change.
+	diffContext = 3
+
+	// maxDiffCells bounds the line comparison of the changed middle of a
+	// file; beyond it the whole middle is shown as replaced.
+	maxDiffCells = 4 * 1024 * 1024
+)
+
+// DiffOptions selects the clients `diff` shows.
+type DiffOptions struct {
+	Client string
+	Output OutputFormat
+}
+
+// diffOp is one line of a line diff: ' ' unchanged, '-' removed, '+' added.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines compares two files line by line. Apply changes only mcpServers,
+// so the common prefix and suffix are skipped before the middle is compared
+// with a longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+func diffMiddle(a, b []string) []diffOp {
+	var ops []diffOp
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// unifiedDiff renders ops as unified diff hunks. Changes closer than twice
+// the context share a hunk.
+func unifiedDiff(out io.Writer, ops []diffOp) {
+	var changes []int
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	for k := 0; k < len(changes); {
+		first, last := changes[k], changes[k]
+		for k++; k < len(changes) && changes[k]-last-1 <= 2*diffContext; k++ {
+			last = changes[k]
+		}
+		from, to := max(first-diffContext, 0), min(last+1+diffContext, len(ops))
+
+		oldStart, newStart := 1, 1
+		for _, op := range ops[:from] {
+			if op.kind != '+' {
+				oldStart++
+			}
+			if op.kind != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		for _, op := range ops[from:to] {
+			line := op.line
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n\\ No newline at end of file\n"
+			}
+			fmt.Fprintf(out, "%c%s", op.kind, line)
+		}
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// clientDiff is what apply would change in one client file.
+type clientDiff struct {
+	name          string
+	path          string
+	before, after []byte
+	added         []string
+	changed       []string
+	err           error
+}
+
+func (d *clientDiff) differs() bool {
+	return d.err == nil && !bytes.Equal(d.before, d.after)
+}
+
+// skipped reports whether the client was left out because it has no
+// servers, as apply leaves it out.
+func (d *clientDiff) skipped() bool {
+	return errors.Is(d.err, ErrNoServers)
+}
+
+// failed reports whether the diff of the client could not be computed.
+func (d *clientDiff) failed() bool {
+	return d.err != nil && !d.skipped()
+}
+
+// planDiffs computes the content apply would write for each client.
+func planDiffs(yamlFile string, options DiffOptions) ([]*clientDiff, error) {
+	yamlContent, err := loadEffectiveYAML(yamlFile)
+	if err != nil {
+		return nil, err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errorf("error getting home directory: %v", err)
+	}
+
+	var diffs []*clientDiff
+	for _, entry := range clientEntries(yamlContent) {
+		if options.Client != "" && entry.name != options.Client {
+			continue
+		}
+		d := &clientDiff{name: entry.name}
+		diffs = append(diffs, d)
+		config, ok := entry.config.(map[string]interface{})
+		if !ok {
+			d.err = withCode(CodeInvalidConfig, errorf("invalid configuration"))
+			continue
+		}
+		pathStr, _ := config["path"].(string)
+		if d.path, d.err = validateSafePath(pathStr, homeDir); d.err != nil {
+			d.err = withCode(CodeUnsafePath, d.err)
+			continue
+		}
+		servers := extractClientServers(config)
+		if len(servers) == 0 {
+			d.err = newError(ErrNoServers, nil, "no servers")
+			continue
+		}
+		d.before, d.after, d.err = plannedClientData(d.path, extractClientServerNames(config), servers, ApplyOptions{})
+		if d.differs() {
+			cs := compareClient(entry.name, config, homeDir)
+			d.added, d.changed = cs.missing, cs.modified
+		}
+	}
+	if options.Client != "" && len(diffs) == 0 {
+		return nil, newError(ErrNotInYAML, nil, "client '%s' is not in %s", options.Client, yamlFile)
+	}
+	return diffs, nil
+}
+
+// printDiffs writes a unified diff per changed client and returns how many
+// clients would change.
+func printDiffs(out io.Writer, diffs []*clientDiff) int {
+	changed := 0
+	for _, d := range diffs {
+		switch {
+		case d.skipped():
+			fmt.Fprintln(out, msgf("- %s has no servers; skipped", d.name))
+		case d.err != nil:
+			fmt.Fprintln(out, msgf("✗ %s could not be compared: %v", d.name, d.err))
+		case !d.differs():
+			fmt.Fprintln(out, msgf("= %s is up to date: %s", d.name, d.path))
+		default:
+			changed++
+			before := d.path
+			if d.before == nil {
+				before = "/dev/null"
+			}
+			fmt.Fprintf(out, "--- %s\n+++ %s\n", before, d.path)
+			unifiedDiff(out, diffLines(splitLines(d.before), splitLines(d.after)))
+		}
+	}
+	return changed
+}
+
+// jsonClient converts the diff for --output json.
+func (d *clientDiff) jsonClient() jsonClient {
+	client := jsonClient{Name: d.name, Path: d.path, Status: "unchanged", Servers: newJSONServers(d.added, d.changed, nil, nil)}
+	switch {
+	case d.skipped():
+		client.Status = "skipped"
+	case d.err != nil:
+		client.Status = "failed"
+		client.Error = newJSONError(d.err)
+	case d.differs():
+		client.Status = "changed"
+		var diff bytes.Buffer
+		unifiedDiff(&diff, diffLines(splitLines(d.before), splitLines(d.after)))
+		client.Diff = diff.String()
+	}
+	return client
+}
+
+func diffConfig(yamlFile string, options DiffOptions) error {
+	diffs, err := planDiffs(yamlFile, options)
+	if err != nil {
+		return failCommand(options.Output, CommandDiff, yamlFile, err)
+	}
+
+	exitCode := ExitOK
+	for _, d := range diffs {
+		switch {
+		case d.failed():
+			exitCode = ExitPartialFailure
+		case d.differs() && exitCode == ExitOK:
+			exitCode = ExitDrift
+		}
+	}
+
+	if options.Output == OutputJSON {
+		report := newJSONReport(CommandDiff, yamlFile)
+		report.ExitCode = exitCode
+		for _, d := range diffs {
+			report.Clients = append(report.Clients, d.jsonClient())
+			if d.failed() {
+				jsonErr := newJSONError(d.err)
+				jsonErr.Client = d.name
+				report.Errors = append(report.Errors, *jsonErr)
+			}
+		}
+		writeJSONReport(os.Stdout, report)
+	} else if changed := printDiffs(os.Stdout, diffs); changed > 0 {
+		fmt.Println("\n" + msgf("%d client(s) would change; run apply to write them", changed))
+	}
+	if exitCode != ExitOK {
+		return reportedExit(exitCode, "client files differ from %s", yamlFile)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func renderDiff(a, b string) string {
+	var out bytes.Buffer
+	unifiedDiff(&out, diffLines(splitLines([]byte(a)), splitLines([]byte(b))))
+	return out.String()
+}
+
+// TestUnifiedDiff 行単位の差分表示テスト
+func TestUnifiedDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+
+	assert.Empty(t, renderDiff(before, before))
+
+	assert.Equal(t, `@@ -2,7 +2,7 @@
+ b
+ c
+ d
+-e
++E
+ f
+ g
+ h
+`, renderDiff(before, strings.Replace(before, "e\n", "E\n", 1)))
+
+	// changes far apart get their own hunks
+	after := strings.Replace(strings.Replace(before, "b\n", "", 1), "l\n", "l\nl2\n", 1)
+	assert.Equal(t, `@@ -1,5 +1,4 @@
+ a
+-b
+ c
+ d
+ e
+@@ -10,4 +9,5 @@
+ j
+ k
+ l
++l2
+ m
+`, renderDiff(before, after))
+
+	assert.Equal(t, "@@ -0,0 +1,2 @@\n+{\n+}\n", renderDiff("", "{\n}\n"))
+	assert.Equal(t, "@@ -1 +1 @@\n-{}\n\\ No newline at end of file\n+{}\n", renderDiff("{}", "{}\n"))
+}
+
+// TestPlanDiffs applyで変更される内容の表示テスト（ファイルは書き換えない）
+func TestPlanDiffs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	yamlFile := filepath.Join(home, "servers.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: fetch
+      command: uvx
+      args: [mcp-server-fetch]
+  cursor:
+    path: .cursor/mcp.json
+    servers:
+    - name: fetch
+      command: uvx
+      args: [mcp-server-fetch]
+  broken:
+    path: /etc/mcp.json
+    servers:
+    - name: fetch
+      command: uvx
+`), 0600))
+	claudeJSON := `{
+  "theme": "dark",
+  "mcpServers": {
+    "fetch": {"command": "npx"}
+  }
+}
+`
+	claudeFile := filepath.Join(home, ".claude.json")
+	require.NoError(t, os.WriteFile(claudeFile, []byte(claudeJSON), 0600))
+
+	diffs, err := planDiffs(yamlFile, DiffOptions{})
+	require.NoError(t, err)
+	var out bytes.Buffer
+	assert.Equal(t, 2, printDiffs(&out, diffs))
+	output := out.String()
+	assert.Contains(t, output, "--- "+claudeFile+"\n+++ "+claudeFile+"\n@@ -1,6 +1,11 @@\n")
+	assert.Contains(t, output, "-    \"fetch\": {\"command\": \"npx\"}\n")
+	assert.Contains(t, output, "--- /dev/null\n+++ "+filepath.Join(home, ".cursor", "mcp.json")+"\n")
+	assert.Contains(t, output, "✗ broken could not be compared: ")
+	assert.Equal(t, claudeJSON, readFile(t, claudeFile), "diff must not write")
+	assert.NoFileExists(t, filepath.Join(home, ".cursor", "mcp.json"))
+
+	diffs, err = planDiffs(yamlFile, DiffOptions{Client: "cursor"})
+	require.NoError(t, err)
+	require.Len(t, diffs, 1)
+	assert.Equal(t, "cursor", diffs[0].name)
+
+	_, err = planDiffs(yamlFile, DiffOptions{Client: "zed"})
+	assert.EqualError(t, err, "client 'zed' is not in "+yamlFile)
+}
+
+// TestDiffConfig_SkipsClientsWithoutServers サーバーのないクライアントはapplyと同じくスキップし失敗にしないテスト
+func TestDiffConfig_SkipsClientsWithoutServers(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	yamlFile := filepath.Join(home, "servers.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`clients:
+  claude:
+    path: .claude.json
+    servers: []
+`), 0600))
+
+	var err error
+	output := captureStdout(t, func() { err = diffConfig(yamlFile, DiffOptions{}) })
+	require.NoError(t, err)
+	assert.Contains(t, output, "- claude has no servers; skipped")
+
+	output = captureStdout(t, func() { err = diffConfig(yamlFile, DiffOptions{Output: OutputJSON}) })
+	require.NoError(t, err)
+	assert.Contains(t, output, `"status": "skipped"`)
+	assert.Contains(t, output, `"errors": []`)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	DefaultYAMLFile = "servers.yaml"
+	ConfigEnvVar    = "MCPYAMMY_CONFIG"
+	ConfigDirName   = "mcpyammy"
+)
+
+// configCandidate is one place servers.yaml may live, in discovery order.
+type configCandidate struct {
+	path   string
+	source string
+	exists bool
+}
+
+// configCandidates lists the discovery order: --config, $MCPYAMMY_CONFIG,
+// ./servers.yaml and $XDG_CONFIG_HOME/mcpyammy/servers.yaml.
+func configCandidates(flagPath string) []configCandidate {
+	var candidates []configCandidate
+	add := func(path, source string) {
+		if path == "" {
+			return
+		}
+		for _, c := range candidates {
+			if c.path == path {
+				return
+			}
+		}
+		_, err := os.Stat(path)
+		candidates = append(candidates, configCandidate{path: path, source: source, exists: err == nil})
+	}
+	add(flagPath, "--config")
+	add(os.Getenv(ConfigEnvVar), "$"+ConfigEnvVar)
+	add(DefaultYAMLFile, "current directory")
+	add(xdgConfigPath(), "XDG config")
+	return candidates
+}
+
+// resolveConfigPath picks the YAML file to use. An explicit --config or
+// $MCPYAMMY_CONFIG wins even if the file does not exist yet; otherwise the
+// first existing default location is used. found is false when nothing was
+// specified or discovered.
+func resolveConfigPath(flagPath string) (path string, found bool) {
+	if flagPath != "" {
+		return flagPath, true
+	}
+	if envPath := os.Getenv(ConfigEnvVar); envPath != "" {
+		return envPath, true
+	}
+	for _, candidate := range configCandidates("") {
+		if candidate.exists {
+			return candidate.path, true
+		}
+	}
+	return "", false
+}
+
+func xdgConfigPath() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, ConfigDirName, DefaultYAMLFile)
+}
+
+// createDefaultConfig writes the starter YAML to path unless it exists.
+func createDefaultConfig(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), DirectoryMode); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(defaultYAML), SecureFileMode)
+}
+
+// expandHomePath expands a leading "~" to the user's home directory.
+func expandHomePath(path string) string {
+	if after, found := strings.CutPrefix(path, "~"); found {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, after)
+		}
+	}
+	return path
+}
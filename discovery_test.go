@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResolveConfigPath_DiscoveryOrder 設定ファイルの探索順テスト
+func TestResolveConfigPath_DiscoveryOrder(t *testing.T) {
+	workDir := t.TempDir()
+	configHome := t.TempDir()
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(workDir))
+	defer os.Chdir(originalWd)
+
+	t.Setenv(ConfigEnvVar, "")
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	_, found := resolveConfigPath("")
+	assert.False(t, found, "どこにも存在しない場合は見つからないべき")
+
+	xdgPath := filepath.Join(configHome, ConfigDirName, DefaultYAMLFile)
+	require.NoError(t, createDefaultConfig(xdgPath))
+	path, found := resolveConfigPath("")
+	assert.True(t, found)
+	assert.Equal(t, xdgPath, path)
+
+	require.NoError(t, createDefaultConfig(DefaultYAMLFile))
+	path, _ = resolveConfigPath("")
+	assert.Equal(t, DefaultYAMLFile, path, "カレントディレクトリがXDGより優先されるべき")
+
+	t.Setenv(ConfigEnvVar, "from-env.yaml")
+	path, _ = resolveConfigPath("")
+	assert.Equal(t, "from-env.yaml", path, "環境変数が優先されるべき")
+
+	path, _ = resolveConfigPath("from-flag.yaml")
+	assert.Equal(t, "from-flag.yaml", path, "--configが最優先されるべき")
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// doctorLevel is the severity of a doctor finding.
+type doctorLevel int
+
+const (
+	doctorOK doctorLevel = iota
+	doctorWarn
+	doctorFail
+)
+
+func (l doctorLevel) symbol() string {
+	switch l {
+	case doctorWarn:
+		return "⚠"
+	case doctorFail:
+		return "✗"
+	}
+	return "✓"
+}
+
+// doctorCheck is one finding, with a fix for anything that is not OK.
+type doctorCheck struct {
+	level   doctorLevel
+	message string
+	fix     string
+}
+
+// doctorSection groups the findings for the YAML or for one client.
+type doctorSection struct {
+	title  string
+	checks []doctorCheck
+}
+
+func (s *doctorSection) ok(message string) {
+	s.checks = append(s.checks, doctorCheck{level: doctorOK, message: message})
+}
+
+func (s *doctorSection) warn(fix, message string) {
+	s.checks = append(s.checks, doctorCheck{level: doctorWarn, message: message, fix: fix})
+}
+
+func (s *doctorSection) fail(fix, message string) {
+	s.checks = append(s.checks, doctorCheck{level: doctorFail, message: message, fix: fix})
+}
+
+var (
+	// secretKeyPattern matches env and header names that usually hold
+	// credentials.
+	secretKeyPattern = regexp.MustCompile(`(?i)(token|secret|passw|api[_-]?key|auth|credential|private[_-]?key)`)
+
+	// envRefPattern matches ${VAR} and $VAR references, which clients
+	// expand from their own environment.
+	envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-[^}]*)?\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// installHints tells how to get the launchers MCP servers commonly use. The
+// hints are translated when they are printed.
+var installHints = map[string]string{
+	"npx":     "install Node.js, which provides npx: https://nodejs.org/",
+	"npm":     "install Node.js, which provides npm: https://nodejs.org/",
+	"node":    "install Node.js: https://nodejs.org/",
+	"bunx":    "install Bun: https://bun.sh/",
+	"uvx":     "install uv, which provides uvx: https://docs.astral.sh/uv/getting-started/installation/",
+	"uv":      "install uv: https://docs.astral.sh/uv/getting-started/installation/",
+	"docker":  "install Docker Desktop or Docker Engine: https://docs.docker.com/get-docker/",
+	"python":  "install Python 3 or use python3 as the command: https://www.python.org/downloads/",
+	"python3": "install Python 3: https://www.python.org/downloads/",
+	"deno":    "install Deno: https://deno.com/",
+}
+
+// runDoctor checks that everything the YAML refers to is in place on this
+// machine.
+func runDoctor(yamlFile string) ([]*doctorSection, error) {
+	yamlContent, err := loadEffectiveYAML(yamlFile)
+	if err != nil {
+		return nil, err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errorf("error getting home directory: %v", err)
+	}
+
+	config := &doctorSection{title: yamlFile}
+	files, err := configTreeFiles(yamlFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		checkSecretFile(config, file, yamlFileHasSecrets(file))
+	}
+	sections := []*doctorSection{config}
+
+	for _, entry := range clientEntries(yamlContent) {
+		section := &doctorSection{title: entry.name}
+		sections = append(sections, section)
+		clientConfig, ok := entry.config.(map[string]interface{})
+		if !ok {
+			section.fail(msgf("make the client a mapping with path and servers"), msgf("invalid client configuration"))
+			continue
+		}
+		servers := extractClientServers(clientConfig)
+		checkClientFile(section, clientConfig, servers, homeDir)
+		for _, name := range extractClientServerNames(clientConfig) {
+			server, _ := servers[name].(map[string]interface{})
+			checkServer(section, name, server)
+		}
+	}
+	return sections, nil
+}
+
+// checkClientFile checks that the client's config directory exists and is
+// writable, and that the file is private when it will hold secrets.
+func checkClientFile(section *doctorSection, config map[string]interface{}, servers map[string]interface{}, homeDir string) {
+	pathStr, _ := config["path"].(string)
+	path, err := validateSafePath(pathStr, homeDir)
+	if err != nil {
+		section.fail(msgf("set path to a file inside your home directory"), fmt.Sprintf("path %q: %v", pathStr, err))
+		return
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	dir := filepath.Dir(path)
+	info, err := os.Stat(dir)
+	switch {
+	case os.IsNotExist(err):
+		section.warn(msgf("install the client, or run: mkdir -p %s", dir),
+			msgf("%s does not exist; the client may not be installed", dir))
+	case err != nil:
+		section.fail(msgf("check the permissions of the parent directories"), fmt.Sprintf("%s: %v", dir, err))
+	case !info.IsDir():
+		section.fail(msgf("move %s out of the way", dir), msgf("%s is not a directory", dir))
+	default:
+		if err := checkWritable(dir); err != nil {
+			section.fail(msgf("run: chmod u+w %s", dir), msgf("%s is not writable: %v", dir, err))
+		} else {
+			section.ok(msgf("%s is writable", dir))
+		}
+	}
+
+	secrets := false
+	for _, server := range servers {
+		if containsSecret(server) {
+			secrets = true
+			break
+		}
+	}
+	if _, err := os.Stat(path); err == nil {
+		checkSecretFile(section, path, secrets)
+	}
+}
+
+// checkWritable creates and removes a file in dir.
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".mcpyammy-doctor-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkSecretFile warns when a file holding secrets can be read by other
+// users.
+func checkSecretFile(section *doctorSection, path string, secrets bool) {
+	if !secrets || goosFunc() == "windows" {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if mode := info.Mode().Perm(); mode&0077 != 0 {
+		section.warn(msgf("run: chmod 600 %s", path),
+			msgf("%s contains secrets but is readable by others (mode %04o)", path, mode))
+		return
+	}
+	section.ok(msgf("%s contains secrets and is private", path))
+}
+
+// checkServer checks the command, the files and the environment variables a
+// server needs.
+func checkServer(section *doctorSection, name string, server map[string]interface{}) {
+	if url, ok := server["url"].(string); ok {
+		section.ok(msgf("%s: remote server at %s", name, url))
+		checkEnvReferences(section, name, server["headers"])
+		return
+	}
+
+	command, _ := server["command"].(string)
+	if command == "" {
+		section.fail(msgf("add a command or a url to the server"), msgf("%s: no command specified", name))
+		return
+	}
+	if resolved, err := lookPathFunc(expandHomePath(command)); err != nil {
+		fix := msgf("install %s or use its absolute path as the command", command)
+		if hint, ok := installHints[filepath.Base(command)]; ok {
+			fix = translate(hint)
+		}
+		section.fail(fix, msgf("%s: command %q not found on PATH", name, command))
+	} else {
+		section.ok(fmt.Sprintf("%s: %s", name, resolved))
+	}
+
+	for _, arg := range stringList(server["args"]) {
+		checkReferencedFile(section, name, arg)
+	}
+	if env, ok := server["env"].(map[string]interface{}); ok {
+		for _, key := range sortedKeys(env) {
+			value := fmt.Sprint(env[key])
+			if value == "" {
+				section.warn(msgf("set env.%s in the YAML", key), msgf("%s: env %s is empty", name, key))
+				continue
+			}
+			checkReferencedFile(section, name, value)
+		}
+	}
+	checkEnvReferences(section, name, server["args"])
+	checkEnvReferences(section, name, server["env"])
+}
+
+// checkReferencedFile checks that an absolute or home-relative path in an
+// argument or env value exists. Relative paths depend on where the client
+// starts the server and are not checked.
+func checkReferencedFile(section *doctorSection, name, value string) {
+	if _, after, found := strings.Cut(value, "="); found && strings.HasPrefix(value, "-") {
+		value = after
+	}
+	if !strings.HasPrefix(value, "/") && !strings.HasPrefix(value, "~/") && !filepath.IsAbs(value) {
+		return
+	}
+	if envRefPattern.MatchString(value) {
+		return
+	}
+	path := expandHomePath(value)
+	if _, err := os.Stat(path); err != nil {
+		section.fail(msgf("create %s or fix the path in the YAML", path), msgf("%s: %s does not exist", name, path))
+	}
+}
+
+// checkEnvReferences checks that the variables referenced as ${VAR} in the
+// strings of v are set.
+func checkEnvReferences(section *doctorSection, name string, v interface{}) {
+	seen := make(map[string]bool)
+	for _, s := range collectStrings(v) {
+		for _, match := range envRefPattern.FindAllStringSubmatch(s, -1) {
+			variable := match[1] + match[2]
+			if seen[variable] {
+				continue
+			}
+			seen[variable] = true
+			if value, ok := lookupEnv(variable); !ok || value == "" {
+				section.fail(msgf("export %s=... in your shell profile, or set it where the client is started", variable),
+					msgf("%s: environment variable %s is not set", name, variable))
+			}
+		}
+	}
+}
+
+// collectStrings returns the strings in a YAML value.
+func collectStrings(v interface{}) []string {
+	var out []string
+	switch val := v.(type) {
+	case string:
+		out = append(out, val)
+	case []interface{}:
+		for _, item := range val {
+			out = append(out, collectStrings(item)...)
+		}
+	case map[string]interface{}:
+		for _, key := range sortedKeys(val) {
+			out = append(out, collectStrings(val[key])...)
+		}
+	}
+	return out
+}
+
+// containsSecret reports whether a value has a non-empty literal under a
+// key that looks like a credential. ${VAR} references are not secrets.
+func containsSecret(v interface{}) bool {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, item := range val {
+			if s, ok := item.(string); ok && secretKeyPattern.MatchString(key) && s != "" && !envRefPattern.MatchString(s) {
+				return true
+			}
+			if containsSecret(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			if containsSecret(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// yamlFileHasSecrets reports whether one YAML file, without its includes,
+// contains credentials.
+func yamlFileHasSecrets(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var content map[string]interface{}
+	if err := parseYAMLSafely(data, MaxYAMLSize, &content); err != nil {
+		return false
+	}
+	return containsSecret(content)
+}
+
+// printDoctor writes the findings and returns how many failed.
+func printDoctor(out io.Writer, sections []*doctorSection) (failed, warned int) {
+	for i, section := range sections {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintln(out, section.title)
+		for _, check := range section.checks {
+			fmt.Fprintf(out, "  %s %s\n", check.level.symbol(), check.message)
+			if check.fix != "" {
+				fmt.Fprintln(out, msgf("    fix: %s", check.fix))
+			}
+			switch check.level {
+			case doctorFail:
+				failed++
+			case doctorWarn:
+				warned++
+			}
+		}
+	}
+	return failed, warned
+}
+
+func doctorConfig(yamlFile string) error {
+	sections, err := runDoctor(yamlFile)
+	if err != nil {
+		return err
+	}
+	failed, warned := printDoctor(os.Stdout, sections)
+	switch {
+	case failed > 0:
+		fmt.Println("\n" + msgf("%d problem(s) and %d warning(s) found", failed, warned))
+		return reportedExit(ExitError, "%d problem(s) found", failed)
+	case warned > 0:
+		fmt.Println("\n" + msgf("No problems found, %d warning(s)", warned))
+	default:
+		fmt.Println("\n" + msgf("No problems found"))
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupDoctorTest(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	originalGOOS, originalLookupEnv, originalLookPath := goosFunc, lookupEnv, lookPathFunc
+	t.Cleanup(func() { goosFunc, lookupEnv, lookPathFunc = originalGOOS, originalLookupEnv, originalLookPath })
+	goosFunc = func() string { return "linux" }
+	lookupEnv = func(key string) (string, bool) {
+		if key == "GITHUB_TOKEN" {
+			return "ghp_x", true
+		}
+		return "", false
+	}
+	lookPathFunc = func(file string) (string, error) {
+		if file == "npx" {
+			return "/usr/bin/npx", nil
+		}
+		return "", errors.New("not found")
+	}
+	return home
+}
+
+func findCheck(sections []*doctorSection, title string, level doctorLevel, message string) *doctorCheck {
+	for _, section := range sections {
+		if section.title != title {
+			continue
+		}
+		for i, check := range section.checks {
+			if check.level == level && check.message == message {
+				return &section.checks[i]
+			}
+		}
+	}
+	return nil
+}
+
+// TestRunDoctor コマンド・ファイル・環境変数・権限のチェックテスト
+func TestRunDoctor(t *testing.T) {
+	home := setupDoctorTest(t)
+	require.NoError(t, os.Mkdir(filepath.Join(home, ".cursor"), 0755))
+	notes := filepath.Join(home, "notes")
+	require.NoError(t, os.Mkdir(notes, 0755))
+
+	yamlFile := filepath.Join(home, "servers.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`clients:
+  cursor:
+    path: .cursor/mcp.json
+    servers:
+    - name: fs
+      command: npx
+      args: [-y, server-filesystem, ~/notes, /no/such/dir, --root=/also/missing, ./relative]
+    - name: github
+      command: npx
+      env:
+        GITHUB_TOKEN: ${GITHUB_TOKEN}
+        SLACK_TOKEN: $SLACK_TOKEN
+        EMPTY: ""
+    - name: fetch
+      command: uvx
+    - name: remote
+      url: https://example.com/mcp
+      headers:
+        Authorization: Bearer ${REMOTE_KEY}
+  windsurf:
+    path: .codeium/windsurf/mcp_config.json
+    servers:
+    - name: db
+      command: npx
+      env:
+        DB_PASSWORD: hunter2
+`), 0644))
+
+	sections, err := runDoctor(yamlFile)
+	require.NoError(t, err)
+	require.Len(t, sections, 3)
+
+	check := findCheck(sections, yamlFile, doctorWarn, yamlFile+" contains secrets but is readable by others (mode 0644)")
+	require.NotNil(t, check)
+	assert.Equal(t, "run: chmod 600 "+yamlFile, check.fix)
+
+	assert.NotNil(t, findCheck(sections, "cursor", doctorOK, filepath.Join(home, ".cursor")+" is writable"))
+	assert.NotNil(t, findCheck(sections, "cursor", doctorOK, "fs: /usr/bin/npx"))
+	assert.Nil(t, findCheck(sections, "cursor", doctorFail, "fs: "+notes+" does not exist"))
+	assert.NotNil(t, findCheck(sections, "cursor", doctorFail, "fs: /no/such/dir does not exist"))
+	assert.NotNil(t, findCheck(sections, "cursor", doctorFail, "fs: /also/missing does not exist"))
+
+	assert.Nil(t, findCheck(sections, "cursor", doctorFail, "github: environment variable GITHUB_TOKEN is not set"))
+	check = findCheck(sections, "cursor", doctorFail, "github: environment variable SLACK_TOKEN is not set")
+	require.NotNil(t, check)
+	assert.Contains(t, check.fix, "export SLACK_TOKEN=")
+	assert.NotNil(t, findCheck(sections, "cursor", doctorWarn, "github: env EMPTY is empty"))
+
+	check = findCheck(sections, "cursor", doctorFail, `fetch: command "uvx" not found on PATH`)
+	require.NotNil(t, check)
+	assert.Contains(t, check.fix, "install uv")
+
+	assert.NotNil(t, findCheck(sections, "cursor", doctorFail, "remote: environment variable REMOTE_KEY is not set"))
+
+	check = findCheck(sections, "windsurf", doctorWarn, filepath.Join(home, ".codeium", "windsurf")+" does not exist; the client may not be installed")
+	require.NotNil(t, check)
+	assert.Contains(t, check.fix, "mkdir -p")
+}
+
+// TestRunDoctor_ClientFilePermissions 秘密情報を含むクライアント設定ファイルの権限テスト
+func TestRunDoctor_ClientFilePermissions(t *testing.T) {
+	home := setupDoctorTest(t)
+	clientFile := filepath.Join(home, ".claude.json")
+	require.NoError(t, os.WriteFile(clientFile, []byte(`{}`), 0644))
+	yamlFile := filepath.Join(home, "servers.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: api
+      command: npx
+      env:
+        API_KEY: abc
+`), 0600))
+
+	sections, err := runDoctor(yamlFile)
+	require.NoError(t, err)
+	assert.NotNil(t, findCheck(sections, yamlFile, doctorOK, yamlFile+" contains secrets and is private"))
+	assert.NotNil(t, findCheck(sections, "claude", doctorWarn, clientFile+" contains secrets but is readable by others (mode 0644)"))
+
+	// permission bits mean nothing on Windows
+	goosFunc = func() string { return "windows" }
+	sections, err = runDoctor(yamlFile)
+	require.NoError(t, err)
+	assert.Nil(t, findCheck(sections, "claude", doctorWarn, clientFile+" contains secrets but is readable by others (mode 0644)"))
+}
+
+// TestContainsSecret 秘密情報の判定テスト
+func TestContainsSecret(t *testing.T) {
+	assert.True(t, containsSecret(map[string]interface{}{"env": map[string]interface{}{"OPENAI_API_KEY": "sk-1"}}))
+	assert.True(t, containsSecret([]interface{}{map[string]interface{}{"Authorization": "Bearer x"}}))
+	assert.False(t, containsSecret(map[string]interface{}{"GITHUB_TOKEN": "${GITHUB_TOKEN}"}))
+	assert.False(t, containsSecret(map[string]interface{}{"API_KEY": ""}))
+	assert.False(t, containsSecret(map[string]interface{}{"LOG_LEVEL": "debug"}))
+}
+
+// TestPrintDoctor 表示と件数のテスト
+func TestPrintDoctor(t *testing.T) {
+	section := &doctorSection{title: "claude"}
+	section.ok("dir is writable")
+	section.fail("install it", `x: command "x" not found on PATH`)
+	section.warn("run: chmod 600 f", "f is readable by others")
+
+	var out bytes.Buffer
+	failed, warned := printDoctor(&out, []*doctorSection{section})
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, 1, warned)
+	assert.Equal(t, `claude
+  ✓ dir is writable
+  ✗ x: command "x" not found on PATH
+    fix: install it
+  ⚠ f is readable by others
+    fix: run: chmod 600 f
+`, out.String())
+}
@@ -0,0 +1,60 @@
+package main
+
+// ErrorKind is a failure callers can react to: an error of a kind matches it
+// with errors.Is and reports its code in JSON output.
+type ErrorKind struct {
+	code ErrorCode
+	name string
+}
+
+func (k *ErrorKind) Error() string   { return k.name }
+func (k *ErrorKind) Code() ErrorCode { return k.code }
+
+var (
+	// ErrPathOutsideHome is a client path that resolves outside the home
+	// directory.
+	ErrPathOutsideHome = &ErrorKind{CodeUnsafePath, "path outside the home directory"}
+	// ErrNoServers is a client without servers in the YAML, or a client file
+	// without mcpServers on import.
+	ErrNoServers = &ErrorKind{CodeNoServers, "no servers"}
+	// ErrClientParse is a client file that cannot be parsed.
+	ErrClientParse = &ErrorKind{CodeClientParse, "client file cannot be parsed"}
+	// ErrYAMLTooLarge is a YAML file over MaxYAMLSize.
+	ErrYAMLTooLarge = &ErrorKind{CodeYAMLTooLarge, "YAML file too large"}
+	// ErrYAMLTooDeep is a YAML file nested deeper than MaxNestLevel.
+	ErrYAMLTooDeep = &ErrorKind{CodeYAMLTooDeep, "YAML nested too deeply"}
+	// ErrNotInYAML is a client or server asked for on the command line
+	// that the YAML does not define.
+	ErrNotInYAML = &ErrorKind{CodeNotInYAML, "not in the YAML"}
+)
+
+// Error is an error of a kind. Message is what the user sees; Cause is the
+// error that led to it, if any, and is unwrapped along with the kind.
+type Error struct {
+	Kind    *ErrorKind
+	Message string
+	Cause   error
+}
+
+func (e *Error) Error() string   { return e.Message }
+func (e *Error) Code() ErrorCode { return e.Kind.code }
+
+func (e *Error) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Cause}
+}
+
+// newError returns an error of kind with a message from the catalog.
+func newError(kind *ErrorKind, cause error, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Message: msgf(format, args...), Cause: cause}
+}
+
+// ofKind marks err as an error of kind, keeping its message.
+func ofKind(kind *ErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Message: err.Error(), Cause: err}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestErrorKinds 種類付きのエラーをerrors.Isとコードで判別するテスト
+func TestErrorKinds(t *testing.T) {
+	home := t.TempDir()
+
+	_, err := validateSafePath("/etc/passwd", home)
+	assert.ErrorIs(t, err, ErrPathOutsideHome)
+	assert.Equal(t, CodeUnsafePath, errorCode(err))
+	assert.EqualError(t, err, "access outside the home directory is not allowed: /etc/passwd")
+	_, err = validateSafePath("", home)
+	assert.NotErrorIs(t, err, ErrPathOutsideHome)
+
+	var content interface{}
+	err = parseYAMLSafely([]byte("a: 1\n"), 2, &content)
+	assert.ErrorIs(t, err, ErrYAMLTooLarge)
+	assert.Equal(t, CodeYAMLTooLarge, errorCode(err))
+	err = parseYAMLSafely([]byte("a: "+strings.Repeat("[", MaxNestLevel+1)), MaxYAMLSize, &content)
+	assert.ErrorIs(t, err, ErrYAMLTooDeep)
+	assert.NotErrorIs(t, err, ErrYAMLTooLarge)
+
+	err = parseYAMLSafely([]byte("a: [1"), MaxYAMLSize, &content)
+	var kind *Error
+	assert.False(t, errors.As(err, &kind), "構文エラーは種類なし")
+}
+
+// TestLoadAndValidateYAML_ErrorKinds 読み込みエラーの種類がincludeを通しても残るテスト
+func TestLoadAndValidateYAML_ErrorKinds(t *testing.T) {
+	dir := t.TempDir()
+	deep := filepath.Join(dir, "deep.yaml")
+	require.NoError(t, os.WriteFile(deep, []byte("clients: "+strings.Repeat("[", MaxNestLevel+1)), 0600))
+	yamlFile := filepath.Join(dir, "servers.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte("include: deep.yaml\nclients: {}\n"), 0600))
+
+	_, err := loadAndValidateYAML(yamlFile)
+	assert.ErrorIs(t, err, ErrYAMLTooDeep)
+	assert.Equal(t, CodeYAMLTooDeep, errorCode(err))
+	assert.Contains(t, err.Error(), "deep.yaml")
+	assert.True(t, invalidYAML(err), "validateでは不正なYAMLとして扱う")
+
+	require.NoError(t, os.WriteFile(yamlFile, []byte("clients: [1"), 0600))
+	_, err = loadAndValidateYAML(yamlFile)
+	assert.Equal(t, CodeInvalidYAML, errorCode(err))
+}
+
+// TestApplyClient_ErrorKinds クライアントごとのエラーの種類と原因のテスト
+func TestApplyClient_ErrorKinds(t *testing.T) {
+	home := t.TempDir()
+
+	err := applyClient("claude", map[string]interface{}{"path": ".claude.json"}, home, ApplyOptions{}).err
+	assert.ErrorIs(t, err, ErrNoServers)
+
+	config := map[string]interface{}{
+		"path":    "../outside.json",
+		"servers": []interface{}{map[string]interface{}{"name": "a", "command": "a"}},
+	}
+	err = applyClient("claude", config, home, ApplyOptions{}).err
+	assert.ErrorIs(t, err, ErrPathOutsideHome)
+	assert.Equal(t, CodeUnsafePath, errorCode(err))
+
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".claude.json"), []byte(`{"mcpServers": `), 0600))
+	config["path"] = ".claude.json"
+	err = applyClient("claude", config, home, ApplyOptions{}).err
+	assert.ErrorIs(t, err, ErrClientParse)
+	assert.Equal(t, CodeClientParse, errorCode(err))
+	var kind *Error
+	require.True(t, errors.As(err, &kind))
+	assert.NotNil(t, kind.Cause, "解析エラーを原因として包む")
+	assert.Contains(t, err.Error(), "--force-reset")
+}
@@ -0,0 +1,9 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package main
+
+// lockFile is a no-op where flock is not available. The modification check
+// in replaceFile still detects concurrent writers.
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
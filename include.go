@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	includeKey = "include"
+	extendsKey = "extends"
+)
+
+// loadConfigTree reads yamlFile and every file it pulls in through `extends:`
+// and `include:`, and returns the merged document with key order preserved.
+// Bases are merged in the order extends → include, then the file itself is
+// laid on top of them.
+func loadConfigTree(yamlFile string) (yaml.MapSlice, error) {
+	return loadConfigTreeRecursive(yamlFile, nil, nil)
+}
+
+// configTreeFiles returns the absolute paths of yamlFile and every file it
+// pulls in. Files are listed up to the first one that fails to load, which
+// is returned with the error so it can still be watched for a fix.
+func configTreeFiles(yamlFile string) ([]string, error) {
+	var files []string
+	_, err := loadConfigTreeRecursive(yamlFile, nil, &files)
+	return files, err
+}
+
+func loadConfigTreeRecursive(yamlFile string, stack []string, files *[]string) (yaml.MapSlice, error) {
+	absPath, err := filepath.Abs(yamlFile)
+	if err != nil {
+		return nil, errorf("could not get the absolute path: %v", err)
+	}
+	for _, visited := range stack {
+		if visited == absPath {
+			return nil, withCode(CodeInvalidYAML, errorf("include cycle: %s", strings.Join(append(stack, absPath), " → ")))
+		}
+	}
+	stack = append(stack, absPath)
+	if files != nil {
+		*files = append(*files, absPath)
+	}
+
+	yamlData, err := os.ReadFile(absPath)
+	if os.IsNotExist(err) {
+		return nil, withCode(CodeNotFound, errorf("Error reading YAML file: %v", err))
+	}
+	if err != nil {
+		return nil, errorf("Error reading YAML file: %v", err)
+	}
+	var doc yaml.MapSlice
+	if err := parseYAMLSafely(yamlData, MaxYAMLSize, &doc, yaml.UseOrderedMap()); err != nil {
+		if errorCode(err) == CodeError {
+			err = withCode(CodeInvalidYAML, err)
+		}
+		return nil, errorf("Invalid YAML (%s): %w", yamlFile, err)
+	}
+
+	var bases []string
+	for _, key := range []string{extendsKey, includeKey} {
+		refs, err := includeRefs(doc, key)
+		if err != nil {
+			return nil, withCode(CodeInvalidYAML, errorf("%s (%s): %v", key, yamlFile, err))
+		}
+		bases = append(bases, refs...)
+	}
+
+	merged := yaml.MapSlice{}
+	for _, ref := range bases {
+		base, err := loadConfigTreeRecursive(resolveIncludePath(ref, filepath.Dir(absPath)), stack, files)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeMapSlices(merged, base)
+	}
+
+	own := make(yaml.MapSlice, 0, len(doc))
+	for _, item := range doc {
+		if key := fmt.Sprint(item.Key); key != includeKey && key != extendsKey {
+			own = append(own, item)
+		}
+	}
+	return mergeMapSlices(merged, own), nil
+}
+
+// includeRefs returns the file references listed under key, which may be a
+// single string or a list of strings.
+func includeRefs(doc yaml.MapSlice, key string) ([]string, error) {
+	for _, item := range doc {
+		if fmt.Sprint(item.Key) != key {
+			continue
+		}
+		switch v := item.Value.(type) {
+		case nil:
+			return nil, nil
+		case string:
+			return []string{v}, nil
+		case []interface{}:
+			refs := make([]string, 0, len(v))
+			for _, ref := range v {
+				s, ok := ref.(string)
+				if !ok || s == "" {
+					return nil, errorf("file paths must be strings: %v", ref)
+				}
+				refs = append(refs, s)
+			}
+			return refs, nil
+		default:
+			return nil, errorf("must be a string or a list")
+		}
+	}
+	return nil, nil
+}
+
+// resolveIncludePath expands "~" and resolves relative references against
+// the directory of the including file.
+func resolveIncludePath(ref, baseDir string) string {
+	ref = expandHomePath(ref)
+	if filepath.IsAbs(ref) {
+		return ref
+	}
+	return filepath.Join(baseDir, ref)
+}
+
+// mergeMapSlices lays overlay on top of base. Maps merge key by key, scalars
+// and ordinary lists from the overlay replace the base value, and `servers`
+// lists merge by `name`: an overlay entry replaces the base entry of the same
+// name in place, and new names are appended.
+func mergeMapSlices(base, overlay yaml.MapSlice) yaml.MapSlice {
+	merged := make(yaml.MapSlice, len(base))
+	copy(merged, base)
+	for _, item := range overlay {
+		key := fmt.Sprint(item.Key)
+		idx := -1
+		for i, existing := range merged {
+			if fmt.Sprint(existing.Key) == key {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			merged = append(merged, item)
+			continue
+		}
+		merged[idx].Value = mergeValues(merged[idx].Value, item.Value, key)
+	}
+	return merged
+}
+
+func mergeValues(base, overlay interface{}, key string) interface{} {
+	switch o := overlay.(type) {
+	case yaml.MapSlice:
+		if b, ok := base.(yaml.MapSlice); ok {
+			return mergeMapSlices(b, o)
+		}
+	case []interface{}:
+		if b, ok := base.([]interface{}); ok && key == "servers" {
+			return mergeServerLists(b, o)
+		}
+	case nil:
+		// `servers:` with no entries in an overlay keeps the base servers
+		if key == "servers" {
+			return base
+		}
+	}
+	return overlay
+}
+
+func mergeServerLists(base, overlay []interface{}) []interface{} {
+	merged := make([]interface{}, len(base))
+	copy(merged, base)
+	for _, entry := range overlay {
+		name := mapSliceString(entry, "name")
+		replaced := false
+		if name != "" {
+			for i, existing := range merged {
+				if mapSliceString(existing, "name") == name {
+					merged[i] = entry
+					replaced = true
+					break
+				}
+			}
+		}
+		if !replaced {
+			merged = append(merged, entry)
+		}
+	}
+	return merged
+}
+
+func mapSliceValue(ms yaml.MapSlice, key string) interface{} {
+	for _, item := range ms {
+		if fmt.Sprint(item.Key) == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+func mapSliceString(v interface{}, key string) string {
+	ms, ok := v.(yaml.MapSlice)
+	if !ok {
+		return ""
+	}
+	for _, item := range ms {
+		if fmt.Sprint(item.Key) == key {
+			s, _ := item.Value.(string)
+			return s
+		}
+	}
+	return ""
+}
+
+// toPlainValue converts an ordered YAML tree into the map[string]interface{}
+// form used by the rest of the processor.
+func toPlainValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case yaml.MapSlice:
+		m := make(map[string]interface{}, len(val))
+		for _, item := range val {
+			m[fmt.Sprint(item.Key)] = toPlainValue(item.Value)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(val))
+		for i, elem := range val {
+			list[i] = toPlainValue(elem)
+		}
+		return list
+	default:
+		return val
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadAndValidateYAML_MergesExtendsOverlay チームベースと個人設定のマージテスト
+func TestLoadAndValidateYAML_MergesExtendsOverlay(t *testing.T) {
+	dir := t.TempDir()
+	base := `clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: fetch
+      command: uvx
+    - name: github
+      command: npx
+      env:
+        TOKEN: team
+`
+	overlay := `extends: base.yaml
+clients:
+  claude:
+    servers:
+    - name: github
+      command: docker
+    - name: personal
+      command: node
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base.yaml"), []byte(base), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "servers.yaml"), []byte(overlay), 0600))
+
+	yamlContent, err := loadAndValidateYAML(filepath.Join(dir, "servers.yaml"))
+	require.NoError(t, err)
+	assert.NotContains(t, yamlContent, "extends")
+
+	claude := clientConfigByName(yamlContent, "claude")
+	assert.Equal(t, ".claude.json", claude["path"], "ベースのpathが引き継がれるべき")
+
+	servers := claude["servers"].([]interface{})
+	require.Len(t, servers, 3)
+	assert.Equal(t, "fetch", servers[0].(map[string]interface{})["name"])
+	github := servers[1].(map[string]interface{})
+	assert.Equal(t, "docker", github["command"], "同名サーバーは上書きされるべき")
+	assert.NotContains(t, github, "env", "同名サーバーはエントリごと置き換えられるべき")
+	assert.Equal(t, "personal", servers[2].(map[string]interface{})["name"])
+}
+
+// TestLoadAndValidateYAML_IncludeCycle include循環の検出テスト
+func TestLoadAndValidateYAML_IncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("include: b.yaml\nclients: {}\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yaml"), []byte("include: a.yaml\n"), 0600))
+
+	_, err := loadAndValidateYAML(filepath.Join(dir, "a.yaml"))
+	assert.ErrorContains(t, err, "include cycle")
+}
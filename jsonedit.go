@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// jsonParseError is a client file that could not be parsed, with the line
+// and column of the problem so the user can fix the file by hand.
+type jsonParseError struct {
+	line   int
+	column int
+	err    error
+}
+
+func (e *jsonParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", e.line, e.column, e.err)
+}
+
+func (e *jsonParseError) Unwrap() error {
+	return e.err
+}
+
+// newJSONParseError attaches the position of err within src when the
+// decoder reported one.
+func newJSONParseError(src []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return positionError(src, int(syntaxErr.Offset), err)
+	case errors.As(err, &typeErr):
+		return positionError(src, int(typeErr.Offset), err)
+	}
+	return err
+}
+
+func positionError(src []byte, offset int, err error) error {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	lineStart := bytes.LastIndexByte(src[:offset], '\n') + 1
+	return &jsonParseError{
+		line:   bytes.Count(src[:lineStart], []byte("\n")) + 1,
+		column: utf8.RuneCount(src[lineStart:offset]) + 1,
+		err:    err,
+	}
+}
+
+// jsonMember is the byte range of one member of a JSON object.
+type jsonMember struct {
+	key        string
+	keyStart   int
+	valueStart int
+	valueEnd   int
+}
+
+// jsonObjectSpan is an object together with its members, as byte offsets
+// into the original source.
+type jsonObjectSpan struct {
+	start   int
+	end     int // offset of the closing brace
+	members []jsonMember
+}
+
+// standardizeJSONC blanks out comments and trailing commas so that the result
+// is plain JSON with every remaining byte at the same offset as in src.
+func standardizeJSONC(src []byte) []byte {
+	std := make([]byte, len(src))
+	copy(std, src)
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if std[i] != '\n' && std[i] != '\r' {
+				std[i] = ' '
+			}
+		}
+	}
+
+	lastComma := -1
+	for i := 0; i < len(std); i++ {
+		switch c := std[i]; {
+		case c == '"':
+			lastComma = -1
+			for i++; i < len(std) && std[i] != '"'; i++ {
+				if std[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(std) && std[i+1] == '/':
+			end := bytes.IndexByte(std[i:], '\n')
+			if end < 0 {
+				end = len(std) - i
+			}
+			blank(i, i+end)
+			i += end - 1
+		case c == '/' && i+1 < len(std) && std[i+1] == '*':
+			end := bytes.Index(std[i+2:], []byte("*/"))
+			if end < 0 {
+				blank(i, len(std))
+				return std
+			}
+			blank(i, i+2+end+2)
+			i += 2 + end + 1
+		case c == ',':
+			lastComma = i
+		case c == '}' || c == ']':
+			if lastComma >= 0 {
+				std[lastComma] = ' '
+			}
+			lastComma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			lastComma = -1
+		}
+	}
+	return std
+}
+
+// parseObjectSpan decodes the object starting at offset start of std, which
+// must already be standardized, and records where each member lives.
+func parseObjectSpan(std []byte, start int) (*jsonObjectSpan, error) {
+	dec := json.NewDecoder(bytes.NewReader(std[start:]))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected object at offset %d", start)
+	}
+
+	span := &jsonObjectSpan{start: start}
+	pos := start + int(dec.InputOffset())
+	for dec.More() {
+		keyStart := skipSeparators(std, pos)
+		keyTok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := keyTok.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid object key at offset %d", keyStart)
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		valueEnd := start + int(dec.InputOffset())
+		span.members = append(span.members, jsonMember{
+			key:        key,
+			keyStart:   keyStart,
+			valueStart: valueEnd - len(raw),
+			valueEnd:   valueEnd,
+		})
+		pos = valueEnd
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	span.end = start + int(dec.InputOffset()) - 1
+	return span, nil
+}
+
+// member returns the member named key. Of duplicate keys the last one wins,
+// as it does for the JSON parsers of the clients.
+func (s *jsonObjectSpan) member(key string) (jsonMember, bool) {
+	idx := s.memberIndex(key)
+	if idx < 0 {
+		return jsonMember{}, false
+	}
+	return s.members[idx], true
+}
+
+func (s *jsonObjectSpan) memberIndex(key string) int {
+	for i := len(s.members) - 1; i >= 0; i-- {
+		if s.members[i].key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// jsonFileEditor rewrites single members of a client settings file. Bytes
+// outside the edited member, including comments in JSONC files, stay as they
+// were.
+type jsonFileEditor struct {
+	src  []byte
+	std  []byte
+	root *jsonObjectSpan
+}
+
+func newJSONFileEditor(src []byte) (*jsonFileEditor, error) {
+	if len(bytes.TrimSpace(src)) == 0 {
+		src = []byte("{}\n")
+	}
+	std := standardizeJSONC(src)
+	if err := json.Unmarshal(std, new(interface{})); err != nil {
+		return nil, newJSONParseError(src, err)
+	}
+	start := skipSeparators(std, 0)
+	if std[start] != '{' {
+		return nil, positionError(src, start, errors.New("top-level value is not an object"))
+	}
+	root, err := parseObjectSpan(std, start)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonFileEditor{src: src, std: std, root: root}, nil
+}
+
+// decodeMember returns the ordered value of a top-level member.
+func (e *jsonFileEditor) decodeMember(key string) (interface{}, bool, error) {
+	m, ok := e.root.member(key)
+	if !ok {
+		return nil, false, nil
+	}
+	value, err := decodeOrderedJSON(e.std[m.valueStart:m.valueEnd])
+	return value, true, err
+}
+
+// memberObject returns the span of a top-level member whose value is an
+// object.
+func (e *jsonFileEditor) memberObject(key string) (*jsonObjectSpan, bool) {
+	m, ok := e.root.member(key)
+	if !ok || e.std[m.valueStart] != '{' {
+		return nil, false
+	}
+	span, err := parseObjectSpan(e.std, m.valueStart)
+	if err != nil {
+		return nil, false
+	}
+	return span, true
+}
+
+// setMember replaces the value of a top-level member, or appends the member
+// to the end of the object when it does not exist yet.
+func (e *jsonFileEditor) setMember(key string, value interface{}) ([]byte, error) {
+	return e.setObjectMember(e.root, key, value)
+}
+
+// setObjectMember replaces or appends one member of obj. Comments and
+// formatting around the other members are left alone.
+func (e *jsonFileEditor) setObjectMember(obj *jsonObjectSpan, key string, value interface{}) ([]byte, error) {
+	indent, unit, multiline := e.indentation(obj)
+	rendered, err := marshalIndented(value, indent, unit, multiline)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if m, ok := obj.member(key); ok {
+		out.Write(e.src[:m.valueStart])
+		out.Write(rendered)
+		out.Write(e.src[m.valueEnd:])
+		return out.Bytes(), nil
+	}
+
+	keyBytes, _ := marshalJSON(key)
+	var member bytes.Buffer
+	if multiline {
+		member.WriteString("\n" + indent)
+	}
+	member.Write(keyBytes)
+	if multiline {
+		member.WriteString(": ")
+	} else {
+		member.WriteString(":")
+	}
+	member.Write(rendered)
+
+	if len(obj.members) == 0 {
+		out.Write(e.src[:obj.start+1])
+		out.Write(member.Bytes())
+		if multiline {
+			out.WriteString("\n" + e.lineIndent(obj.start))
+		}
+		out.Write(e.src[obj.end:])
+		return out.Bytes(), nil
+	}
+
+	last := obj.members[len(obj.members)-1]
+	commaAt, insertAt := last.valueEnd, last.valueEnd
+	separator := ","
+	if next := skipWhitespaceAndComments(e.src, last.valueEnd); next < obj.end && e.src[next] == ',' {
+		// keep the trailing comma style of JSONC files
+		commaAt, insertAt = next+1, next+1
+		separator = ""
+		member.WriteString(",")
+	}
+	if multiline {
+		// a comment at the end of the last member's line stays with it
+		insertAt = lineCommentEnd(e.src, insertAt)
+	}
+	out.Write(e.src[:commaAt])
+	out.WriteString(separator)
+	out.Write(e.src[commaAt:insertAt])
+	out.Write(member.Bytes())
+	out.Write(e.src[insertAt:])
+	return out.Bytes(), nil
+}
+
+// lineCommentEnd returns the end of a // comment that follows pos on the
+// same line, or pos when there is none.
+func lineCommentEnd(src []byte, pos int) int {
+	i := pos
+	for i < len(src) && (src[i] == ' ' || src[i] == '\t') {
+		i++
+	}
+	if !bytes.HasPrefix(src[i:], []byte("//")) {
+		return pos
+	}
+	end := bytes.IndexByte(src[i:], '\n')
+	if end < 0 {
+		return len(src)
+	}
+	if end > 0 && src[i+end-1] == '\r' {
+		end--
+	}
+	return i + end
+}
+
+// removeObjectMember deletes one member of obj and the comma that separates
+// it from its neighbours. When the member sits on lines of its own, those
+// lines are removed entirely, including a comment at the end of the line.
+func (e *jsonFileEditor) removeObjectMember(obj *jsonObjectSpan, key string) ([]byte, bool) {
+	idx := obj.memberIndex(key)
+	if idx < 0 {
+		return e.src, false
+	}
+	m := obj.members[idx]
+	next := skipWhitespaceAndComments(e.src, m.valueEnd)
+	hasComma := next < obj.end && e.src[next] == ','
+
+	// the last member without a trailing comma takes the comma after the
+	// member before it
+	comma := -1
+	from, to := m.keyStart, m.valueEnd
+	if hasComma {
+		to = next + 1
+	} else if idx > 0 {
+		comma = skipWhitespaceAndComments(e.src, obj.members[idx-1].valueEnd)
+	}
+
+	lineStart := bytes.LastIndexByte(e.src[:from], '\n') + 1
+	lineEnd := bytes.IndexByte(e.src[to:], '\n')
+	rest := []byte(nil)
+	if lineEnd >= 0 {
+		rest = bytes.TrimSpace(e.src[to : to+lineEnd])
+	}
+	if len(bytes.TrimSpace(e.src[lineStart:from])) == 0 && lineEnd >= 0 &&
+		(len(rest) == 0 || bytes.HasPrefix(rest, []byte("//"))) {
+		from, to = lineStart, to+lineEnd+1
+	} else if comma >= 0 {
+		from, comma = comma, -1
+	} else {
+		for to < len(e.src) && (e.src[to] == ' ' || e.src[to] == '\t') {
+			to++
+		}
+	}
+
+	var out bytes.Buffer
+	if comma >= 0 {
+		out.Write(e.src[:comma])
+		out.Write(e.src[comma+1 : from])
+	} else {
+		out.Write(e.src[:from])
+	}
+	out.Write(e.src[to:])
+	return out.Bytes(), true
+}
+
+// indentation detects how the members of obj are indented. indent is the
+// prefix of a member line and unit one nesting level. Objects written on a
+// single line are reported as not multiline and stay compact.
+func (e *jsonFileEditor) indentation(obj *jsonObjectSpan) (indent, unit string, multiline bool) {
+	base := e.lineIndent(obj.start)
+	if len(obj.members) == 0 {
+		unit = "  "
+		if obj != e.root {
+			unit, _, _ = e.indentation(e.root)
+		}
+		return base + unit, unit, true
+	}
+	first := obj.members[0].keyStart
+	if bytes.LastIndexByte(e.std[:first], '\n') < obj.start {
+		return "", "", false
+	}
+	indent = e.lineIndent(first)
+	unit = strings.TrimPrefix(indent, base)
+	if unit == "" || unit == indent && base != "" {
+		unit = "  "
+	}
+	return indent, unit, true
+}
+
+// lineIndent returns the leading whitespace of the line containing pos.
+func (e *jsonFileEditor) lineIndent(pos int) string {
+	lineStart := bytes.LastIndexByte(e.std[:pos], '\n') + 1
+	end := lineStart
+	for end < len(e.std) && (e.std[end] == ' ' || e.std[end] == '\t') {
+		end++
+	}
+	return string(e.std[lineStart:end])
+}
+
+func marshalIndented(value interface{}, indent, unit string, multiline bool) ([]byte, error) {
+	data, err := marshalJSON(value)
+	if err != nil || !multiline {
+		return data, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, indent, unit); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// skipWhitespaceAndComments returns the offset of the next token in src.
+func skipWhitespaceAndComments(src []byte, pos int) int {
+	for pos < len(src) {
+		switch {
+		case src[pos] == ' ' || src[pos] == '\t' || src[pos] == '\n' || src[pos] == '\r':
+			pos++
+		case bytes.HasPrefix(src[pos:], []byte("//")):
+			end := bytes.IndexByte(src[pos:], '\n')
+			if end < 0 {
+				return len(src)
+			}
+			pos += end
+		case bytes.HasPrefix(src[pos:], []byte("/*")):
+			end := bytes.Index(src[pos+2:], []byte("*/"))
+			if end < 0 {
+				return len(src)
+			}
+			pos += 2 + end + 2
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+func skipSeparators(std []byte, pos int) int {
+	for pos < len(std) {
+		switch std[pos] {
+		case ' ', '\t', '\n', '\r', ',', ':':
+			pos++
+		default:
+			return pos
+		}
+	}
+	return pos
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeForTest(t *testing.T, src string, names []string, servers map[string]interface{}) string {
+	t.Helper()
+	editor, err := newJSONFileEditor([]byte(src))
+	require.NoError(t, err)
+	output, err := mergeClientServers(editor, names, servers)
+	require.NoError(t, err)
+	return string(output)
+}
+
+// TestMergeClientServers_OnlyRewritesMcpServers mcpServers以外の書式が変わらないテスト
+func TestMergeClientServers_OnlyRewritesMcpServers(t *testing.T) {
+	src := `{
+    "theme": {"name":   "dark"},
+    "mcpServers": {
+        "old": {
+            "command": "o"
+        }
+    },
+    "numbers": [1,2,3]
+}
+`
+	output := mergeForTest(t, src, []string{"new"}, map[string]interface{}{
+		"new": map[string]interface{}{"command": "n"},
+	})
+	expected := `{
+    "theme": {"name":   "dark"},
+    "mcpServers": {
+        "old": {
+            "command": "o"
+        },
+        "new": {
+            "command": "n"
+        }
+    },
+    "numbers": [1,2,3]
+}
+`
+	assert.Equal(t, expected, output)
+}
+
+// TestMergeClientServers_AddsMissingSection mcpServersがない場合に末尾へ追加するテスト
+func TestMergeClientServers_AddsMissingSection(t *testing.T) {
+	servers := map[string]interface{}{"a": map[string]interface{}{"command": "a"}}
+
+	output := mergeForTest(t, "{\n\t\"x\": true\n}", []string{"a"}, servers)
+	assert.Equal(t, "{\n\t\"x\": true,\n\t\"mcpServers\": {\n\t\t\"a\": {\n\t\t\t\"command\": \"a\"\n\t\t}\n\t}\n}", output)
+
+	output = mergeForTest(t, "", []string{"a"}, servers)
+	assert.Equal(t, "{\n  \"mcpServers\": {\n    \"a\": {\n      \"command\": \"a\"\n    }\n  }\n}\n", output)
+
+	output = mergeForTest(t, "{}", []string{"a"}, servers)
+	assert.Equal(t, "{\n  \"mcpServers\": {\n    \"a\": {\n      \"command\": \"a\"\n    }\n  }\n}", output)
+}
+
+// TestMergeClientServers_JSONC コメントと末尾カンマを含むファイルを扱えるテスト
+func TestMergeClientServers_JSONC(t *testing.T) {
+	src := `// Zed settings
+{
+  /* editor */
+  "vim_mode": true, // keep me
+  "url": "http://example.com/*not a comment*/",
+}
+`
+	output := mergeForTest(t, src, []string{"a"}, map[string]interface{}{
+		"a": map[string]interface{}{"command": "a"},
+	})
+	expected := `// Zed settings
+{
+  /* editor */
+  "vim_mode": true, // keep me
+  "url": "http://example.com/*not a comment*/",
+  "mcpServers": {
+    "a": {
+      "command": "a"
+    }
+  },
+}
+`
+	assert.Equal(t, expected, output)
+
+	// the result must itself be readable again
+	editor, err := newJSONFileEditor([]byte(output))
+	require.NoError(t, err)
+	value, found, err := editor.decodeMember("mcpServers")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, []string{"a"}, value.(*jsonObject).keys)
+}
+
+// TestNewJSONFileEditor_Invalid 壊れたファイルや配列のファイルをエラーにするテスト
+func TestNewJSONFileEditor_Invalid(t *testing.T) {
+	for _, src := range []string{`{"a": }`, `[1, 2]`, `{"a": 1} {"b": 2}`} {
+		_, err := newJSONFileEditor([]byte(src))
+		assert.Error(t, err, src)
+	}
+}
+
+// TestMergeClientServers_KeepsCommentsInsideMcpServers 変更のないサーバーとコメントを残すテスト
+func TestMergeClientServers_KeepsCommentsInsideMcpServers(t *testing.T) {
+	src := `{
+  "mcpServers": {
+    // team servers
+    "fetch": {"command": "uvx"}, // unchanged
+    /* personal */
+    "notes": {
+      "command": "old",
+    },
+  },
+}
+`
+	output := mergeForTest(t, src, []string{"fetch", "notes"}, map[string]interface{}{
+		"fetch": map[string]interface{}{"command": "uvx"},
+		"notes": map[string]interface{}{"command": "new"},
+	})
+	expected := `{
+  "mcpServers": {
+    // team servers
+    "fetch": {"command": "uvx"}, // unchanged
+    /* personal */
+    "notes": {
+      "command": "new"
+    },
+  },
+}
+`
+	assert.Equal(t, expected, output)
+}
+
+// TestReadClientServers_JSONC コメント付きのクライアント設定をインポートできるテスト
+func TestReadClientServers_JSONC(t *testing.T) {
+	home := t.TempDir()
+	src := `{
+  // Zed
+  "context_servers": {},
+  "mcpServers": {
+    "fetch": {"command": "uvx", "args": ["a//b", "/*c*/"],}, // trailing comma
+  },
+}`
+	require.NoError(t, os.WriteFile(filepath.Join(home, "settings.json"), []byte(src), 0600))
+
+	names, servers, _, status, err := readClientServers(map[string]interface{}{"path": "settings.json"}, home)
+	require.NoError(t, err)
+	assert.Equal(t, "imported", status)
+	assert.Equal(t, []string{"fetch"}, names)
+	assert.Equal(t, map[string]interface{}{"command": "uvx", "args": []interface{}{"a//b", "/*c*/"}}, servers["fetch"])
+}
+
+// TestMergeClientServers_EmptyMcpServers 空のmcpServersにインデントを合わせて追加するテスト
+func TestMergeClientServers_EmptyMcpServers(t *testing.T) {
+	src := "{\n    \"mcpServers\": {},\n    \"x\": 1\n}\n"
+	output := mergeForTest(t, src, []string{"a"}, map[string]interface{}{"a": map[string]interface{}{"command": "a"}})
+	assert.Equal(t, "{\n    \"mcpServers\": {\n        \"a\": {\n            \"command\": \"a\"\n        }\n    },\n    \"x\": 1\n}\n", output)
+}
+
+// TestRemoveClientServers サーバー削除時に周りの書式とコメントを残すテスト
+func TestRemoveClientServers(t *testing.T) {
+	src := `{
+  "mcpServers": {
+    // team
+    "a": {"command": "a"}, // first
+    "b": {
+      "command": "b"
+    },
+    "c": {"command": "c"}
+  }
+}
+`
+	output, err := removeClientServers([]byte(src), []string{"b"})
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "mcpServers": {
+    // team
+    "a": {"command": "a"}, // first
+    "c": {"command": "c"}
+  }
+}
+`, string(output))
+
+	output, err = removeClientServers(output, []string{"c"})
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "mcpServers": {
+    // team
+    "a": {"command": "a"} // first
+  }
+}
+`, string(output))
+
+	output, err = removeClientServers(output, []string{"a", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"mcpServers\": {\n    // team\n  }\n}\n", string(output))
+
+	output, err = removeClientServers([]byte(`{"mcpServers": {"a": 1, "b": 2, "c": 3}}`), []string{"a", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, `{"mcpServers": {"b": 2}}`, string(output))
+}
+
+// TestMergeClientServers_KeepsServerKeyOrder 置き換えたサーバーのキー順とエスケープしない値のテスト
+func TestMergeClientServers_KeepsServerKeyOrder(t *testing.T) {
+	src := `{
+  "mcpServers": {
+    "a": {
+      "command": "old",
+      "env": {"Z": "1", "A": "2"},
+      "args": ["x"]
+    }
+  }
+}
+`
+	output := mergeForTest(t, src, []string{"a"}, map[string]interface{}{
+		"a": map[string]interface{}{
+			"command": "new",
+			"args":    []interface{}{"--url", "http://h/?a=1&b=<2>"},
+			"env":     map[string]interface{}{"A": "2", "Z": "1", "M": "3"},
+			"cwd":     "/tmp",
+		},
+	})
+	expected := `{
+  "mcpServers": {
+    "a": {
+      "command": "new",
+      "env": {
+        "Z": "1",
+        "A": "2",
+        "M": "3"
+      },
+      "args": [
+        "--url",
+        "http://h/?a=1&b=<2>"
+      ],
+      "cwd": "/tmp"
+    }
+  }
+}
+`
+	assert.Equal(t, expected, output)
+}
+
+// TestMergeClientServers_AppendsAfterLineComment 末尾カンマの後のコメントより後ろへ追加するテスト
+func TestMergeClientServers_AppendsAfterLineComment(t *testing.T) {
+	for _, src := range []string{
+		"{\n  \"mcpServers\": {\n    \"a\": {\"command\": \"a\"}, // first\n  }\n}\n",
+		"{\n  \"mcpServers\": {\n    \"a\": {\"command\": \"a\"} // first\n  }\n}\n",
+	} {
+		output := mergeForTest(t, src, []string{"b"}, map[string]interface{}{"b": map[string]interface{}{"command": "b"}})
+		assert.Contains(t, output, "\"a\": {\"command\": \"a\"}, // first\n    \"b\": {\n", src)
+	}
+}
+
+// TestJSONFileEditor_DuplicateKeys 重複したキーは置き換えも削除も最後のものを使うテスト
+func TestJSONFileEditor_DuplicateKeys(t *testing.T) {
+	src := "{\n  \"mcpServers\": {\n    \"a\": {\"command\": \"first\"},\n    \"a\": {\"command\": \"last\"}\n  }\n}\n"
+
+	output := mergeForTest(t, src, []string{"a"}, map[string]interface{}{"a": map[string]interface{}{"command": "new"}})
+	assert.Equal(t, "{\n  \"mcpServers\": {\n    \"a\": {\"command\": \"first\"},\n    \"a\": {\n      \"command\": \"new\"\n    }\n  }\n}\n", output)
+
+	removed, err := removeClientServers([]byte(src), []string{"a"})
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"mcpServers\": {\n    \"a\": {\"command\": \"first\"}\n  }\n}\n", string(removed))
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// jsonObject is a JSON object that remembers the order of its keys, so a
+// client file can be written back with its keys where they were and new keys
+// appended at the end.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newJSONObject() *jsonObject {
+	return &jsonObject{values: make(map[string]interface{})}
+}
+
+func (o *jsonObject) get(key string) (interface{}, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// set replaces the value of an existing key in place or appends a new key.
+func (o *jsonObject) set(key string, value interface{}) {
+	if _, exists := o.values[key]; !exists {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		keyBytes, err := marshalJSON(key)
+		if err != nil {
+			return nil, err
+		}
+		valueBytes, err := marshalJSON(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keyBytes)
+		buf.WriteByte(':')
+		buf.Write(valueBytes)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalJSON is json.Marshal without HTML escaping, so URLs and shell
+// commands keep their & < > as users wrote them.
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// orderedLike returns value with its object keys in the order of current,
+// the value it replaces in a client file. Keys current does not have follow
+// in sorted order.
+func orderedLike(value, current interface{}) interface{} {
+	switch val := value.(type) {
+	case map[string]interface{}:
+		cur, _ := current.(*jsonObject)
+		if cur == nil {
+			cur = newJSONObject()
+		}
+		obj := newJSONObject()
+		for _, key := range cur.keys {
+			if v, ok := val[key]; ok {
+				obj.set(key, orderedLike(v, cur.values[key]))
+			}
+		}
+		for _, key := range sortedKeys(val) {
+			if _, ok := obj.get(key); !ok {
+				obj.set(key, orderedLike(val[key], nil))
+			}
+		}
+		return obj
+	case []interface{}:
+		cur, _ := current.([]interface{})
+		list := make([]interface{}, len(val))
+		for i, elem := range val {
+			var c interface{}
+			if i < len(cur) {
+				c = cur[i]
+			}
+			list[i] = orderedLike(elem, c)
+		}
+		return list
+	default:
+		return value
+	}
+}
+
+// decodeOrderedJSON decodes data keeping object key order. Objects become
+// *jsonObject and numbers json.Number so that values round-trip unchanged.
+func decodeOrderedJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	value, err := decodeOrderedValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err == nil {
+		return nil, fmt.Errorf("unexpected data after top-level value")
+	}
+	return value, nil
+}
+
+func decodeOrderedValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := newJSONObject()
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := keyTok.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid object key %v", keyTok)
+				}
+				value, err := decodeOrderedValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				obj.set(key, value)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return obj, nil
+		case '[':
+			list := []interface{}{}
+			for dec.More() {
+				value, err := decodeOrderedValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return list, nil
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", t)
+	default:
+		return t, nil
+	}
+}
+
+// readClientJSONObject decodes a client file whose top level must be an
+// object. Comments and trailing commas are accepted, as VS Code and Zed
+// allow them in their settings files.
+func readClientJSONObject(data []byte) (*jsonObject, error) {
+	std := standardizeJSONC(data)
+	// validate first: the token decoder reports truncated input without a
+	// position
+	if err := json.Unmarshal(std, new(interface{})); err != nil {
+		return nil, newJSONParseError(data, err)
+	}
+	value, err := decodeOrderedJSON(std)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := value.(*jsonObject)
+	if !ok {
+		return nil, fmt.Errorf("top-level value is not an object")
+	}
+	return obj, nil
+}
+
+// mcpServersObject returns the mcpServers object of a client file, or nil
+// when it is missing or not an object.
+func mcpServersObject(root *jsonObject) *jsonObject {
+	if root == nil {
+		return nil
+	}
+	value, _ := root.get("mcpServers")
+	servers, _ := value.(*jsonObject)
+	return servers
+}
+
+// plainJSONValue converts decoded ordered JSON into plain maps, slices and
+// Go numbers, the form used for YAML output and comparisons.
+func plainJSONValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case *jsonObject:
+		m := make(map[string]interface{}, len(val.keys))
+		for _, key := range val.keys {
+			m[key] = plainJSONValue(val.values[key])
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(val))
+		for i, elem := range val {
+			list[i] = plainJSONValue(elem)
+		}
+		return list
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	default:
+		return val
+	}
+}
+
+// sameServerConfig compares two server definitions by value, ignoring key
+// order and the numeric representation used by YAML and JSON decoders.
+func sameServerConfig(a, b interface{}) bool {
+	return reflect.DeepEqual(normalizeJSONValue(a), normalizeJSONValue(b))
+}
+
+func normalizeJSONValue(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return v
+	}
+	return normalized
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+)
+
+// logger records the decisions the planner makes: clients that are skipped
+// and why, the paths client files resolve to and how servers compare. It
+// discards everything unless -v, -vv or --log-file ask for it.
+var logger = slog.New(slog.DiscardHandler)
+
+// logLevel maps the number of -v flags to the lowest level logged: -v shows
+// decisions, -vv adds per-server and per-path details.
+func logLevel(verbosity int) slog.Level {
+	switch {
+	case verbosity >= 2:
+		return slog.LevelDebug
+	case verbosity == 1:
+		return slog.LevelInfo
+	}
+	return slog.LevelWarn
+}
+
+// setupLogging points logger at stderr or the log file and returns a function
+// that closes the file. With --log-file and no -v, decisions are logged.
+func setupLogging(verbosity int, logFile string) (func(), error) {
+	if verbosity == 0 && logFile == "" {
+		logger = slog.New(slog.DiscardHandler)
+		return func() {}, nil
+	}
+	var out io.Writer = os.Stderr
+	closeLog := func() {}
+	if logFile != "" {
+		f, err := os.OpenFile(logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, SecureFileMode)
+		if err != nil {
+			return nil, errorf("could not open the log file: %v", err)
+		}
+		out = f
+		closeLog = func() { f.Close() }
+		if verbosity == 0 {
+			verbosity = 1
+		}
+	}
+	logger = slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{Level: logLevel(verbosity)}))
+	return closeLog, nil
+}
+
+// setupTUILogging is setupLogging for the TUI, which takes over the terminal:
+// logs only go to --log-file, and -v without it logs nothing rather than
+// writing over the screen.
+func setupTUILogging(verbosity int, logFile string) (func(), error) {
+	if logFile == "" {
+		verbosity = 0
+	}
+	return setupLogging(verbosity, logFile)
+}
+
+// logSkip records a client the planner leaves out.
+func logSkip(client, reason string, args ...any) {
+	logger.Info("client skipped", append([]any{"client", client, "reason", reason}, args...)...)
+}
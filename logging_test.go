@@ -0,0 +1,205 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withLogFile テストの間だけログをファイルに書き出す
+func withLogFile(t *testing.T, verbosity int) string {
+	t.Helper()
+	original := logger
+	t.Cleanup(func() { logger = original })
+	logFile := filepath.Join(t.TempDir(), "mcpyammy.log")
+	closeLog, err := setupLogging(verbosity, logFile)
+	require.NoError(t, err)
+	t.Cleanup(closeLog)
+	return logFile
+}
+
+// readLog ログファイルの内容を読む
+func readLog(t *testing.T, logFile string) string {
+	t.Helper()
+	data, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// TestLogLevel -vの数とログレベルの対応テスト
+func TestLogLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelWarn, logLevel(0))
+	assert.Equal(t, slog.LevelInfo, logLevel(1))
+	assert.Equal(t, slog.LevelDebug, logLevel(2))
+	assert.Equal(t, slog.LevelDebug, logLevel(3))
+}
+
+// TestSetupLogging ログの出力先とエラーのテスト
+func TestSetupLogging(t *testing.T) {
+	logFile := withLogFile(t, 0)
+	logger.Info("decision")
+	logger.Debug("detail")
+	content := readLog(t, logFile)
+	assert.Contains(t, content, "decision", "--log-fileだけでも判断を記録する")
+	assert.NotContains(t, content, "detail")
+
+	_, err := setupLogging(1, filepath.Join(t.TempDir(), "missing", "mcpyammy.log"))
+	assert.ErrorContains(t, err, "could not open the log file")
+
+	closeLog, err := setupLogging(0, "")
+	require.NoError(t, err)
+	closeLog()
+	assert.False(t, logger.Enabled(t.Context(), slog.LevelWarn), "指定がなければ何も記録しない")
+}
+
+// TestSetupTUILogging TUIでは--log-fileがなければ-vでもログを書かないテスト
+func TestSetupTUILogging(t *testing.T) {
+	original := logger
+	t.Cleanup(func() { logger = original })
+
+	closeLog, err := setupTUILogging(2, "")
+	require.NoError(t, err)
+	closeLog()
+	assert.False(t, logger.Enabled(t.Context(), slog.LevelWarn), "画面を崩さないように標準エラー出力には書かない")
+
+	logFile := filepath.Join(t.TempDir(), "mcpyammy.log")
+	closeLog, err = setupTUILogging(2, logFile)
+	require.NoError(t, err)
+	logger.Debug("detail")
+	closeLog()
+	assert.Contains(t, readLog(t, logFile), "detail", "--log-fileがあれば-vvの内容を記録する")
+}
+
+// TestPerformApply_LogsSkips TUIの適用でスキップしたクライアントと理由を記録するテスト
+func TestPerformApply_LogsSkips(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, ".state"))
+	yamlFile := filepath.Join(home, "servers.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`clients:
+  claude:
+    path: ~/.claude.json
+    servers:
+      - name: a
+        command: a
+  cursor:
+    path: /etc/mcp.json
+    servers:
+      - name: a
+        command: a
+  gemini:
+    path: ~/.gemini/settings.json
+    servers: []
+`), 0600))
+	logFile := withLogFile(t, 2)
+
+	_, err := performApply(yamlFile, Selection{})
+	require.NoError(t, err)
+	_, err = performApply(yamlFile, Selection{})
+	require.NoError(t, err)
+
+	content := readLog(t, logFile)
+	assert.Contains(t, content, `msg="client applied" client=claude`)
+	assert.Contains(t, content, `msg="client skipped" client=cursor reason="unsafe path"`)
+	assert.Contains(t, content, `msg="client skipped" client=gemini reason="no servers"`)
+	assert.Contains(t, content, `msg="client skipped" client=claude reason="up to date"`)
+	assert.Contains(t, content, `msg="path resolved" path=~/.claude.json`)
+}
+
+// TestApplySelection_LogsSkips 選択から外れたクライアントとサーバーを記録するテスト
+func TestApplySelection_LogsSkips(t *testing.T) {
+	logFile := withLogFile(t, 2)
+	var yamlContent map[string]interface{}
+	require.NoError(t, parseYAMLSafely([]byte(selectionYAML), MaxYAMLSize, &yamlContent))
+
+	require.NoError(t, applySelection(yamlContent, "servers.yaml", Selection{Clients: []string{"claude", "cursor"}, Servers: []string{"git"}}))
+
+	content := readLog(t, logFile)
+	assert.Contains(t, content, `msg="client skipped" client=gemini reason="not selected with --client"`)
+	assert.Contains(t, content, `msg="server skipped" client=claude server=fetch reason="not selected with --server"`)
+	assert.Contains(t, content, `msg="client skipped" client=cursor reason="has none of the servers selected with --server"`)
+}
+
+// TestCompareClient_LogsServers サーバーごとの比較結果を記録するテスト
+func TestCompareClient_LogsServers(t *testing.T) {
+	home := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".claude.json"), []byte(`{"mcpServers": {"a": {"command": "old"}, "b": {"command": "b"}}}`), 0600))
+	logFile := withLogFile(t, 2)
+
+	config := map[string]interface{}{
+		"path": ".claude.json",
+		"servers": []interface{}{
+			map[string]interface{}{"name": "a", "command": "new"},
+			map[string]interface{}{"name": "c", "command": "c"},
+		},
+	}
+	compareClient("claude", config, home)
+
+	content := readLog(t, logFile)
+	assert.Contains(t, content, `msg="server compared" client=claude server=a result=modified`)
+	assert.Contains(t, content, `msg="server compared" client=claude server=c result=missing`)
+	assert.Contains(t, content, `msg="server compared" client=claude server=b result=extra`)
+}
+
+// TestApplyClient_LogsDryRun dry-runは適用済みとして記録しないテスト
+func TestApplyClient_LogsDryRun(t *testing.T) {
+	home := t.TempDir()
+	config := map[string]interface{}{
+		"path":    ".claude.json",
+		"servers": []interface{}{map[string]interface{}{"name": "a", "command": "a"}},
+	}
+
+	logFile := withLogFile(t, 1)
+	require.NoError(t, applyClient("claude", config, home, ApplyOptions{DryRun: true}).err)
+	assert.NotContains(t, readLog(t, logFile), "client applied")
+
+	logFile = withLogFile(t, 2)
+	require.NoError(t, applyClient("claude", config, home, ApplyOptions{DryRun: true}).err)
+	assert.Contains(t, readLog(t, logFile), `msg="client planned" client=claude`)
+}
+
+// TestMain_Verbosity -v・-vv・--log-fileの解析テスト
+func TestMain_Verbosity(t *testing.T) {
+	defer setupTest()()
+	mocks := &testMocks{}
+	mocks.setup()
+	defer mocks.restore()
+	original := logger
+	t.Cleanup(func() { logger = original })
+
+	var enabled []slog.Level
+	statusFunc = func(string, StatusOptions) error {
+		enabled = nil
+		for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo} {
+			if logger.Enabled(t.Context(), level) {
+				enabled = append(enabled, level)
+			}
+		}
+		return nil
+	}
+	os.Args = []string{"mcpyammy", "status", "test.yaml"}
+	main()
+	assert.Empty(t, enabled)
+
+	os.Args = []string{"mcpyammy", "-v", "status", "test.yaml"}
+	main()
+	assert.Equal(t, []slog.Level{slog.LevelInfo}, enabled)
+
+	os.Args = []string{"mcpyammy", "-vv", "status", "test.yaml"}
+	main()
+	assert.Equal(t, []slog.Level{slog.LevelDebug, slog.LevelInfo}, enabled)
+
+	os.Args = []string{"mcpyammy", "-v", "-v", "status", "test.yaml"}
+	main()
+	assert.Equal(t, []slog.Level{slog.LevelDebug, slog.LevelInfo}, enabled)
+
+	logFile := filepath.Join(t.TempDir(), "mcpyammy.log")
+	os.Args = []string{"mcpyammy", "--log-file", logFile, "status", "test.yaml"}
+	main()
+	assert.Equal(t, []slog.Level{slog.LevelInfo}, enabled)
+	assert.Contains(t, readLog(t, logFile), `msg="command started" command=status`)
+}
@@ -1,15 +1,32 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/goccy/go-yaml"
 )
 
 const (
-	CommandApply  = "apply"
-	CommandImport = "import"
+	CommandApply        = "apply"
+	CommandImport       = "import"
+	CommandStatus       = "status"
+	CommandWatch        = "watch"
+	CommandSync         = "sync"
+	CommandTest         = "test"
+	CommandTools        = "tools"
+	CommandDoctor       = "doctor"
+	CommandConfig       = "config"
+	CommandConfigRender = "config render"
+	CommandDiff         = "diff"
+	CommandValidate     = "validate"
+	CommandHelp         = "help"
+	CommandCompletion   = "completion"
+
+	SubcommandRender = "render"
 
 	MaxYAMLSize  = 1024 * 1024 // 1MB
 	MaxNestLevel = 50
@@ -18,18 +35,31 @@ const (
 	DirectoryMode  = 0755
 )
 
+// Version is the release version, set at build time with
+// -ldflags "-X main.Version=...".
+var Version = "dev"
+
 var (
 	osExit           func(int)
-	runTUIFunc       func()
-	applyConfigFunc  func(string)
-	importConfigFunc func(string)
+	runTUIFunc       func(string) error
+	applyConfigFunc  func(string, ApplyOptions) error
+	importConfigFunc func(string, ImportOptions) error
+	renderConfigFunc func(string) error
+	statusFunc       func(string, StatusOptions) error
+	watchFunc        func(string, WatchOptions) error
+	syncFunc         func(string, SyncOptions) error
+	testFunc         func(string, TestOptions) error
+	toolsFunc        func(string, ToolsOptions) error
+	doctorFunc       func(string) error
+	diffFunc         func(string, DiffOptions) error
+	validateFunc     func(string, ValidateOptions) error
 )
 
 type OrderedServer struct {
 	Name    string                 `yaml:"name"`
 	Command string                 `yaml:"command,omitempty"`
-	Args    []string               `yaml:"args,omitempty"`
-	Env     map[string]string      `yaml:"env,omitempty"`
+	Args    []interface{}          `yaml:"args,omitempty"`
+	Env     map[string]interface{} `yaml:"env,omitempty"`
 	Extra   map[string]interface{} `yaml:",inline"`
 }
 
@@ -38,34 +68,199 @@ func init() {
 	runTUIFunc = runTUI
 	applyConfigFunc = applyConfig
 	importConfigFunc = importConfig
+	renderConfigFunc = renderConfig
+	statusFunc = showStatus
+	watchFunc = watchConfig
+	syncFunc = syncConfig
+	testFunc = testConfigServers
+	toolsFunc = listConfigTools
+	doctorFunc = doctorConfig
+	diffFunc = diffConfig
+	validateFunc = validateConfig
 }
 
 func main() {
-	if len(os.Args) == 1 {
-		runTUIFunc()
+	if len(os.Args) > 1 && os.Args[1] == CommandComplete {
+		for _, candidate := range completeWords(os.Args[2:]) {
+			fmt.Println(candidate)
+		}
+		return
+	}
+
+	globals := &globalOptions{}
+	globalFlags := newFlagSet("mcpyammy")
+	globals.register(globalFlags)
+	globalFlags.BoolVar(&globals.version, "version", false, "print the version and exit")
+	if err := globalFlags.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printUsage()
+			return
+		}
+		usageError(err, "")
 		return
 	}
+	if err := globals.applyLang(); err != nil {
+		usageError(err, "")
+		return
+	}
+	if globals.version {
+		fmt.Printf("mcpyammy %s\n", Version)
+		return
+	}
+	args := globalFlags.Args()
 
-	command := os.Args[1]
+	if len(args) == 0 {
+		closeLog, err := setupTUILogging(globals.verbosity, globals.logFile)
+		if err != nil {
+			exitWith(err)
+			return
+		}
+		err = runTUIFunc(globals.config)
+		closeLog()
+		exitWith(err)
+		return
+	}
 
-	if len(os.Args) < 3 {
-		fmt.Printf("Usage: mcp-setup %s <yaml-file>\n", command)
+	command, args := args[0], args[1:]
+	if command == CommandHelp {
+		printHelp(args)
+		return
+	}
+	if command == CommandConfig {
+		if len(args) == 0 || args[0] != SubcommandRender {
+			usageError(errorf("unknown config subcommand; expected %q", SubcommandRender), CommandConfigRender)
+			return
+		}
+		command, args = CommandConfigRender, args[1:]
+	}
+	cmd := findCommand(command)
+	if cmd == nil {
+		fmt.Println(msgf("Unknown command: %s", command))
+		printUsage()
 		osExit(1)
+		return
 	}
 
 	runner := &CLICommandRunner{}
-	runner.runCommand(command, os.Args[2])
+	positional, err := parseCommandFlags(command, args, runner, globals)
+	if errors.Is(err, flag.ErrHelp) {
+		printCommandHelp(cmd)
+		return
+	}
+	if err == nil {
+		err = globals.applyLang()
+	}
+	if err != nil {
+		usageError(err, command)
+		return
+	}
+	if len(positional) > cmd.maxArgs() {
+		usageError(errorf("too many arguments: %s", strings.Join(positional, " ")), command)
+		return
+	}
+
+	if cmd.args == argsShell {
+		if len(positional) == 0 {
+			usageError(errorf("missing shell (expected %s)", strings.Join(completionShells, ", ")), command)
+			return
+		}
+		if err := printCompletion(os.Stdout, positional[0]); err != nil {
+			usageError(err, command)
+		}
+		return
+	}
+
+	if cmd.args == argsClientServer {
+		// the positionals select a client and server, so the YAML comes
+		// from --config or discovery
+		var client, server string
+		if len(positional) > 0 {
+			client = positional[0]
+		}
+		if len(positional) > 1 {
+			server = positional[1]
+		}
+		runner.testOptions.Client, runner.testOptions.Server = client, server
+		runner.toolsOptions.Client, runner.toolsOptions.Server = client, server
+		positional = nil
+	}
+	yamlFile, ok := commandYAMLFile(positional, globals.config)
+	if !ok {
+		fmt.Fprintln(os.Stderr, msgf("No YAML file given and none found in: %s", strings.Join(discoveryLocations(), ", ")))
+		fmt.Fprintln(os.Stderr, msgf("Pass one with --config or run 'mcpyammy help %s'", command))
+		osExit(1)
+		return
+	}
+
+	closeLog, err := setupLogging(globals.verbosity, globals.logFile)
+	if err != nil {
+		exitWith(err)
+		return
+	}
+	logger.Info("command started", "command", command, "config", yamlFile)
+	var restore func()
+	if globals.quiet {
+		restore = silenceStdout()
+	}
+	err = runner.runCommand(command, yamlFile)
+	if restore != nil {
+		restore()
+	}
+	closeLog()
+	exitWith(err)
+}
+
+// exitWith prints the error a command returned, unless the command already
+// reported it, and exits with its code.
+func exitWith(err error) {
+	if err == nil {
+		return
+	}
+	var exit *exitError
+	if !errors.As(err, &exit) || !exit.reported {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+	osExit(exitCode(err))
+}
+
+// commandYAMLFile returns the positional YAML file, falling back to --config,
+// $MCPYAMMY_CONFIG and the default locations.
+func commandYAMLFile(positional []string, configFlag string) (string, bool) {
+	if len(positional) > 0 {
+		return positional[0], true
+	}
+	return resolveConfigPath(configFlag)
 }
 
-func printUsage() {
-	fmt.Println("Usage:")
-	fmt.Println("  mcp-setup apply <yaml-file>   Apply configuration from YAML file")
-	fmt.Println("  mcp-setup import <yaml-file>  Import existing mcp.json files to YAML format")
+// usageError reports a command line mistake and exits.
+func usageError(err error, command string) {
+	fmt.Fprintln(os.Stderr, err)
+	if command != "" {
+		fmt.Fprintln(os.Stderr, msgf("Run 'mcpyammy help %s' for usage.", command))
+	} else {
+		fmt.Fprintln(os.Stderr, msgf("Run 'mcpyammy help' for usage."))
+	}
+	osExit(1)
+}
+
+// silenceStdout sends normal output to the null device for --quiet. Errors
+// are written to stderr and still show.
+func silenceStdout() func() {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return func() {}
+	}
+	stdout := os.Stdout
+	os.Stdout = devNull
+	return func() {
+		os.Stdout = stdout
+		devNull.Close()
+	}
 }
 
-func parseYAMLSafely(yamlData []byte, maxSize int64, target interface{}) error {
+func parseYAMLSafely(yamlData []byte, maxSize int64, target interface{}, opts ...yaml.DecodeOption) error {
 	if int64(len(yamlData)) > maxSize {
-		return fmt.Errorf("YAMLファイルサイズが上限(%dKB)を超えています: %dKB",
+		return newError(ErrYAMLTooLarge, nil, "YAML file is larger than the limit of %dKB: %dKB",
 			maxSize/1024, int64(len(yamlData))/1024)
 	}
 
@@ -86,13 +281,13 @@ func parseYAMLSafely(yamlData []byte, maxSize int64, target interface{}) error {
 		}
 
 		if maxDetected > maxNestLevel {
-			return fmt.Errorf("YAML構造が深すぎます（最大%d階層）: %d階層検出",
+			return newError(ErrYAMLTooDeep, nil, "YAML is nested too deeply (at most %d levels): %d levels found",
 				maxNestLevel, maxDetected)
 		}
 	}
 
-	if err := yaml.Unmarshal(yamlData, target); err != nil {
-		return fmt.Errorf("YAML解析エラー: %v", err)
+	if err := yaml.UnmarshalWithOptions(yamlData, target, opts...); err != nil {
+		return errorf("YAML parse error: %w", err)
 	}
 	return nil
 }
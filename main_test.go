@@ -1,10 +1,16 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupTest() func() {
@@ -14,10 +20,45 @@ func setupTest() func() {
 	}
 }
 
+// isolateConfigDiscovery makes sure no servers.yaml is found in the real
+// home, XDG config directory or working directory.
+func isolateConfigDiscovery(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv(ConfigEnvVar, "")
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(home))
+	t.Cleanup(func() { os.Chdir(originalWd) })
+	return home
+}
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
 type testMocks struct {
-	originalRunTUI       func()
-	originalApplyConfig  func(string)
-	originalImportConfig func(string)
+	originalRunTUI       func(string) error
+	originalApplyConfig  func(string, ApplyOptions) error
+	originalImportConfig func(string, ImportOptions) error
+	originalTest         func(string, TestOptions) error
+	originalTools        func(string, ToolsOptions) error
+	originalStatus       func(string, StatusOptions) error
+	originalDiff         func(string, DiffOptions) error
+	originalValidate     func(string, ValidateOptions) error
 	originalOsExit       func(int)
 }
 
@@ -25,6 +66,11 @@ func (m *testMocks) setup() {
 	m.originalRunTUI = runTUIFunc
 	m.originalApplyConfig = applyConfigFunc
 	m.originalImportConfig = importConfigFunc
+	m.originalTest = testFunc
+	m.originalTools = toolsFunc
+	m.originalStatus = statusFunc
+	m.originalDiff = diffFunc
+	m.originalValidate = validateFunc
 	m.originalOsExit = osExit
 }
 
@@ -32,6 +78,11 @@ func (m *testMocks) restore() {
 	runTUIFunc = m.originalRunTUI
 	applyConfigFunc = m.originalApplyConfig
 	importConfigFunc = m.originalImportConfig
+	testFunc = m.originalTest
+	toolsFunc = m.originalTools
+	statusFunc = m.originalStatus
+	diffFunc = m.originalDiff
+	validateFunc = m.originalValidate
 	osExit = m.originalOsExit
 }
 
@@ -45,7 +96,7 @@ func TestMain_NoArguments_LaunchesTUI(t *testing.T) {
 	// 引数をTUIモード用に設定
 	os.Args = []string{"mcp-setup"}
 	tuiLaunched := false
-	runTUIFunc = func() { tuiLaunched = true }
+	runTUIFunc = func(string) error { tuiLaunched = true; return nil }
 
 	main()
 
@@ -66,9 +117,10 @@ func TestMain_ApplyCommand_CallsApplyConfig(t *testing.T) {
 
 	applyCalled := false
 	var applyFile string
-	applyConfigFunc = func(file string) {
+	applyConfigFunc = func(file string, _ ApplyOptions) error {
 		applyCalled = true
 		applyFile = file
+		return nil
 	}
 
 	main()
@@ -77,6 +129,143 @@ func TestMain_ApplyCommand_CallsApplyConfig(t *testing.T) {
 	assert.Equal(t, "test.yaml", applyFile, "正しいファイル名が渡されるべき")
 }
 
+// TestMain_ApplyCommand_ForceReset --force-resetがファイル名の前後どちらでも渡されるテスト
+func TestMain_ApplyCommand_ForceReset(t *testing.T) {
+	defer setupTest()()
+
+	mocks := &testMocks{}
+	mocks.setup()
+	defer mocks.restore()
+
+	for _, args := range [][]string{
+		{"mcp-setup", "apply", "--force-reset", "test.yaml"},
+		{"mcp-setup", "apply", "test.yaml", "--force-reset"},
+	} {
+		os.Args = args
+		var options ApplyOptions
+		applyConfigFunc = func(_ string, opts ApplyOptions) error {
+			options = opts
+			return nil
+		}
+
+		main()
+
+		assert.True(t, options.ForceReset, "--force-resetが渡されるべき")
+	}
+}
+
+// TestMain_ApplyAndImport_Selection --clientと--serverがapplyとimportに渡されるテスト
+func TestMain_ApplyAndImport_Selection(t *testing.T) {
+	defer setupTest()()
+
+	mocks := &testMocks{}
+	mocks.setup()
+	defer mocks.restore()
+
+	var applyOptions ApplyOptions
+	applyConfigFunc = func(_ string, opts ApplyOptions) error { applyOptions = opts; return nil }
+	os.Args = []string{"mcpyammy", "apply", "--client", "claude, gemini,claude", "test.yaml", "--server=fetch"}
+	main()
+	assert.Equal(t, Selection{Clients: []string{"claude", "gemini"}, Servers: []string{"fetch"}}, applyOptions.Selection)
+
+	var importOptions ImportOptions
+	importConfigFunc = func(_ string, opts ImportOptions) error { importOptions = opts; return nil }
+	os.Args = []string{"mcpyammy", "import", "--client=cursor", "test.yaml"}
+	main()
+	assert.Equal(t, Selection{Clients: []string{"cursor"}}, importOptions.Selection)
+	assert.Equal(t, ConflictKeepYAML, importOptions.OnConflict)
+
+	os.Args = []string{"mcpyammy", "apply", "test.yaml"}
+	main()
+	assert.True(t, applyOptions.Selection.empty(), "指定しなければ全クライアントが対象")
+}
+
+// TestMain_OutputFlag --outputがコマンドに渡されるテスト
+func TestMain_OutputFlag(t *testing.T) {
+	defer setupTest()()
+
+	mocks := &testMocks{}
+	mocks.setup()
+	defer mocks.restore()
+
+	var statusOptions StatusOptions
+	statusFunc = func(_ string, opts StatusOptions) error { statusOptions = opts; return nil }
+	os.Args = []string{"mcpyammy", "status", "--output", "json", "test.yaml"}
+	main()
+	assert.Equal(t, OutputJSON, statusOptions.Output)
+
+	var validateOptions ValidateOptions
+	validateFunc = func(_ string, opts ValidateOptions) error { validateOptions = opts; return nil }
+	os.Args = []string{"mcpyammy", "validate", "test.yaml"}
+	main()
+	assert.Equal(t, OutputText, validateOptions.Output)
+
+	var applyOptions ApplyOptions
+	applyConfigFunc = func(_ string, opts ApplyOptions) error { applyOptions = opts; return nil }
+	os.Args = []string{"mcpyammy", "apply", "--output=json", "test.yaml"}
+	main()
+	assert.Equal(t, OutputJSON, applyOptions.Output)
+
+	os.Args = []string{"mcpyammy", "apply", "--json", "test.yaml"}
+	main()
+	assert.Equal(t, OutputJSON, applyOptions.Output, "--jsonは--output jsonと同じ")
+	os.Args = []string{"mcpyammy", "validate", "--json=false", "test.yaml"}
+	main()
+	assert.Equal(t, OutputText, validateOptions.Output)
+
+	var exitCode int
+	osExit = func(code int) { exitCode = code }
+	os.Args = []string{"mcpyammy", "status", "--output", "xml", "test.yaml"}
+	main()
+	assert.Equal(t, 1, exitCode)
+}
+
+// TestMain_TestCommand_SelectsClientAndServer testコマンドの引数がクライアントとサーバーになるテスト
+func TestMain_TestCommand_SelectsClientAndServer(t *testing.T) {
+	defer setupTest()()
+
+	mocks := &testMocks{}
+	mocks.setup()
+	defer mocks.restore()
+
+	os.Args = []string{"mcp-setup", "--config", "test.yaml", "test", "claude", "fetch", "--timeout", "3s"}
+	var yamlFile string
+	var options TestOptions
+	testFunc = func(file string, opts TestOptions) error {
+		yamlFile = file
+		options = opts
+		return nil
+	}
+
+	main()
+
+	assert.Equal(t, "test.yaml", yamlFile)
+	assert.Equal(t, TestOptions{Client: "claude", Server: "fetch", Timeout: 3 * time.Second}, options)
+}
+
+// TestMain_ToolsCommand_Budget toolsコマンドの予算と絞り込みのテスト
+func TestMain_ToolsCommand_Budget(t *testing.T) {
+	defer setupTest()()
+
+	mocks := &testMocks{}
+	mocks.setup()
+	defer mocks.restore()
+
+	var options ToolsOptions
+	toolsFunc = func(_ string, opts ToolsOptions) error {
+		options = opts
+		return nil
+	}
+
+	os.Args = []string{"mcp-setup", "--config", "test.yaml", "tools", "--budget", "8000", "claude"}
+	main()
+	assert.Equal(t, ToolsOptions{Client: "claude", Budget: 8000, Timeout: DefaultTestTimeout}, options)
+
+	os.Args = []string{"mcp-setup", "--config", "test.yaml", "tools"}
+	main()
+	assert.Equal(t, DefaultToolTokenBudget, options.Budget)
+}
+
 // TestMain_ImportCommand_CallsImportConfig importコマンドテスト
 func TestMain_ImportCommand_CallsImportConfig(t *testing.T) {
 	defer setupTest()()
@@ -89,9 +278,10 @@ func TestMain_ImportCommand_CallsImportConfig(t *testing.T) {
 
 	importCalled := false
 	var importFile string
-	importConfigFunc = func(file string) {
+	importConfigFunc = func(file string, _ ImportOptions) error {
 		importCalled = true
 		importFile = file
+		return nil
 	}
 
 	main()
@@ -127,6 +317,7 @@ func TestMain_UnknownCommand_ExitsWithError(t *testing.T) {
 // TestMain_ApplyWithoutFile_ExitsWithError ファイル引数なしエラーテスト
 func TestMain_ApplyWithoutFile_ExitsWithError(t *testing.T) {
 	defer setupTest()()
+	isolateConfigDiscovery(t)
 
 	mocks := &testMocks{}
 	mocks.setup()
@@ -148,6 +339,7 @@ func TestMain_ApplyWithoutFile_ExitsWithError(t *testing.T) {
 // TestMain_ImportWithoutFile_ExitsWithError importファイル引数なしエラーテスト
 func TestMain_ImportWithoutFile_ExitsWithError(t *testing.T) {
 	defer setupTest()()
+	isolateConfigDiscovery(t)
 
 	mocks := &testMocks{}
 	mocks.setup()
@@ -166,6 +358,188 @@ func TestMain_ImportWithoutFile_ExitsWithError(t *testing.T) {
 	assert.True(t, exitCalled, "ファイル引数なしでos.Exitが呼ばれるべき")
 }
 
+// TestMain_DiscoversYAMLFile YAMLファイル省略時に探索されるテスト
+func TestMain_DiscoversYAMLFile(t *testing.T) {
+	defer setupTest()()
+	home := isolateConfigDiscovery(t)
+	require.NoError(t, os.WriteFile(filepath.Join(home, DefaultYAMLFile), []byte("clients: {}\n"), 0600))
+
+	mocks := &testMocks{}
+	mocks.setup()
+	defer mocks.restore()
+
+	var applyFile string
+	applyConfigFunc = func(file string, _ ApplyOptions) error { applyFile = file; return nil }
+	os.Args = []string{"mcpyammy", "apply"}
+	main()
+	assert.Equal(t, DefaultYAMLFile, applyFile)
+
+	os.Args = []string{"mcpyammy", "apply", "--config", "other.yaml"}
+	main()
+	assert.Equal(t, "other.yaml", applyFile, "コマンドの後の--configも使われるべき")
+}
+
+// TestMain_Help ヘルプとバージョン表示のテスト
+func TestMain_Help(t *testing.T) {
+	defer setupTest()()
+	mocks := &testMocks{}
+	mocks.setup()
+	defer mocks.restore()
+	osExit = func(code int) { t.Fatalf("os.Exit(%d) called", code) }
+
+	for _, args := range [][]string{{"--help"}, {"-h"}, {"help"}} {
+		os.Args = append([]string{"mcpyammy"}, args...)
+		out := captureStdout(t, main)
+		assert.Contains(t, out, "Commands:")
+		assert.Contains(t, out, "  diff ")
+		assert.Contains(t, out, "  validate ")
+		assert.Contains(t, out, "--version")
+	}
+
+	for _, args := range [][]string{{"help", "apply"}, {"apply", "--help"}, {"apply", "-h"}} {
+		os.Args = append([]string{"mcpyammy"}, args...)
+		out := captureStdout(t, main)
+		assert.Contains(t, out, "Usage: mcpyammy apply [flags] [yaml-file]")
+		assert.Contains(t, out, "--force-reset")
+		assert.Contains(t, out, "--config file")
+	}
+
+	os.Args = []string{"mcpyammy", "help", "config", "render"}
+	assert.Contains(t, captureStdout(t, main), "Usage: mcpyammy config render [flags] [yaml-file]")
+
+	os.Args = []string{"mcpyammy", "--version"}
+	assert.Equal(t, "mcpyammy "+Version+"\n", captureStdout(t, main))
+}
+
+// TestMain_Completion completionコマンドと補完候補の出力テスト
+func TestMain_Completion(t *testing.T) {
+	defer setupTest()()
+	mocks := &testMocks{}
+	mocks.setup()
+	defer mocks.restore()
+	osExit = func(code int) { t.Fatalf("os.Exit(%d) called", code) }
+
+	os.Args = []string{"mcpyammy", "completion", "bash"}
+	assert.Contains(t, captureStdout(t, main), "complete -F _mcpyammy mcpyammy")
+
+	os.Args = []string{"mcpyammy", CommandComplete, "--config", "x.yaml", "vali"}
+	assert.Equal(t, "validate\n", captureStdout(t, main))
+
+	var exitCode int
+	osExit = func(code int) { exitCode = code }
+	os.Args = []string{"mcpyammy", "completion", "tcsh"}
+	captureStdout(t, main)
+	assert.Equal(t, 1, exitCode)
+}
+
+// TestMain_DiffAndValidateCommands diffとvalidateコマンドのテスト
+func TestMain_DiffAndValidateCommands(t *testing.T) {
+	defer setupTest()()
+	mocks := &testMocks{}
+	mocks.setup()
+	defer mocks.restore()
+
+	var diffFile string
+	var diffOptions DiffOptions
+	diffFunc = func(file string, opts DiffOptions) error { diffFile, diffOptions = file, opts; return nil }
+	os.Args = []string{"mcpyammy", "diff", "--client", "claude", "test.yaml"}
+	main()
+	assert.Equal(t, "test.yaml", diffFile)
+	assert.Equal(t, DiffOptions{Client: "claude", Output: OutputText}, diffOptions)
+
+	var validateFile string
+	validateFunc = func(file string, _ ValidateOptions) error { validateFile = file; return nil }
+	os.Args = []string{"mcpyammy", "validate", "test.yaml"}
+	main()
+	assert.Equal(t, "test.yaml", validateFile)
+}
+
+// TestMain_Quiet --quietで標準出力が抑制されるテスト
+func TestMain_Quiet(t *testing.T) {
+	defer setupTest()()
+	mocks := &testMocks{}
+	mocks.setup()
+	defer mocks.restore()
+
+	statusFunc = func(string, StatusOptions) error { fmt.Println("claude is in sync"); return nil }
+	os.Args = []string{"mcpyammy", "status", "test.yaml"}
+	assert.Equal(t, "claude is in sync\n", captureStdout(t, main))
+
+	os.Args = []string{"mcpyammy", "--quiet", "status", "test.yaml"}
+	assert.Empty(t, captureStdout(t, main))
+}
+
+// TestMain_CommandErrors コマンドが返したエラーが終了コードになるテスト
+func TestMain_CommandErrors(t *testing.T) {
+	defer setupTest()()
+	mocks := &testMocks{}
+	mocks.setup()
+	defer mocks.restore()
+
+	exitCode := -1
+	osExit = func(code int) { exitCode = code }
+	os.Args = []string{"mcpyammy", "apply", "--dry-run", "--yes", "test.yaml"}
+
+	var applyOptions ApplyOptions
+	applyConfigFunc = func(_ string, opts ApplyOptions) error { applyOptions = opts; return nil }
+	main()
+	assert.Equal(t, -1, exitCode, "成功時は終了しない")
+	assert.True(t, applyOptions.DryRun)
+	assert.True(t, applyOptions.Yes)
+
+	applyConfigFunc = func(string, ApplyOptions) error {
+		return reportedExit(ExitPartialFailure, "1 client(s) could not be applied")
+	}
+	main()
+	assert.Equal(t, ExitPartialFailure, exitCode)
+
+	applyConfigFunc = func(string, ApplyOptions) error { return errors.New("broken yaml") }
+	main()
+	assert.Equal(t, ExitError, exitCode)
+}
+
+// TestMain_Lang --langでメッセージの言語を切り替えるテスト
+func TestMain_Lang(t *testing.T) {
+	defer setupTest()()
+	mocks := &testMocks{}
+	mocks.setup()
+	defer mocks.restore()
+	withLang(t, LangEnglish)
+
+	var statusLang Lang
+	statusFunc = func(string, StatusOptions) error { statusLang = lang; return nil }
+	os.Args = []string{"mcpyammy", "status", "--lang", "ja", "test.yaml"}
+	main()
+	assert.Equal(t, LangJapanese, statusLang)
+
+	os.Args = []string{"mcpyammy", "--lang=en", "status", "test.yaml"}
+	main()
+	assert.Equal(t, LangEnglish, statusLang)
+
+	exitCode := 0
+	osExit = func(code int) { exitCode = code }
+	os.Args = []string{"mcpyammy", "--lang", "fr", "status", "test.yaml"}
+	main()
+	assert.Equal(t, 1, exitCode)
+}
+
+// TestMain_TooManyArguments 余分な引数のエラーテスト
+func TestMain_TooManyArguments(t *testing.T) {
+	defer setupTest()()
+	mocks := &testMocks{}
+	mocks.setup()
+	defer mocks.restore()
+
+	exitCode := 0
+	osExit = func(code int) {
+		exitCode = code
+		panic("os.Exit called")
+	}
+	os.Args = []string{"mcpyammy", "status", "a.yaml", "b.yaml"}
+	assert.Panics(t, func() { main() })
+	assert.Equal(t, 1, exitCode)
+}
+
 // TestCommandRunner_Interface CommandRunnerインターフェースのテスト
 func TestCommandRunner_Interface(t *testing.T) {
 	defer setupTest()()
@@ -192,13 +566,15 @@ func TestCLICommandRunner_RunCommand(t *testing.T) {
 	originalApplyConfig := applyConfigFunc
 	originalImportConfig := importConfigFunc
 
-	applyConfigFunc = func(arg string) {
+	applyConfigFunc = func(arg string, _ ApplyOptions) error {
 		capturedCommand = "apply"
 		capturedArg = arg
+		return nil
 	}
-	importConfigFunc = func(arg string) {
+	importConfigFunc = func(arg string, _ ImportOptions) error {
 		capturedCommand = "import"
 		capturedArg = arg
+		return nil
 	}
 
 	defer func() {
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+const (
+	// MCPProtocolVersion is the protocol version mcpyammy asks for in
+	// initialize. Servers answer with the version they actually speak.
+	MCPProtocolVersion = "2025-06-18"
+
+	// maxToolPages bounds tools/list pagination against servers that keep
+	// returning a cursor.
+	maxToolPages = 100
+)
+
+// mcpTransport carries JSON-RPC messages to one MCP server.
+type mcpTransport interface {
+	// call sends a request and decodes the result of its response into
+	// result.
+	call(ctx context.Context, method string, params, result interface{}) error
+	// notify sends a notification, which has no response.
+	notify(ctx context.Context, method string, params interface{}) error
+	close() error
+}
+
+// jsonRPCMessage is any JSON-RPC 2.0 message: a request, a notification or
+// a response.
+type jsonRPCMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  interface{}     `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *jsonRPCError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// decodeResult unpacks the result or error of a response.
+func (m *jsonRPCMessage) decodeResult(result interface{}) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(m.Result, result); err != nil {
+		return fmt.Errorf("invalid result: %v", err)
+	}
+	return nil
+}
+
+// pendingCalls matches responses to the requests waiting for them.
+type pendingCalls struct {
+	mu     sync.Mutex
+	nextID int
+	calls  map[string]chan *jsonRPCMessage
+}
+
+// add allocates an id and the channel its response will arrive on.
+func (p *pendingCalls) add() (int, chan *jsonRPCMessage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.calls == nil {
+		p.calls = make(map[string]chan *jsonRPCMessage)
+	}
+	p.nextID++
+	ch := make(chan *jsonRPCMessage, 1)
+	p.calls[fmt.Sprint(p.nextID)] = ch
+	return p.nextID, ch
+}
+
+// deliver hands a response to its caller. Responses nobody waits for are
+// dropped.
+func (p *pendingCalls) deliver(msg *jsonRPCMessage) {
+	p.mu.Lock()
+	ch, ok := p.calls[string(msg.ID)]
+	delete(p.calls, string(msg.ID))
+	p.mu.Unlock()
+	if ok {
+		ch <- msg
+	}
+}
+
+// newRequest builds a request with the given id.
+func newRequest(id int, method string, params interface{}) jsonRPCMessage {
+	return jsonRPCMessage{JSONRPC: "2.0", ID: json.RawMessage(fmt.Sprint(id)), Method: method, Params: params}
+}
+
+// serverRequestReply answers a request the server sent: ping gets an empty
+// result and everything else is rejected, since mcpyammy offers no client
+// capabilities.
+func serverRequestReply(msg *jsonRPCMessage) map[string]interface{} {
+	reply := map[string]interface{}{"jsonrpc": "2.0", "id": msg.ID}
+	if msg.Method == "ping" {
+		reply["result"] = map[string]interface{}{}
+	} else {
+		reply["error"] = jsonRPCError{Code: -32601, Message: "method not found"}
+	}
+	return reply
+}
+
+type mcpImplementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type mcpInitializeResult struct {
+	ProtocolVersion string            `json:"protocolVersion"`
+	ServerInfo      mcpImplementation `json:"serverInfo"`
+}
+
+// mcpTool is one entry of tools/list.
+type mcpTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+}
+
+type mcpToolsListResult struct {
+	Tools      []mcpTool `json:"tools"`
+	NextCursor string    `json:"nextCursor,omitempty"`
+}
+
+// serverProbe is what a handshake learned about a server.
+type serverProbe struct {
+	transport       string
+	protocolVersion string
+	serverInfo      mcpImplementation
+	tools           []mcpTool
+	stderr          string
+	err             error
+}
+
+// mcpHandshake runs initialize, notifications/initialized and tools/list,
+// following tools/list pagination.
+func mcpHandshake(ctx context.Context, t mcpTransport, probe *serverProbe) error {
+	var init mcpInitializeResult
+	err := t.call(ctx, "initialize", map[string]interface{}{
+		"protocolVersion": MCPProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      mcpImplementation{Name: "mcpyammy", Version: Version},
+	}, &init)
+	if err != nil {
+		return fmt.Errorf("initialize: %w", err)
+	}
+	probe.protocolVersion = init.ProtocolVersion
+	probe.serverInfo = init.ServerInfo
+
+	if err := t.notify(ctx, "notifications/initialized", nil); err != nil {
+		return fmt.Errorf("notifications/initialized: %w", err)
+	}
+
+	cursor := ""
+	for page := 0; page < maxToolPages; page++ {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var list mcpToolsListResult
+		if err := t.call(ctx, "tools/list", params, &list); err != nil {
+			return fmt.Errorf("tools/list: %w", err)
+		}
+		probe.tools = append(probe.tools, list.Tools...)
+		if list.NextCursor == "" {
+			return nil
+		}
+		cursor = list.NextCursor
+	}
+	return fmt.Errorf("tools/list: more than %d pages", maxToolPages)
+}
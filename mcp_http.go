@@ -0,0 +1,434 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	TransportStdio          = "stdio"
+	TransportStreamableHTTP = "streamable HTTP"
+	TransportSSE            = "SSE"
+
+	headerSessionID       = "Mcp-Session-Id"
+	headerProtocolVersion = "MCP-Protocol-Version"
+
+	// maxErrorBody is how much of an error response is shown.
+	maxErrorBody = 200
+)
+
+// remoteHTTPClient sends the requests of remote server tests. The deadline
+// comes from the context of each request.
+var remoteHTTPClient = &http.Client{}
+
+// httpStatusError is a response with an unexpected status.
+type httpStatusError struct {
+	status          string
+	code            int
+	wwwAuthenticate string
+	body            string
+}
+
+func (e *httpStatusError) Error() string {
+	if e.code == http.StatusUnauthorized {
+		if e.wwwAuthenticate != "" {
+			return fmt.Sprintf("authentication failed: %s (WWW-Authenticate: %s)", e.status, e.wwwAuthenticate)
+		}
+		return "authentication failed: " + e.status
+	}
+	if e.body != "" {
+		return fmt.Sprintf("HTTP %s: %s", e.status, e.body)
+	}
+	return "HTTP " + e.status
+}
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody+1))
+	return &httpStatusError{
+		status:          resp.Status,
+		code:            resp.StatusCode,
+		wwwAuthenticate: resp.Header.Get("WWW-Authenticate"),
+		body:            truncate(strings.TrimSpace(string(body)), maxErrorBody),
+	}
+}
+
+// remoteError labels certificate and handshake failures as TLS errors, which
+// otherwise read like generic connection errors.
+func remoteError(err error) error {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		invalid          x509.CertificateInvalidError
+		verification     *tls.CertificateVerificationError
+		recordHeader     tls.RecordHeaderError
+		alert            tls.AlertError
+	)
+	switch {
+	case errors.As(err, &unknownAuthority), errors.As(err, &hostname), errors.As(err, &invalid),
+		errors.As(err, &verification), errors.As(err, &recordHeader), errors.As(err, &alert):
+		return fmt.Errorf("TLS error: %w", err)
+	}
+	return err
+}
+
+// remoteHeaders returns the configured headers of a remote server.
+func remoteHeaders(server map[string]interface{}) map[string]string {
+	headers := make(map[string]string)
+	if h, ok := server["headers"].(map[string]interface{}); ok {
+		for k, v := range h {
+			headers[k] = fmt.Sprint(v)
+		}
+	}
+	return headers
+}
+
+// httpTransport is the Streamable HTTP transport: every message is POSTed to
+// the server URL, and responses come back as JSON or as an event stream.
+type httpTransport struct {
+	url             string
+	headers         map[string]string
+	pending         pendingCalls
+	sessionID       string
+	protocolVersion string
+}
+
+func newHTTPTransport(serverURL string, headers map[string]string) *httpTransport {
+	return &httpTransport{url: serverURL, headers: headers}
+}
+
+func (t *httpTransport) newRequest(ctx context.Context, method string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if t.sessionID != "" {
+		req.Header.Set(headerSessionID, t.sessionID)
+	}
+	if t.protocolVersion != "" {
+		req.Header.Set(headerProtocolVersion, t.protocolVersion)
+	}
+	return req, nil
+}
+
+func (t *httpTransport) post(ctx context.Context, msg interface{}) (*http.Response, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := t.newRequest(ctx, http.MethodPost, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := remoteHTTPClient.Do(req)
+	if err != nil {
+		return nil, remoteError(err)
+	}
+	if err := checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) call(ctx context.Context, method string, params, result interface{}) error {
+	id, ch := t.pending.add()
+	resp, err := t.post(ctx, newRequest(id, method, params))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if sessionID := resp.Header.Get(headerSessionID); sessionID != "" {
+		t.sessionID = sessionID
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		var msg jsonRPCMessage
+		if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+			return fmt.Errorf("invalid JSON-RPC response: %v", err)
+		}
+		t.pending.deliver(&msg)
+	case "text/event-stream":
+		err := readSSE(resp.Body, func(event, data string) bool {
+			msg, ok := t.handleMessage(ctx, data)
+			if ok {
+				t.pending.deliver(msg)
+			}
+			return len(ch) == 0
+		})
+		if err != nil && len(ch) == 0 {
+			return fmt.Errorf("event stream: %v", remoteError(err))
+		}
+	default:
+		return fmt.Errorf("unexpected response Content-Type %q", resp.Header.Get("Content-Type"))
+	}
+
+	select {
+	case msg := <-ch:
+		if err := msg.decodeResult(result); err != nil {
+			return err
+		}
+		if method == "initialize" {
+			// later requests carry the negotiated version
+			var init mcpInitializeResult
+			json.Unmarshal(msg.Result, &init)
+			t.protocolVersion = init.ProtocolVersion
+		}
+		return nil
+	default:
+		return errors.New("response did not answer the request")
+	}
+}
+
+// handleMessage decodes one streamed message and answers server requests.
+// It returns responses.
+func (t *httpTransport) handleMessage(ctx context.Context, data string) (*jsonRPCMessage, bool) {
+	var msg jsonRPCMessage
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		return nil, false
+	}
+	if msg.Method != "" {
+		if len(msg.ID) > 0 {
+			if resp, err := t.post(ctx, serverRequestReply(&msg)); err == nil {
+				resp.Body.Close()
+			}
+		}
+		return nil, false
+	}
+	return &msg, true
+}
+
+func (t *httpTransport) notify(ctx context.Context, method string, params interface{}) error {
+	resp, err := t.post(ctx, jsonRPCMessage{JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// close ends the session when the server opened one.
+func (t *httpTransport) close() error {
+	if t.sessionID == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := t.newRequest(ctx, http.MethodDelete, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := remoteHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// sseTransport is the legacy HTTP+SSE transport of protocol 2024-11-05:
+// responses arrive on a long-lived event stream whose first event names the
+// URL to POST requests to.
+type sseTransport struct {
+	headers   map[string]string
+	pending   pendingCalls
+	postURL   string
+	cancel    context.CancelFunc
+	done      chan struct{} // closed when the stream ends
+	streamErr error         // why the stream ended, set before done is closed
+}
+
+// startSSETransport opens the event stream and waits for the endpoint event.
+func startSSETransport(ctx context.Context, serverURL string, headers map[string]string) (*sseTransport, error) {
+	streamCtx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, serverURL, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	type connectResult struct {
+		resp *http.Response
+		err  error
+	}
+	connected := make(chan connectResult, 1)
+	go func() {
+		resp, err := remoteHTTPClient.Do(req)
+		connected <- connectResult{resp, err}
+	}()
+	var resp *http.Response
+	select {
+	case r := <-connected:
+		if r.err != nil {
+			cancel()
+			return nil, remoteError(r.err)
+		}
+		resp = r.resp
+	case <-ctx.Done():
+		cancel()
+		return nil, fmt.Errorf("no response: %w", ctx.Err())
+	}
+	if err := checkResponse(resp); err != nil {
+		resp.Body.Close()
+		cancel()
+		return nil, err
+	}
+
+	t := &sseTransport{headers: headers, cancel: cancel, done: make(chan struct{})}
+	endpoint := make(chan string, 1)
+	go func() {
+		defer resp.Body.Close()
+		err := readSSE(resp.Body, func(event, data string) bool {
+			if event == "endpoint" {
+				select {
+				case endpoint <- data:
+				default:
+				}
+				return true
+			}
+			t.handleMessage(data)
+			return true
+		})
+		if err == nil {
+			err = errors.New("server closed the event stream")
+		}
+		t.streamErr = err
+		close(t.done)
+	}()
+
+	select {
+	case data := <-endpoint:
+		base, _ := url.Parse(serverURL)
+		ref, err := url.Parse(strings.TrimSpace(data))
+		if err != nil {
+			t.close()
+			return nil, fmt.Errorf("invalid endpoint event %q", data)
+		}
+		t.postURL = base.ResolveReference(ref).String()
+		return t, nil
+	case <-t.done:
+		cancel()
+		return nil, fmt.Errorf("no endpoint event: %v", t.streamErr)
+	case <-ctx.Done():
+		t.close()
+		return nil, fmt.Errorf("no endpoint event: %w", ctx.Err())
+	}
+}
+
+func (t *sseTransport) handleMessage(data string) {
+	var msg jsonRPCMessage
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		return
+	}
+	switch {
+	case msg.Method != "" && len(msg.ID) > 0:
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		t.post(ctx, serverRequestReply(&msg))
+	case msg.Method != "":
+	default:
+		t.pending.deliver(&msg)
+	}
+}
+
+func (t *sseTransport) post(ctx context.Context, msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.postURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := remoteHTTPClient.Do(req)
+	if err != nil {
+		return remoteError(err)
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
+
+func (t *sseTransport) call(ctx context.Context, method string, params, result interface{}) error {
+	id, ch := t.pending.add()
+	if err := t.post(ctx, newRequest(id, method, params)); err != nil {
+		return err
+	}
+	select {
+	case msg := <-ch:
+		return msg.decodeResult(result)
+	case <-t.done:
+		return fmt.Errorf("event stream: %v", t.streamErr)
+	case <-ctx.Done():
+		return fmt.Errorf("no response: %w", ctx.Err())
+	}
+}
+
+func (t *sseTransport) notify(ctx context.Context, method string, params interface{}) error {
+	return t.post(ctx, jsonRPCMessage{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (t *sseTransport) close() error {
+	t.cancel()
+	<-t.done
+	return nil
+}
+
+// readSSE calls fn for every event of a text/event-stream until fn returns
+// false or the stream ends.
+func readSSE(r io.Reader, fn func(event, data string) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), MaxYAMLSize*4)
+	event := ""
+	var data []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			if len(data) > 0 {
+				if event == "" {
+					event = "message"
+				}
+				if !fn(event, strings.Join(data, "\n")) {
+					return nil
+				}
+			}
+			event, data = "", nil
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRemoteMCP answers the handshake requests the way an MCP server would.
+func fakeRemoteMCP(t *testing.T, msg *jsonRPCMessage, protocolVersion string) interface{} {
+	t.Helper()
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"protocolVersion": protocolVersion,
+			"capabilities":    map[string]interface{}{},
+			"serverInfo":      map[string]interface{}{"name": "remote", "version": "0.9"},
+		}
+	case "tools/list":
+		return map[string]interface{}{"tools": []interface{}{
+			map[string]interface{}{"name": "search"},
+			map[string]interface{}{"name": "fetch"},
+		}}
+	}
+	t.Errorf("unexpected method %s", msg.Method)
+	return nil
+}
+
+func decodeRequest(t *testing.T, r *http.Request) *jsonRPCMessage {
+	t.Helper()
+	var msg jsonRPCMessage
+	require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+	return &msg
+}
+
+func writeResponse(w io.Writer, msg *jsonRPCMessage, result interface{}) {
+	json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": msg.ID, "result": result})
+}
+
+// newStreamableServer serves Streamable HTTP, answering in JSON or as an
+// event stream.
+func newStreamableServer(t *testing.T, stream bool) (*httptest.Server, *[]http.Header) {
+	var mu sync.Mutex
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header.Clone())
+		mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", `Bearer resource_metadata="https://auth.example.com"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			return
+		}
+		msg := decodeRequest(t, r)
+		if len(msg.ID) == 0 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set(headerSessionID, "session-1")
+		result := fakeRemoteMCP(t, msg, "2025-06-18")
+		if !stream {
+			w.Header().Set("Content-Type", "application/json")
+			writeResponse(w, msg, result)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/message\",\"params\":{}}\n\n")
+		fmt.Fprint(w, "data: ")
+		writeResponse(w, msg, result)
+		fmt.Fprint(w, "\n")
+	}))
+	t.Cleanup(server.Close)
+	return server, &headers
+}
+
+// newLegacySSEServer serves the 2024-11-05 HTTP+SSE transport.
+func newLegacySSEServer(t *testing.T) *httptest.Server {
+	events := make(chan string, 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sse", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "k" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: endpoint\ndata: /messages?session=1\n\n")
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case event := <-events:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", event)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("POST /messages", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "1", r.URL.Query().Get("session"))
+		msg := decodeRequest(t, r)
+		w.WriteHeader(http.StatusAccepted)
+		if len(msg.ID) == 0 {
+			return
+		}
+		data, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": msg.ID, "result": fakeRemoteMCP(t, msg, "2024-11-05")})
+		events <- string(data)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestProbeRemoteServer_StreamableHTTP JSONとイベントストリームの両方の応答形式のテスト
+func TestProbeRemoteServer_StreamableHTTP(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stream=%v", stream), func(t *testing.T) {
+			server, headers := newStreamableServer(t, stream)
+			probe := probeServer(context.Background(), map[string]interface{}{
+				"url":     server.URL,
+				"headers": map[string]interface{}{"Authorization": "Bearer secret"},
+			}, 5*time.Second)
+			require.NoError(t, probe.err)
+			assert.Equal(t, TransportStreamableHTTP, probe.transport)
+			assert.Equal(t, "2025-06-18", probe.protocolVersion)
+			assert.Equal(t, "remote", probe.serverInfo.Name)
+			assert.Len(t, probe.tools, 2)
+
+			// initialize, notifications/initialized, tools/list and the closing DELETE
+			require.Len(t, *headers, 4)
+			assert.Empty(t, (*headers)[0].Get(headerSessionID))
+			assert.Equal(t, "session-1", (*headers)[2].Get(headerSessionID))
+			assert.Equal(t, "2025-06-18", (*headers)[2].Get(headerProtocolVersion))
+		})
+	}
+}
+
+// TestProbeRemoteServer_LegacySSE 旧SSEトランスポートと自動フォールバックのテスト
+func TestProbeRemoteServer_LegacySSE(t *testing.T) {
+	server := newLegacySSEServer(t)
+	for _, kind := range []string{"sse", ""} {
+		t.Run("type="+kind, func(t *testing.T) {
+			config := map[string]interface{}{
+				"url":     server.URL + "/sse",
+				"headers": map[string]interface{}{"X-Api-Key": "k"},
+			}
+			if kind != "" {
+				config["type"] = kind
+			}
+			probe := probeServer(context.Background(), config, 5*time.Second)
+			require.NoError(t, probe.err)
+			assert.Equal(t, TransportSSE, probe.transport)
+			assert.Equal(t, "2024-11-05", probe.protocolVersion)
+			assert.Len(t, probe.tools, 2)
+		})
+	}
+}
+
+// TestProbeRemoteServer_Errors 認証エラー・TLSエラー・タイムアウトのテスト
+func TestProbeRemoteServer_Errors(t *testing.T) {
+	t.Run("unauthorized", func(t *testing.T) {
+		server, _ := newStreamableServer(t, false)
+		probe := probeServer(context.Background(), map[string]interface{}{"url": server.URL}, 5*time.Second)
+		require.Error(t, probe.err)
+		assert.Equal(t, `initialize: authentication failed: 401 Unauthorized (WWW-Authenticate: Bearer resource_metadata="https://auth.example.com")`, probe.err.Error())
+	})
+	t.Run("unauthorized sse", func(t *testing.T) {
+		server := newLegacySSEServer(t)
+		probe := probeServer(context.Background(), map[string]interface{}{"url": server.URL + "/sse", "type": "sse"}, 5*time.Second)
+		assert.EqualError(t, probe.err, "authentication failed: 401 Unauthorized")
+	})
+	t.Run("untrusted certificate", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(http.NotFoundHandler())
+		server.Config.ErrorLog = log.New(io.Discard, "", 0)
+		server.StartTLS()
+		defer server.Close()
+		probe := probeServer(context.Background(), map[string]interface{}{"url": server.URL}, 5*time.Second)
+		require.Error(t, probe.err)
+		assert.Contains(t, probe.err.Error(), "TLS error:")
+		assert.Contains(t, probe.err.Error(), "certificate")
+	})
+	t.Run("no response", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+		probe := probeServer(context.Background(), map[string]interface{}{"url": server.URL}, 200*time.Millisecond)
+		assert.ErrorIs(t, probe.err, context.DeadlineExceeded)
+	})
+	t.Run("server error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "upstream unavailable", http.StatusBadGateway)
+		}))
+		defer server.Close()
+		probe := probeServer(context.Background(), map[string]interface{}{"url": server.URL}, 5*time.Second)
+		assert.EqualError(t, probe.err, "initialize: HTTP 502 Bad Gateway: upstream unavailable")
+	})
+}
+
+// TestReadSSE イベントストリームの解析テスト
+func TestReadSSE(t *testing.T) {
+	stream := ": comment\r\nevent: endpoint\r\ndata: /a\r\n\r\ndata: line1\ndata: line2\n\nid: 3\ndata: last\n\n"
+	var events []string
+	require.NoError(t, readSSE(strings.NewReader(stream), func(event, data string) bool {
+		events = append(events, event+"="+data)
+		return true
+	}))
+	assert.Equal(t, []string{"endpoint=/a", "message=line1\nline2", "message=last"}, events)
+}
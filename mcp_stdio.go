@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxStderrCapture is how much of a server's stderr is kept for the
+	// report.
+	maxStderrCapture = 16 * 1024
+
+	// stdioWaitDelay is how long a server gets to exit after its stdin is
+	// closed before it is killed.
+	stdioWaitDelay = 2 * time.Second
+)
+
+// stdioTransport talks newline-delimited JSON-RPC to a server process over
+// its stdin and stdout.
+type stdioTransport struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *limitedBuffer
+
+	mu      sync.Mutex
+	pending pendingCalls
+	done    chan struct{} // closed when stdout ends
+	readErr error
+
+	waitOnce sync.Once
+	waitErr  error
+}
+
+// startStdioServer launches the command of server with its args and env
+// added to the current environment.
+func startStdioServer(server map[string]interface{}) (*stdioTransport, error) {
+	command, _ := server["command"].(string)
+	if command == "" {
+		return nil, fmt.Errorf("no command specified")
+	}
+	cmd := exec.Command(command, stringList(server["args"])...)
+	cmd.Env = append(os.Environ(), envList(server["env"])...)
+	cmd.WaitDelay = stdioWaitDelay
+
+	t := &stdioTransport{
+		cmd:    cmd,
+		stderr: &limitedBuffer{limit: maxStderrCapture},
+		done:   make(chan struct{}),
+	}
+	cmd.Stderr = t.stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	t.stdin = stdin
+	go t.readLoop(stdout)
+	return t, nil
+}
+
+// readLoop hands responses to their callers and answers requests the server
+// sends while starting up.
+func (t *stdioTransport) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), MaxYAMLSize*4)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var msg jsonRPCMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			t.fail(fmt.Errorf("server wrote invalid JSON-RPC to stdout: %q", truncate(string(line), 80)))
+			return
+		}
+		switch {
+		case msg.Method != "" && len(msg.ID) > 0:
+			t.write(serverRequestReply(&msg))
+		case msg.Method != "":
+			// notifications such as logging are not needed for the test
+		default:
+			t.pending.deliver(&msg)
+		}
+	}
+	err := scanner.Err()
+	if err == nil {
+		err = errors.New("server closed stdout")
+	}
+	t.fail(err)
+}
+
+func (t *stdioTransport) fail(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.done:
+	default:
+		t.readErr = err
+		close(t.done)
+	}
+}
+
+func (t *stdioTransport) write(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err = t.stdin.Write(append(data, '\n'))
+	return err
+}
+
+func (t *stdioTransport) call(ctx context.Context, method string, params, result interface{}) error {
+	id, ch := t.pending.add()
+	if err := t.write(newRequest(id, method, params)); err != nil {
+		return t.exitError(err)
+	}
+	select {
+	case msg := <-ch:
+		return msg.decodeResult(result)
+	case <-t.done:
+		return t.exitError(t.readErr)
+	case <-ctx.Done():
+		return fmt.Errorf("no response: %w", ctx.Err())
+	}
+}
+
+func (t *stdioTransport) notify(ctx context.Context, method string, params interface{}) error {
+	if err := t.write(jsonRPCMessage{JSONRPC: "2.0", Method: method, Params: params}); err != nil {
+		return t.exitError(err)
+	}
+	return nil
+}
+
+// exitError prefers the exit status of the process over the pipe error it
+// caused.
+func (t *stdioTransport) exitError(err error) error {
+	select {
+	case <-t.done:
+	case <-time.After(stdioWaitDelay):
+		return err
+	}
+	if waitErr := t.wait(); waitErr != nil {
+		return fmt.Errorf("server exited: %v", waitErr)
+	}
+	return err
+}
+
+// wait reaps the process once, however often it is asked.
+func (t *stdioTransport) wait() error {
+	t.waitOnce.Do(func() { t.waitErr = t.cmd.Wait() })
+	return t.waitErr
+}
+
+// close ends stdin, which asks the server to exit, and kills it if it does
+// not.
+func (t *stdioTransport) close() error {
+	t.stdin.Close()
+	done := make(chan struct{})
+	go func() {
+		t.wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(stdioWaitDelay):
+		t.cmd.Process.Kill()
+		<-done
+	}
+	return nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it.
+type limitedBuffer struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.limit - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// stringList converts a YAML or JSON list to strings.
+func stringList(v interface{}) []string {
+	items, _ := v.([]interface{})
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		list = append(list, fmt.Sprint(item))
+	}
+	return list
+}
+
+// envList converts an env mapping to KEY=value pairs, sorted by key.
+func envList(v interface{}) []string {
+	env, _ := v.(map[string]interface{})
+	list := make([]string, 0, len(env))
+	for _, key := range sortedKeys(env) {
+		list = append(list, key+"="+fmt.Sprint(env[key]))
+	}
+	return list
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.TrimSpace(s[:n]) + "…"
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// ConflictChoice decides what happens when a server exists in both the YAML
+// and a client file with different settings.
+type ConflictChoice string
+
+const (
+	ConflictKeepYAML   ConflictChoice = "yaml"
+	ConflictTakeClient ConflictChoice = "client"
+	ConflictKeepBoth   ConflictChoice = "both"
+)
+
+var conflictChoices = []ConflictChoice{ConflictKeepYAML, ConflictTakeClient, ConflictKeepBoth}
+
+// ImportOptions controls a non-interactive import.
+type ImportOptions struct {
+	OnConflict ConflictChoice
+	Output     OutputFormat
+
+	// Selection limits import to some client files and the servers they
+	// define.
+	Selection
+}
+
+func parseConflictChoice(s string) (ConflictChoice, error) {
+	for _, choice := range conflictChoices {
+		if string(choice) == s {
+			return choice, nil
+		}
+	}
+	return "", errorf("invalid conflict choice %q (expected yaml, client or both)", s)
+}
+
+func (c ConflictChoice) label() string {
+	switch c {
+	case ConflictTakeClient:
+		return msgf("Take client")
+	case ConflictKeepBoth:
+		return msgf("Keep both")
+	default:
+		return msgf("Keep YAML")
+	}
+}
+
+// serverConflict is a server whose YAML and client definitions differ.
+type serverConflict struct {
+	client       string
+	name         string
+	yamlServer   interface{}
+	clientServer map[string]interface{}
+	choice       ConflictChoice
+}
+
+// clientImport is the import result for one client file.
+type clientImport struct {
+	name      string
+	path      string
+	status    string
+	err       error
+	added     []string
+	updated   []string // changed only in the client since the last sync
+	removed   []string // removed from the YAML since the last sync
+	unchanged []string
+	skipped   []string
+	conflicts []*serverConflict
+	servers   map[string]interface{}
+	declared  map[string]interface{}
+}
+
+// importPlan merges every client file into the YAML document without losing
+// what is already there. The servers recorded by the last sync are the base
+// of a three-way merge: a server changed on one side only takes that side,
+// and servers changed differently on both sides become conflicts that must be
+// resolved before the plan is rendered. Without a recorded base every
+// difference is a conflict.
+type importPlan struct {
+	yamlFile  string
+	snapshot  *fileSnapshot
+	clients   []*clientImport
+	conflicts []*serverConflict
+}
+
+func planImport(yamlFile string, selection Selection) (*importPlan, error) {
+	allContent, err := loadAndValidateYAML(yamlFile)
+	if err != nil {
+		return nil, err
+	}
+	effective, err := loadEffectiveYAML(yamlFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := selection.checkClients(effective, yamlFile); err != nil {
+		return nil, err
+	}
+	yamlSnapshot, err := readSnapshot(yamlFile)
+	if err != nil {
+		return nil, errorf("Error reading YAML file: %v", err)
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errorf("error getting home directory: %v", err)
+	}
+
+	state, err := loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &importPlan{yamlFile: yamlFile, snapshot: yamlSnapshot}
+	allClients := make(map[string]interface{})
+	for _, entry := range clientEntries(allContent) {
+		allClients[entry.name] = entry.config
+	}
+
+	for _, entry := range clientEntries(effective) {
+		clientName := entry.name
+		config, ok := entry.config.(map[string]interface{})
+		if !ok || !selection.client(clientName) {
+			continue
+		}
+		ci := &clientImport{name: clientName}
+		plan.clients = append(plan.clients, ci)
+
+		serverNames, clientServers, validatedPath, status, err := readClientServers(config, homeDir)
+		ci.path, ci.status, ci.err = validatedPath, status, err
+		if err != nil {
+			continue
+		}
+		ci.servers = clientServers
+		var baseline map[string]interface{}
+		if cs, ok := state.Clients[validatedPath]; ok {
+			baseline = cs.Servers
+		}
+
+		yamlServers := extractClientServers(config)
+		if allConfig, ok := allClients[clientName].(map[string]interface{}); ok {
+			ci.declared = extractClientServers(allConfig)
+		}
+
+		for _, name := range serverNames {
+			clientServer, ok := clientServers[name].(map[string]interface{})
+			if !ok || !selection.server(name) {
+				continue
+			}
+			yamlServer, inYAML := yamlServers[name]
+			if !inYAML {
+				if _, isDeclared := ci.declared[name]; isDeclared {
+					// defined in the YAML but disabled by `when` on this machine
+					ci.skipped = append(ci.skipped, name)
+				} else if decideSync(nil, clientServer, baseline[name]) == syncPush {
+					ci.removed = append(ci.removed, name)
+				} else {
+					ci.added = append(ci.added, name)
+				}
+				continue
+			}
+			switch decideSync(yamlServer, clientServer, baseline[name]) {
+			case syncInSync, syncPush:
+				// the YAML is already newer than the client
+				ci.unchanged = append(ci.unchanged, name)
+				continue
+			case syncPull:
+				ci.updated = append(ci.updated, name)
+				continue
+			}
+			conflict := &serverConflict{
+				client:       clientName,
+				name:         name,
+				yamlServer:   yamlServer,
+				clientServer: clientServer,
+				choice:       ConflictKeepYAML,
+			}
+			ci.conflicts = append(ci.conflicts, conflict)
+			plan.conflicts = append(plan.conflicts, conflict)
+		}
+	}
+	return plan, nil
+}
+
+// readClientServers reads the mcpServers section of a client file and returns
+// the server names in file order. status is a short description used in
+// import output.
+func readClientServers(config map[string]interface{}, homeDir string) ([]string, map[string]interface{}, string, string, error) {
+	pathStr, ok := config["path"].(string)
+	if !ok {
+		return nil, nil, "", "no path specified", withCode(CodeInvalidConfig, errorf("no path given"))
+	}
+	validatedPath, err := validateSafePath(pathStr, homeDir)
+	if err != nil {
+		return nil, nil, "", "security risk detected", withCode(CodeUnsafePath, err)
+	}
+	jsonData, err := os.ReadFile(validatedPath)
+	if err != nil {
+		return nil, nil, validatedPath, "file not found", withCode(CodeNotFound, err)
+	}
+	root, err := readClientJSONObject(jsonData)
+	if err != nil {
+		return nil, nil, validatedPath, "parse error", ofKind(ErrClientParse, err)
+	}
+	mcpServers := mcpServersObject(root)
+	if mcpServers == nil || len(mcpServers.keys) == 0 {
+		return nil, nil, validatedPath, "no mcpServers", newError(ErrNoServers, nil, "no mcpServers found")
+	}
+	servers := plainJSONValue(mcpServers).(map[string]interface{})
+	return mcpServers.keys, servers, validatedPath, "imported", nil
+}
+
+// summaryLines describes what the import does for this client.
+func (ci *clientImport) summaryLines() []string {
+	var lines []string
+	for _, name := range ci.added {
+		lines = append(lines, "  + "+name)
+	}
+	for _, name := range ci.updated {
+		lines = append(lines, msgf("  ~ %s (changed in the client since the last sync)", name))
+	}
+	for _, name := range ci.removed {
+		lines = append(lines, msgf("  - %s (removed from the YAML since the last sync; not added back)", name))
+	}
+	for _, conflict := range ci.conflicts {
+		lines = append(lines, msgf("  ! %s (conflict: %s)", conflict.name, conflict.choice.label()))
+	}
+	for _, name := range ci.skipped {
+		lines = append(lines, msgf("  = %s (disabled by when on this machine)", name))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, msgf("  (already in sync)"))
+	}
+	return lines
+}
+
+// failed reports whether the client file exists but could not be imported.
+// Clients that are not installed or have no servers are not failures.
+func (ci *clientImport) failed() bool {
+	if errorCode(ci.err) == CodeNotFound || errors.Is(ci.err, ErrNoServers) {
+		return false
+	}
+	return ci.err != nil
+}
+
+// jsonClient converts the import result for --output json.
+func (ci *clientImport) jsonClient() jsonClient {
+	client := jsonClient{Name: ci.name, Path: ci.path, Status: "imported"}
+	if ci.err != nil {
+		client.Status = "failed"
+		client.Error = newJSONError(ci.err)
+		client.Servers = newJSONServers(nil, nil, nil, nil)
+		return client
+	}
+	changed := slices.Clone(ci.updated)
+	for _, conflict := range ci.conflicts {
+		changed = append(changed, conflict.name)
+		client.Conflicts = append(client.Conflicts, jsonConflict{Server: conflict.name, Choice: conflict.choice})
+	}
+	client.Servers = newJSONServers(ci.added, changed, ci.unchanged, nil)
+	client.Servers.Skipped = ci.skipped
+	return client
+}
+
+// resolveAll applies the same choice to every conflict.
+func (p *importPlan) resolveAll(choice ConflictChoice) {
+	for _, conflict := range p.conflicts {
+		conflict.choice = choice
+	}
+}
+
+// render returns the YAML file with the import merged in. Entries are edited
+// in place, so everything the import does not touch is kept byte for byte.
+// New servers are appended in the order of the client file.
+func (p *importPlan) render() ([]byte, error) {
+	doc := newYAMLDocument(p.snapshot.data)
+	for _, ci := range p.clients {
+		var entries []interface{}
+		for _, name := range ci.added {
+			entries = append(entries, convertMcpServerToYaml(name, ci.servers[name].(map[string]interface{})))
+		}
+		for _, name := range ci.updated {
+			entry := convertMcpServerToYaml(name, ci.servers[name].(map[string]interface{}))
+			replaced, err := doc.replaceServer(ci.name, name, entry)
+			if err != nil {
+				return nil, err
+			}
+			if !replaced {
+				entries = append(entries, entry)
+			}
+		}
+		used := make(map[string]bool)
+		for _, conflict := range ci.conflicts {
+			switch conflict.choice {
+			case ConflictTakeClient:
+				entry := convertMcpServerToYaml(conflict.name, conflict.clientServer)
+				replaced, err := doc.replaceServer(ci.name, conflict.name, entry)
+				if err != nil {
+					return nil, err
+				}
+				if !replaced {
+					// the server comes from an included file; override it here
+					entries = append(entries, entry)
+				}
+			case ConflictKeepBoth:
+				newName := ci.uniqueServerName(conflict.name+"-"+ci.name, used)
+				used[newName] = true
+				entries = append(entries, convertMcpServerToYaml(newName, conflict.clientServer))
+			}
+		}
+		if err := doc.appendServers(ci.name, entries); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := doc.parse(); err != nil {
+		return nil, err
+	}
+	return doc.bytes(), nil
+}
+
+// write replaces the YAML file with content rendered from the plan. It fails
+// when the file changed after the plan was made, so edits made in the
+// meantime are not overwritten.
+func (p *importPlan) write(content []byte) error {
+	if err := replaceLockedFile(p.yamlFile, content, p.snapshot); err != nil {
+		if errors.Is(err, errFileChanged) {
+			return errorf("%s was modified while importing; run import again", p.yamlFile)
+		}
+		return err
+	}
+	return nil
+}
+
+func (ci *clientImport) uniqueServerName(base string, used map[string]bool) string {
+	taken := func(name string) bool {
+		_, inClient := ci.servers[name]
+		_, inYAML := ci.declared[name]
+		return inClient || inYAML || used[name]
+	}
+	name := base
+	for i := 2; taken(name); i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name
+}
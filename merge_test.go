@@ -0,0 +1,271 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupImportTest(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	clientJSON := `{"mcpServers": {
+  "fetch": {"command": "uvx", "args": ["mcp-server-fetch", "--verbose"]},
+  "github": {"command": "npx"},
+  "same": {"command": "x"}
+}}`
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".claude.json"), []byte(clientJSON), 0600))
+
+	yamlFile := filepath.Join(home, "servers.yaml")
+	yamlContent := `library:
+  note: kept
+clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: fetch
+      command: uvx
+      args: [mcp-server-fetch]
+    - name: same
+      command: x
+    - name: not-applied-yet
+      command: node
+`
+	require.NoError(t, os.WriteFile(yamlFile, []byte(yamlContent), 0600))
+	return yamlFile
+}
+
+// TestPlanImport_DetectsConflicts YAMLとクライアントの差分検出テスト
+func TestPlanImport_DetectsConflicts(t *testing.T) {
+	yamlFile := setupImportTest(t)
+
+	plan, err := planImport(yamlFile, Selection{})
+	require.NoError(t, err)
+	require.Len(t, plan.clients, 1)
+
+	ci := plan.clients[0]
+	assert.Equal(t, []string{"github"}, ci.added)
+	assert.Equal(t, []string{"same"}, ci.unchanged)
+	require.Len(t, plan.conflicts, 1)
+	assert.Equal(t, "fetch", plan.conflicts[0].name)
+}
+
+// TestPlanImport_ThreeWay 前回のsyncの内容を基準に片側だけの変更は競合にしないテスト
+func TestPlanImport_ThreeWay(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, ".state"))
+	clientFile := filepath.Join(home, ".claude.json")
+	require.NoError(t, os.WriteFile(clientFile, []byte(`{"mcpServers": {
+  "fetch": {"command": "uvx", "args": ["mcp-server-fetch", "--verbose"]},
+  "edited": {"command": "old"},
+  "both": {"command": "client"},
+  "removed": {"command": "r"},
+  "new": {"command": "npx"}
+}}`), 0600))
+	yamlFile := filepath.Join(home, "servers.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: fetch
+      command: uvx
+      args: [mcp-server-fetch]
+    - name: edited
+      command: new
+    - name: both
+      command: yaml
+`), 0600))
+
+	state, err := loadState()
+	require.NoError(t, err)
+	state.client(clientFile).Servers = map[string]interface{}{
+		"fetch":   map[string]interface{}{"command": "uvx", "args": []interface{}{"mcp-server-fetch"}},
+		"edited":  map[string]interface{}{"command": "old"},
+		"both":    map[string]interface{}{"command": "base"},
+		"removed": map[string]interface{}{"command": "r"},
+	}
+	require.NoError(t, state.save())
+
+	plan, err := planImport(yamlFile, Selection{})
+	require.NoError(t, err)
+	ci := plan.clients[0]
+	assert.Equal(t, []string{"fetch"}, ci.updated, "クライアントだけで変更されたものは取り込む")
+	assert.Equal(t, []string{"edited"}, ci.unchanged, "YAMLだけで変更されたものはYAMLのまま")
+	assert.Equal(t, []string{"removed"}, ci.removed, "YAMLから削除したものは戻さない")
+	assert.Equal(t, []string{"new"}, ci.added)
+	require.Len(t, plan.conflicts, 1, "両側で変更されたものだけが競合")
+	assert.Equal(t, "both", plan.conflicts[0].name)
+
+	output, err := plan.render()
+	require.NoError(t, err)
+	assert.Equal(t, `clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: fetch
+      command: uvx
+      args:
+      - mcp-server-fetch
+      - --verbose
+    - name: edited
+      command: new
+    - name: both
+      command: yaml
+    - name: new
+      command: npx
+`, string(output))
+}
+
+// TestPlanImport_Selection 指定したクライアントとサーバーだけを取り込むテスト
+func TestPlanImport_Selection(t *testing.T) {
+	yamlFile := setupImportTest(t)
+
+	plan, err := planImport(yamlFile, Selection{Servers: []string{"github"}})
+	require.NoError(t, err)
+	require.Len(t, plan.clients, 1)
+	assert.Equal(t, []string{"github"}, plan.clients[0].added)
+	assert.Empty(t, plan.clients[0].unchanged)
+	assert.Empty(t, plan.conflicts, "fetchは選択されていないので競合にならない")
+
+	_, err = planImport(yamlFile, Selection{Clients: []string{"gemini"}})
+	assert.EqualError(t, err, "client 'gemini' is not in "+yamlFile)
+}
+
+// TestImportPlan_Render 競合解決ごとのYAML出力テスト
+func TestImportPlan_Render(t *testing.T) {
+	yamlFile := setupImportTest(t)
+
+	tests := []struct {
+		choice   ConflictChoice
+		expected []string
+		fetchArg int
+	}{
+		{ConflictKeepYAML, []string{"fetch", "same", "not-applied-yet", "github"}, 1},
+		{ConflictTakeClient, []string{"fetch", "same", "not-applied-yet", "github"}, 2},
+		{ConflictKeepBoth, []string{"fetch", "same", "not-applied-yet", "github", "fetch-claude"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.choice), func(t *testing.T) {
+			plan, err := planImport(yamlFile, Selection{})
+			require.NoError(t, err)
+			plan.resolveAll(tt.choice)
+
+			output, err := plan.render()
+			require.NoError(t, err)
+			outputFile := filepath.Join(t.TempDir(), "out.yaml")
+			require.NoError(t, os.WriteFile(outputFile, output, 0600))
+
+			yamlContent, err := loadAndValidateYAML(outputFile)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]interface{}{"note": "kept"}, yamlContent["library"], "clients以外のキーは保持されるべき")
+
+			servers := clientConfigByName(yamlContent, "claude")["servers"].([]interface{})
+			var names []string
+			for _, server := range servers {
+				names = append(names, server.(map[string]interface{})["name"].(string))
+			}
+			assert.Equal(t, tt.expected, names, "未適用のサーバーも保持されるべき")
+			assert.Len(t, servers[0].(map[string]interface{})["args"], tt.fetchArg)
+		})
+	}
+}
+
+// TestImportPlan_Render_KeepsNonStringValues 文字列以外のargsとenvの値をそのまま取り込むテスト
+func TestImportPlan_Render_KeepsNonStringValues(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, ".state"))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".claude.json"), []byte(`{"mcpServers": {
+  "web": {"command": "npx", "args": ["--port", 8080], "env": {"DEBUG": true, "LIMIT": 5, "NAME": "x"}}
+}}`), 0600))
+	yamlFile := filepath.Join(home, "servers.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte("clients:\n  claude:\n    path: .claude.json\n"), 0600))
+
+	plan, err := planImport(yamlFile, Selection{})
+	require.NoError(t, err)
+	output, err := plan.render()
+	require.NoError(t, err)
+
+	assert.Equal(t, `clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: web
+      command: npx
+      args:
+      - --port
+      - 8080
+      env:
+        DEBUG: true
+        LIMIT: 5
+        NAME: x
+`, string(output), "数値や真偽値は空文字にしたり落としたりせずに取り込むべき")
+}
+
+// TestImportPlan_Write 取り込み結果の書き込みと、計画後にYAMLが変更された場合の中止テスト
+func TestImportPlan_Write(t *testing.T) {
+	yamlFile := setupImportTest(t)
+
+	plan, err := planImport(yamlFile, Selection{})
+	require.NoError(t, err)
+	output, err := plan.render()
+	require.NoError(t, err)
+	require.NoError(t, plan.write(output))
+	written, err := os.ReadFile(yamlFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(output), string(written))
+
+	plan, err = planImport(yamlFile, Selection{})
+	require.NoError(t, err)
+	edited := string(written) + "# edited meanwhile\n"
+	require.NoError(t, os.WriteFile(yamlFile, []byte(edited), 0600))
+	err = plan.write(output)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "was modified while importing")
+	written, err = os.ReadFile(yamlFile)
+	require.NoError(t, err)
+	assert.Equal(t, edited, string(written), "計画後の変更は上書きされないべき")
+}
+
+// TestImportPlan_Render_PreservesComments コメント・アンカー・キー順の保持テスト
+func TestImportPlan_Render_PreservesComments(t *testing.T) {
+	yamlFile := setupImportTest(t)
+	original := `# personal servers
+defaults: &defaults
+  command: uvx   # shared
+clients:
+  # main client
+  claude:
+    path: .claude.json
+    servers:
+    - name: fetch
+      <<: *defaults
+      args: [mcp-server-fetch]
+    # same as client
+    - name: same
+      command: x
+`
+	require.NoError(t, os.WriteFile(yamlFile, []byte(original), 0600))
+
+	var outputs []string
+	for i := 0; i < 3; i++ {
+		plan, err := planImport(yamlFile, Selection{})
+		require.NoError(t, err)
+		output, err := plan.render()
+		require.NoError(t, err)
+		outputs = append(outputs, string(output))
+	}
+	assert.Equal(t, outputs[0], outputs[1], "出力は実行ごとに同一であるべき")
+	assert.Equal(t, outputs[0], outputs[2], "出力は実行ごとに同一であるべき")
+
+	expected := original + `    - name: github
+      command: npx
+`
+	assert.Equal(t, expected, outputs[0], "既存の記述はそのまま残り、新しいサーバーが末尾に追加されるべき")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Lang is a language of the messages mcpyammy prints.
+type Lang string
+
+const (
+	LangEnglish  Lang = "en"
+	LangJapanese Lang = "ja"
+)
+
+var langs = []Lang{LangEnglish, LangJapanese}
+
+// catalogs translate the English messages, which are their own keys, into
+// the other languages. A message missing from a catalog is printed in
+// English.
+var catalogs = map[Lang]map[string]string{
+	LangJapanese: japaneseMessages,
+}
+
+// lang is the language messages are printed in. It comes from the locale and
+// can be overridden with --lang.
+var lang = localeLang(os.LookupEnv)
+
+func parseLang(s string) (Lang, error) {
+	for _, l := range langs {
+		if Lang(s) == l {
+			return l, nil
+		}
+	}
+	return "", errorf("invalid language %q (expected en or ja)", s)
+}
+
+// localeLang picks the language from LC_ALL, LC_MESSAGES and LANG, in the
+// order gettext reads them.
+func localeLang(lookup func(string) (string, bool)) Lang {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value, ok := lookup(name); ok && value != "" {
+			return langFromLocale(value)
+		}
+	}
+	return LangEnglish
+}
+
+// langFromLocale maps a locale such as ja_JP.UTF-8 to a language of the
+// catalog, falling back to English.
+func langFromLocale(locale string) Lang {
+	name, _, _ := strings.Cut(locale, ".")
+	name, _, _ = strings.Cut(name, "@")
+	name, _, _ = strings.Cut(name, "_")
+	for _, l := range langs {
+		if strings.EqualFold(name, string(l)) {
+			return l
+		}
+	}
+	return LangEnglish
+}
+
+// translate returns the format of message in the current language.
+func translate(message string) string {
+	if translated, ok := catalogs[lang][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// msgf formats a message of the catalog in the current language.
+func msgf(format string, args ...interface{}) string {
+	if len(args) == 0 {
+		return translate(format)
+	}
+	return fmt.Sprintf(translate(format), args...)
+}
+
+// errorf is fmt.Errorf with the format translated; %w still wraps.
+func errorf(format string, args ...interface{}) error {
+	return fmt.Errorf(translate(format), args...)
+}
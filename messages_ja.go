@@ -0,0 +1,317 @@
+package main
+
+// japaneseMessages is the Japanese catalog. Keys are the English messages as
+// written in the code; the translations keep their verbs in the same order.
+var japaneseMessages = map[string]string{
+	// command line
+	"Unknown command: %s":                              "不明なコマンドです: %s",
+	"unknown config subcommand; expected %q":           "不明なconfigサブコマンドです（%qを指定してください）",
+	"too many arguments: %s":                           "引数が多すぎます: %s",
+	"missing shell (expected %s)":                      "シェルが指定されていません（%sのいずれか）",
+	"invalid language %q (expected en or ja)":          "不明な言語です: %q（enまたはja）",
+	"No YAML file given and none found in: %s":         "YAMLファイルが指定されておらず、次の場所にも見つかりません: %s",
+	"Pass one with --config or run 'mcpyammy help %s'": "--configで指定するか、'mcpyammy help %s'を実行してください",
+	"Run 'mcpyammy help %s' for usage.":                "使い方は'mcpyammy help %s'で確認できます。",
+	"Run 'mcpyammy help' for usage.":                   "使い方は'mcpyammy help'で確認できます。",
+
+	"invalid output format %q (expected text or json)":           "不明な出力形式です: %q（textまたはjson）",
+	"invalid conflict choice %q (expected yaml, client or both)": "不明な競合の解決方法です: %q（yaml、clientまたはboth）",
+	"unsupported shell %q (expected %s)":                         "未対応のシェルです: %q（%sのいずれか）",
+	"client '%s' is not in %s":                                   "クライアント'%s'は%sにありません",
+	"server '%s' is not in %s":                                   "サーバー'%s'は%sにありません",
+	"server '%s' is not in the selected clients of %s":           "サーバー'%s'は%sの選択したクライアントにありません",
+
+	// help
+	"Usage:":                     "使い方:",
+	"Usage: %s":                  "使い方: %s",
+	"Launch the interactive TUI": "対話型のTUIを起動します",
+	"Commands:":                  "コマンド:",
+	"Show help for a command":    "コマンドのヘルプを表示します",
+	"Global flags:":              "グローバルフラグ:",
+	"Flags:":                     "フラグ:",
+	"(default %s)":               "（デフォルト: %s）",
+	"Without a yaml-file, --config, $%s, ./%s and the XDG config directory are tried in that order.": "yaml-fileを省略すると、--config、$%s、./%s、XDG設定ディレクトリの順に探します。",
+	"Run 'mcpyammy help <command>' for the flags of a command.":                                      "各コマンドのフラグは'mcpyammy help <command>'で確認できます。",
+	"Write the servers of the YAML to every client file":                                             "YAMLのサーバーを各クライアントの設定ファイルに書き込みます",
+	"Only mcpServers is changed; the rest of each client file keeps its formatting and comments.\nUse --client and --server to roll a change out to some clients or servers first.\nOn a terminal the changes are shown and confirmed first; --dry-run only shows them, --yes skips the question.": "変更するのはmcpServersだけで、クライアント設定ファイルのほかの部分は書式もコメントもそのまま残ります。\n--clientと--serverを使うと、変更を一部のクライアントやサーバーから試せます。\n端末では変更を表示して確認してから書き込みます。--dry-runは表示だけ行い、--yesは確認を省略します。",
+	"Show the changes apply would make to each client file":                   "applyで各クライアント設定ファイルがどう変わるかを表示します",
+	"Nothing is written. Use --client to show a single client.":               "何も書き込みません。--clientで1つのクライアントだけを表示します。",
+	"Check the YAML for mistakes without touching any client":                 "クライアントに触れずにYAMLの誤りを検査します",
+	"Exits with status 3 when the YAML has errors.":                           "YAMLに問題があれば終了コード3で終了します。",
+	"Merge existing client files into the YAML and print the result":          "既存のクライアント設定ファイルをYAMLにマージして結果を出力します",
+	"Pull client-side changes into the YAML and push YAML changes to clients": "クライアント側の変更をYAMLに取り込み、YAMLの変更をクライアントに反映します",
+	"Apply automatically whenever the YAML changes":                           "YAMLが変更されるたびに自動で適用します",
+	"Start each server and check that it answers the MCP handshake":           "各サーバーを起動してMCPのハンドシェイクに応答するか確認します",
+	"Give a client, or a client and a server, to test only those.":            "クライアント、またはクライアントとサーバーを指定すると、それだけをテストします。",
+	"List the tools of each server and estimate their token cost per client":  "各サーバーのツールを一覧にし、クライアントごとのトークン数を見積もります",
+	"Check commands, files, env variables and permissions the servers need":   "サーバーに必要なコマンド、ファイル、環境変数、権限を確認します",
+	"Print the merged configuration after include/extends":                    "include/extendsを反映したあとの設定を出力します",
+	"Print a shell completion script":                                         "シェルの補完スクリプトを出力します",
+	"Client and server names are read from the YAML while completing.\nbash: source <(mcpyammy completion bash)\nzsh:  source <(mcpyammy completion zsh)\nfish: mcpyammy completion fish | source": "クライアント名とサーバー名は補完のたびにYAMLから読み込みます。\nbash: source <(mcpyammy completion bash)\nzsh:  source <(mcpyammy completion zsh)\nfish: mcpyammy completion fish | source",
+	"path to the YAML configuration file":                                              "YAML設定ファイルのパス",
+	"only print errors":                                                                "エラーだけを表示します",
+	"language of messages: en or ja (default from $LANG)":                              "メッセージの言語: enまたはja（デフォルトは$LANGから）",
+	"log which clients are skipped and why":                                            "スキップしたクライアントとその理由をログに出します",
+	"also log resolved paths and how each server compares":                             "解決したパスとサーバーごとの比較結果もログに出します",
+	"append the log to file instead of stderr (the TUI logs only to a file)":           "ログを標準エラー出力ではなくfileに追記します（TUIはファイルにだけ記録します）",
+	"print the version and exit":                                                       "バージョンを表示して終了します",
+	"output format: text or json":                                                      "出力形式: textまたはjson",
+	"same as --output json":                                                            "--output jsonと同じです",
+	"replace client files that cannot be parsed instead of skipping them":              "解析できないクライアント設定ファイルをスキップせずに作り直します",
+	"only apply to these comma separated clients":                                      "カンマ区切りで指定したclientsにだけ適用します",
+	"only write these comma separated servers":                                         "カンマ区切りで指定したserversだけを書き込みます",
+	"print what would change without writing any file":                                 "ファイルを書き込まずに変更内容だけを表示します",
+	"apply without asking for confirmation":                                            "確認せずに適用します",
+	"only show this client":                                                            "このclientだけを表示します",
+	"also warn when a client file drifts away from the YAML":                           "クライアント設定ファイルがYAMLと食い違ったときも警告します",
+	"how often to check the files for changes":                                         "ファイルの変更を確認する間隔",
+	"how to resolve servers that differ between YAML and client: yaml, client or both": "YAMLとクライアントで異なるサーバーの解決方法: yaml、clientまたはboth",
+	"only read the files of these comma separated clients":                             "カンマ区切りで指定したclientsのファイルだけを読み込みます",
+	"only import these comma separated servers":                                        "カンマ区切りで指定したserversだけを取り込みます",
+	"how to resolve servers changed on both sides: ask, yaml, client or skip":          "両側で変更されたサーバーの解決方法: ask、yaml、clientまたはskip",
+	"how long each server gets to answer the handshake":                                "各サーバーがハンドシェイクに応答するまでの待ち時間",
+	"warn when the tools of a client exceed this many tokens (0 disables the check)":   "クライアントのツールがこのトークン数を超えたら警告します（0で無効）",
+
+	// loading the YAML
+	"YAML file is larger than the limit of %dKB: %dKB":               "YAMLファイルサイズが上限(%dKB)を超えています: %dKB",
+	"YAML is nested too deeply (at most %d levels): %d levels found": "YAML構造が深すぎます（最大%d階層）: %d階層検出",
+	"YAML parse error: %w":                                  "YAML解析エラー: %w",
+	"Error reading YAML file: %v":                           "YAMLファイル読み込みエラー: %v",
+	"Error generating YAML: %v":                             "YAML生成エラー: %v",
+	"Error converting to YAML: %v":                          "YAMLへの変換エラー: %v",
+	"YAML has no clients section":                           "YAMLにclientsセクションが見つかりません",
+	"Invalid YAML (%s): %w":                                 "YAML検証エラー（%s）: %w",
+	"%s (%s): %v":                                           "%s（%s）: %v",
+	"include cycle: %s":                                     "includeが循環しています: %s",
+	"file paths must be strings: %v":                        "ファイルパスは文字列で指定してください: %v",
+	"must be a string or a list":                            "文字列またはリストで指定してください",
+	"must be a string or a list: %v":                        "文字列またはリストで指定してください: %v",
+	"must be a string: %v":                                  "文字列で指定してください: %v",
+	"'%s' in flow style cannot be edited":                   "フロースタイルの'%s'は編集できません",
+	"could not parse the structure of 'servers' (line %d)":  "'servers'の構造を解析できませんでした（行%d）",
+	"'servers' must be a list (line %d)":                    "'servers'はリストで指定してください（行%d）",
+	"Invalid when condition (client '%s'): %v":              "when条件エラー（クライアント'%s'): %v",
+	"Invalid when condition (client '%s', server '%v'): %v": "when条件エラー（クライアント'%s'・サーバー'%v'): %v",
+	"when must be a map":                                    "whenはマップで指定してください",
+	"unsupported condition: %s":                             "未対応の条件です: %s",
+	"could not get the host name: %v":                       "ホスト名の取得に失敗しました: %v",
+	"Error parsing state file (%s): %v":                     "状態ファイルの解析エラー（%s): %v",
+
+	// client paths and files
+	"no path given":                                         "パスが指定されていません",
+	"could not check the path: %v":                          "パスの検証に失敗しました: %v",
+	"access outside the home directory is not allowed: %s":  "ホームディレクトリ外へのアクセスは許可されていません: %s",
+	"could not get the absolute path: %v":                   "絶対パスの取得に失敗しました: %v",
+	"error getting home directory: %v":                      "ホームディレクトリの取得に失敗しました: %v",
+	"invalid configuration":                                 "設定が正しくありません",
+	"client '%s' has no path":                               "クライアント'%s'にパスが指定されていません",
+	"client '%s' has no servers":                            "クライアント'%s'にサーバー設定が見つかりません",
+	"no servers":                                            "サーバー設定がありません",
+	"no mcpServers found":                                   "mcpServersが見つかりません",
+	"Unsafe path (client '%s'): %w":                         "セキュリティリスク検出（クライアント'%s'): %w",
+	"Error updating file (client '%s'): %w":                 "ファイル更新エラー（クライアント'%s'): %w",
+	"%s: %v (not written; use --force-reset to replace it)": "%s: %v（書き込みませんでした。作り直すには--force-resetを指定してください）",
+	"%s was modified by another program %d times while applying; close the client and try again": "適用中に%sがほかのプログラムによって%d回変更されました。クライアントを終了してからもう一度実行してください",
+	"Warning: could not record apply state: %v":                                                  "警告: 適用履歴を記録できませんでした: %v",
+	"could not open the log file: %v":                                                            "ログファイルを開けませんでした: %v",
+	"Could not record apply state: %v":                                                           "適用履歴を記録できませんでした: %v",
+
+	// apply
+	"Processing failed for client '%s': %v":                       "クライアント'%s'の処理に失敗しました: %v",
+	"- %s has no servers; skipped":                                "- %sにはサーバーがないためスキップしました",
+	"%d client(s) would change; run apply to write them":          "%d個のクライアントが変更されます。applyで書き込んでください",
+	"= %s is up to date: %s":                                      "= %sは最新です: %s",
+	"~ Would update %s: %s":                                       "~ %sを更新します: %s",
+	"✓ Updated %s: %s":                                            "✓ %sを更新しました: %s",
+	"Apply these changes? [y/N] ":                                 "この変更を適用しますか? [y/N] ",
+	"Nothing was written":                                         "何も書き込みませんでした",
+	"All clients are up to date":                                  "すべてのクライアントは最新です",
+	"Dry run: %d client(s) would be updated; nothing was written": "ドライラン: %d個のクライアントが更新されます（何も書き込んでいません）",
+	"Successfully processed %d client(s)":                         "%d個のクライアントを処理しました",
+	"No clients were processed":                                   "処理したクライアントはありません",
+	"%d client(s) could not be applied":                           "%d個のクライアントに適用できませんでした",
+
+	// import
+	"- %s: %s (path preserved)":                                          "- %s: %s（パスは保持）",
+	"✓ Imported %s from %s":                                              "✓ %sを%sから取り込みました",
+	"  ! %s (conflict: %s)":                                              "  ! %s（競合: %s）",
+	"  = %s (disabled by when on this machine)":                          "  = %s（このマシンではwhenで無効）",
+	"  ~ %s (changed in the client since the last sync)":                 "  ~ %s（前回のsync以降クライアントで変更）",
+	"  - %s (removed from the YAML since the last sync; not added back)": "  - %s（前回のsync以降YAMLから削除されたため取り込みません）",
+	"  (already in sync)":                                                "  （同期済み）",
+	"No configurations were imported":                                    "取り込んだ設定はありません",
+	"no configurations were imported":                                    "取り込んだ設定はありません",
+	"import failed for some clients":                                     "一部のクライアントの取り込みに失敗しました",
+	"%d conflict(s) resolved with --on-conflict=%s":                      "%d件の競合を--on-conflict=%sで解決しました",
+	"--- Imported YAML configuration ---":                                "--- 取り込んだYAML設定 ---",
+	"Take client":                                                        "クライアントを採用",
+	"Keep both":                                                          "両方残す",
+	"Keep YAML":                                                          "YAMLを維持",
+
+	// sync
+	"invalid conflict choice %q (expected ask, yaml, client or skip)":     "不明な競合の解決方法です: %q（ask、yaml、clientまたはskip）",
+	"%s / %s changed in both the YAML and the client since the last sync": "%s / %sは前回の同期以降にYAMLとクライアントの両方で変更されています",
+	"Keep [y]aml, take [c]lient or [s]kip? ":                              "YAMLを維持[y]、クライアントを採用[c]、スキップ[s]? ",
+	"  (removed)":                                                         "  （削除）",
+	"  → %s (YAML → client)":                                              "  → %s（YAML → クライアント）",
+	"  ← %s (client → YAML)":                                              "  ← %s（クライアント → YAML）",
+	"  - %s removed from client":                                          "  - %sをクライアントから削除",
+	"  - %s removed from YAML":                                            "  - %sをYAMLから削除",
+	"  ! %s was removed from the client but comes from an included file; remove it there": "  ! %sはクライアントから削除されましたが、includeしたファイルで定義されています。そちらから削除してください",
+	"  ! %s changed on both sides (skipped)":                                              "  ! %sは両側で変更されています（スキップ）",
+	"= %s is in sync":                                                                     "= %sは同期済みです",
+	"%s was modified while syncing; run sync again":                                       "同期中に%sが変更されました。もう一度syncを実行してください",
+	"%s was modified while importing; run import again":                                   "取り込み中に%sが変更されました。もう一度importを実行してください",
+	"could not record sync state: %v":                                                     "同期の履歴を記録できませんでした: %v",
+	"Warning: %v":                                                                         "警告: %v",
+	"%d client(s) could not be synced":                                                    "%d個のクライアントを同期できませんでした",
+
+	// status
+	"never":                      "なし",
+	"  last applied: %s":         "  最終適用: %s",
+	"  ✗ cannot parse: %v":       "  ✗ 解析できません: %v",
+	"  ✗ file does not exist":    "  ✗ ファイルがありません",
+	"  + %s (missing in client)": "  + %s（クライアントにない）",
+	"  ~ %s (modified)":          "  ~ %s（変更あり）",
+	"  - %s (not in YAML)":       "  - %s（YAMLにない）",
+	"  (no servers)":             "  （サーバーなし）",
+	"%d of %d client(s) differ from %s; run apply to update them":          "%d/%d個のクライアントが%sと異なります。applyで更新してください",
+	"%d of %d client(s) have no client file yet; run apply to create them": "%d/%d個のクライアントは設定ファイルがまだありません。applyで作成してください",
+	"%d of %d client(s) could not be checked":                              "%d/%d個のクライアントを確認できませんでした",
+	"All %d client(s) are in sync with %s":                                 "%d個のクライアントはすべて%sと一致しています",
+
+	// other commands
+	"client files differ from %s":                      "クライアントの設定が%sと異なります",
+	"%d of %d client(s) differ from %s":                "%d/%d個のクライアントが%sと異なります",
+	"%s has %d problem(s)":                             "%sに%d件の問題があります",
+	"%d problem(s) found":                              "%d件の問題が見つかりました",
+	"%d of %d server(s) failed":                        "%d/%d個のサーバーが失敗しました",
+	"%d server(s) could not be listed":                 "%d個のサーバーのツールを取得できませんでした",
+	"✗ %s could not be compared: %v":                   "✗ %sを比較できませんでした: %v",
+	"    fix: %s":                                      "    対処: %s",
+	"%d problem(s) and %d warning(s) found":            "%d件の問題と%d件の警告が見つかりました",
+	"No problems found, %d warning(s)":                 "問題はありません（警告%d件）",
+	"No problems found":                                "問題はありません",
+	"No servers to test":                               "テストするサーバーがありません",
+	"All %d server(s) responded":                       "%d個のサーバーがすべて応答しました",
+	"No servers to list":                               "ツールを取得するサーバーがありません",
+	"%d client(s) exceed the tool budget of %d tokens": "%d個のクライアントがツールの上限%dトークンを超えています",
+
+	// doctor
+	"make the client a mapping with path and servers":           "クライアントをpathとserversを持つマッピングにしてください",
+	"invalid client configuration":                              "クライアントの設定が正しくありません",
+	"set path to a file inside your home directory":             "pathにはホームディレクトリ内のファイルを指定してください",
+	"install the client, or run: mkdir -p %s":                   "クライアントをインストールするか、次を実行してください: mkdir -p %s",
+	"%s does not exist; the client may not be installed":        "%sがありません。クライアントがインストールされていない可能性があります",
+	"check the permissions of the parent directories":           "親ディレクトリの権限を確認してください",
+	"move %s out of the way":                                    "%sを移動してください",
+	"%s is not a directory":                                     "%sはディレクトリではありません",
+	"run: chmod u+w %s":                                         "次を実行してください: chmod u+w %s",
+	"%s is not writable: %v":                                    "%sに書き込めません: %v",
+	"%s is writable":                                            "%sに書き込めます",
+	"run: chmod 600 %s":                                         "次を実行してください: chmod 600 %s",
+	"%s contains secrets but is readable by others (mode %04o)": "%sには秘密情報がありますが、他のユーザーが読めます（モード%04o）",
+	"%s contains secrets and is private":                        "%sには秘密情報があり、本人だけが読めます",
+	"%s: remote server at %s":                                   "%s: %sのリモートサーバー",
+	"add a command or a url to the server":                      "サーバーにcommandかurlを追加してください",
+	"%s: no command specified":                                  "%s: commandが指定されていません",
+	"install %s or use its absolute path as the command":        "%sをインストールするか、commandに絶対パスを指定してください",
+	"%s: command %q not found on PATH":                          "%s: コマンド%qがPATHにありません",
+	"set env.%s in the YAML":                                    "YAMLでenv.%sを設定してください",
+	"%s: env %s is empty":                                       "%s: env %sが空です",
+	"create %s or fix the path in the YAML":                     "%sを作成するか、YAMLのパスを直してください",
+	"%s: %s does not exist":                                     "%s: %sがありません",
+	"export %s=... in your shell profile, or set it where the client is started":              "シェルの設定でexport %s=...を実行するか、クライアントを起動する環境で設定してください",
+	"%s: environment variable %s is not set":                                                  "%s: 環境変数%sが設定されていません",
+	"install Node.js, which provides npx: https://nodejs.org/":                                "npxを含むNode.jsをインストールしてください: https://nodejs.org/",
+	"install Node.js, which provides npm: https://nodejs.org/":                                "npmを含むNode.jsをインストールしてください: https://nodejs.org/",
+	"install Node.js: https://nodejs.org/":                                                    "Node.jsをインストールしてください: https://nodejs.org/",
+	"install Bun: https://bun.sh/":                                                            "Bunをインストールしてください: https://bun.sh/",
+	"install uv, which provides uvx: https://docs.astral.sh/uv/getting-started/installation/": "uvxを含むuvをインストールしてください: https://docs.astral.sh/uv/getting-started/installation/",
+	"install uv: https://docs.astral.sh/uv/getting-started/installation/":                     "uvをインストールしてください: https://docs.astral.sh/uv/getting-started/installation/",
+	"install Docker Desktop or Docker Engine: https://docs.docker.com/get-docker/":            "Docker DesktopかDocker Engineをインストールしてください: https://docs.docker.com/get-docker/",
+	"install Python 3 or use python3 as the command: https://www.python.org/downloads/":       "Python 3をインストールするか、commandにpython3を指定してください: https://www.python.org/downloads/",
+	"install Python 3: https://www.python.org/downloads/":                                     "Python 3をインストールしてください: https://www.python.org/downloads/",
+	"install Deno: https://deno.com/":                                                         "Denoをインストールしてください: https://deno.com/",
+
+	// validate
+	"client '%s', server '%s': %s":                        "クライアント'%s'、サーバー'%s': %s",
+	"client '%s': %s":                                     "クライアント'%s': %s",
+	"must be a mapping with path and servers":             "pathとserversを持つマッピングにしてください",
+	"invalid when condition: %v":                          "whenの条件が正しくありません: %v",
+	"path is missing":                                     "pathがありません",
+	"servers must be a list":                              "serversはリストにしてください",
+	"server #%d must be a mapping":                        "%d番目のサーバーはマッピングにしてください",
+	"server #%d has no name":                              "%d番目のサーバーにnameがありません",
+	"defined more than once; the last definition is used": "複数回定義されています。最後の定義を使います",
+	"has both command and url":                            "commandとurlの両方があります",
+	"needs a command or a url":                            "commandかurlが必要です",
+	"command must be a non-empty string":                  "commandは空でない文字列にしてください",
+	"url must be a non-empty string":                      "urlは空でない文字列にしてください",
+	"args must be a list":                                 "argsはリストにしてください",
+	"args must be strings, got %v":                        "argsは文字列にしてください（%vがあります）",
+	"%s must be a mapping":                                "%sはマッピングにしてください",
+	"✓ %s is valid: %d client(s), %d server(s)":           "✓ %sは正しい形式です: クライアント%d個、サーバー%d個",
+
+	// watch
+	"Watching %s (%d file(s)); press Ctrl+C to stop": "%sを監視しています（%d個のファイル）。Ctrl+Cで終了します",
+	"[%s] ✗ %v (not applied)":                        "[%s] ✗ %v（適用しません）",
+	"[%s] ✗ %s has %d problem(s) (not applied)":      "[%s] ✗ %sに%d件の問題があります（適用しません）",
+	"[%s] %s changed, applying":                      "[%s] %sが変更されたので適用します",
+	"  ✗ %v (not applied)":                           "  ✗ %v（適用しません）",
+	"  ✗ %s could not be applied: %v":                "  ✗ %sに適用できませんでした: %v",
+	"  ✓ %s updated":                                 "  ✓ %sを更新しました",
+	"  = %s up to date":                              "  = %sは最新です",
+	"[%s] ! %s drifted from the YAML: %s":            "[%s] ! %sがYAMLと異なっています: %s",
+	"cannot parse (%v)":                              "解析できません（%v）",
+
+	// TUI
+	"MCP Setup":                              "MCPセットアップ",
+	"Select configuration file":              "設定ファイルを選択",
+	"Import":                                 "インポート",
+	"Apply":                                  "適用",
+	"Status":                                 "状態",
+	"Config":                                 "設定ファイル",
+	"Quit":                                   "終了",
+	"Import existing mcp.json files to YAML": "既存のmcp.jsonをYAMLに取り込みます",
+	"Apply YAML configuration to mcp.json files":   "YAMLの設定をmcp.jsonに適用します",
+	"Show which clients differ from the YAML":      "YAMLと異なるクライアントを表示します",
+	"Select or create the YAML file (current: %s)": "YAMLファイルを選択または作成します（現在: %s）",
+	"Exit the program":                             "プログラムを終了します",
+	"%s (create new file)":                         "%s（新規作成）",
+	"Other path...":                                "その他のパス...",
+	"Enter a path to an existing or new YAML file": "既存または新規のYAMLファイルのパスを入力します",
+	"Error: %v":               "エラー: %v",
+	"Configuration file path": "設定ファイルのパス",
+	"Enter to use (a new file is created if missing), Esc to go back": "Enterで決定（ファイルがなければ作成）、Escで戻る",
+	"Importing...": "取り込み中...",
+	"Reading mcp.json files from paths in %s...":                       "%sのパスからmcp.jsonを読み込んでいます...",
+	"Calculating changes...":                                           "変更を計算中...",
+	"Analyzing differences between YAML and current configurations...": "YAMLと現在の設定の違いを調べています...",
+	"Import Preview": "インポートのプレビュー",
+	"Apply Preview":  "適用のプレビュー",
+	"Yes":            "はい",
+	"No":             "いいえ",
+	"Use ← → to select, Enter to confirm":                 "← →で選択、Enterで決定",
+	"Write this configuration to %s?":                     "この設定を%sに書き込みますか?",
+	"No changes to apply. Press Enter to return to menu.": "適用する変更はありません。Enterでメニューに戻ります。",
+	"Apply these changes?":                                "この変更を適用しますか?",
+	"Press r to refresh, Enter to return to menu":         "rで再読み込み、Enterでメニューに戻る",
+	"Result":                              "結果",
+	"Press Enter to return to menu":       "Enterでメニューに戻る",
+	"Apply to clients":                    "適用するクライアント",
+	"Import from clients":                 "取り込むクライアント",
+	"No clients are defined in the YAML.": "YAMLにクライアントが定義されていません。",
+	"Space to toggle, a to toggle all, Enter to preview, q to go back": "Spaceで切り替え、aですべて切り替え、Enterでプレビュー、qで戻る",
+	"Import Conflict (%d/%d)":                 "インポートの競合 (%d/%d)",
+	"%s / %s differs between YAML and client": "%s / %sがYAMLとクライアントで異なります",
+	"YAML:":   "YAML:",
+	"Client:": "クライアント:",
+	"✓ Successfully wrote configuration to %s":             "✓ 設定を%sに書き込みました",
+	"  ✗ cannot parse (%v); this file will not be changed": "  ✗ 解析できません（%v）。このファイルは変更しません",
+	"  ~ %s (updated)": "  ~ %s（更新）",
+	noApplyChanges:     "変更はありません。すべての設定は最新です。",
+	"✗ Skipped %s":     "✗ %sをスキップしました",
+	"✓ Updated %s":     "✓ %sを更新しました",
+	"No changes were applied (all configurations were up to date)": "変更はありませんでした（すべての設定は最新です）",
+}
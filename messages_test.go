@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain メッセージは英語で検証し、終了後にビルドしたテスト用サーバーを削除する
+func TestMain(m *testing.M) {
+	lang = LangEnglish
+	code := m.Run()
+	removeFakeMCP()
+	os.Exit(code)
+}
+
+// withLang テストの間だけメッセージの言語を切り替える
+func withLang(t *testing.T, l Lang) {
+	t.Helper()
+	original := lang
+	lang = l
+	t.Cleanup(func() { lang = original })
+}
+
+// TestLangFromLocale ロケールから言語を選ぶテスト
+func TestLangFromLocale(t *testing.T) {
+	for locale, expected := range map[string]Lang{
+		"ja_JP.UTF-8": LangJapanese,
+		"ja":          LangJapanese,
+		"ja_JP@euro":  LangJapanese,
+		"en_US.UTF-8": LangEnglish,
+		"C.UTF-8":     LangEnglish,
+		"POSIX":       LangEnglish,
+		"jav_ID":      LangEnglish,
+	} {
+		assert.Equal(t, expected, langFromLocale(locale), locale)
+	}
+
+	env := map[string]string{"LANG": "ja_JP.UTF-8", "LC_MESSAGES": "en_US.UTF-8"}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	assert.Equal(t, LangEnglish, localeLang(lookup), "LC_MESSAGESがLANGより優先される")
+	env["LC_MESSAGES"] = ""
+	assert.Equal(t, LangJapanese, localeLang(lookup), "空の変数は無視する")
+	env["LC_ALL"] = "C"
+	assert.Equal(t, LangEnglish, localeLang(lookup))
+	assert.Equal(t, LangEnglish, localeLang(func(string) (string, bool) { return "", false }))
+
+	l, err := parseLang("ja")
+	require.NoError(t, err)
+	assert.Equal(t, LangJapanese, l)
+	_, err = parseLang("fr")
+	assert.EqualError(t, err, `invalid language "fr" (expected en or ja)`)
+}
+
+// TestMsgf 現在の言語でメッセージを組み立てるテスト
+func TestMsgf(t *testing.T) {
+	assert.Equal(t, "✓ Updated claude: /h/.claude.json", msgf("✓ Updated %s: %s", "claude", "/h/.claude.json"))
+	assert.Equal(t, "not in the catalog", msgf("not in the catalog"))
+
+	withLang(t, LangJapanese)
+	assert.Equal(t, "✓ claudeを更新しました: /h/.claude.json", msgf("✓ Updated %s: %s", "claude", "/h/.claude.json"))
+	assert.Equal(t, "not in the catalog", msgf("not in the catalog"), "訳がなければ英語のまま")
+
+	cause := os.ErrNotExist
+	err := errorf("Error updating file (client '%s'): %w", "claude", cause)
+	assert.Equal(t, "ファイル更新エラー（クライアント'claude'): file does not exist", err.Error())
+	assert.ErrorIs(t, err, cause, "%wは訳しても包む")
+}
+
+var formatVerb = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// TestJapaneseCatalog コード中のメッセージにすべて訳があり、書式が一致するテスト
+func TestJapaneseCatalog(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+	fset := token.NewFileSet()
+	used := make(map[string]string)
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		require.NoError(t, err)
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			ident, ok := call.Fun.(*ast.Ident)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			format := call.Args[0]
+			switch ident.Name {
+			case "msgf", "errorf":
+			case "reportedExit":
+				format = call.Args[1]
+			case "newError":
+				format = call.Args[2]
+			default:
+				return true
+			}
+			if lit, ok := format.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				message, err := strconv.Unquote(lit.Value)
+				require.NoError(t, err)
+				used[message] = fset.Position(lit.Pos()).String()
+			}
+			return true
+		})
+	}
+	used[noApplyChanges] = "noApplyChanges"
+	for command, hint := range installHints {
+		used[hint] = command + " install hint"
+	}
+	// help translates command summaries and flag usages when printing them
+	for _, cmd := range cliCommands {
+		used[cmd.summary] = cmd.name + " summary"
+		if cmd.details != "" {
+			used[cmd.details] = cmd.name + " details"
+		}
+		fs, _ := commandFlagSet(cmd.name, &CLICommandRunner{}, &globalOptions{})
+		fs.VisitAll(func(f *flag.Flag) {
+			_, usage := flag.UnquoteUsage(f)
+			used[usage] = cmd.name + " --" + f.Name
+		})
+	}
+	globalFlagSet().VisitAll(func(f *flag.Flag) {
+		_, usage := flag.UnquoteUsage(f)
+		used[usage] = "--" + f.Name
+	})
+	require.NotEmpty(t, used)
+
+	for message, pos := range used {
+		translated, ok := japaneseMessages[message]
+		if assert.True(t, ok, "%s: %q has no Japanese translation", pos, message) {
+			assert.Equal(t, formatVerb.FindAllString(message, -1), formatVerb.FindAllString(translated, -1), "%q", message)
+		}
+	}
+	for message := range japaneseMessages {
+		_, ok := used[message]
+		assert.True(t, ok, "%q is not used", message)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// OutputFormat selects how apply, import, diff, status and validate print
+// their results.
+type OutputFormat string
+
+const (
+	OutputText OutputFormat = "text"
+	OutputJSON OutputFormat = "json"
+)
+
+func parseOutputFormat(s string) (OutputFormat, error) {
+	switch OutputFormat(s) {
+	case OutputText, OutputJSON:
+		return OutputFormat(s), nil
+	}
+	return "", errorf("invalid output format %q (expected text or json)", s)
+}
+
+// Exit codes. They are the same for text and JSON output.
+const (
+	ExitOK = 0
+	// ExitError means the command could not run, for example because the
+	// YAML could not be read.
+	ExitError = 1
+	// ExitPartialFailure means some clients failed; the others were
+	// processed.
+	ExitPartialFailure = 2
+	// ExitValidation means validate found problems in the YAML.
+	ExitValidation = 3
+	// ExitDrift means diff or status found clients that differ from the
+	// YAML.
+	ExitDrift = 4
+)
+
+// exitError ends a command with an exit code other than ExitError. When
+// reported is set the command has already printed everything, and main only
+// exits.
+type exitError struct {
+	code     int
+	err      error
+	reported bool
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// reportedExit is returned by a command that printed its results and still
+// has to exit with code. The message is translated like errorf.
+func reportedExit(code int, format string, args ...interface{}) error {
+	return &exitError{code: code, err: errorf(format, args...), reported: true}
+}
+
+// exitCode returns the exit code for an error returned by a command.
+func exitCode(err error) int {
+	var exit *exitError
+	if errors.As(err, &exit) {
+		return exit.code
+	}
+	if err != nil {
+		return ExitError
+	}
+	return ExitOK
+}
+
+// ErrorCode identifies a kind of failure in JSON output. Codes are part of
+// the schema: new ones may be added, existing ones keep their meaning.
+type ErrorCode string
+
+const (
+	CodeError         ErrorCode = "error"
+	CodeInvalidConfig ErrorCode = "invalid_config"
+	CodeInvalidYAML   ErrorCode = "invalid_yaml"
+	CodeUnsafePath    ErrorCode = "unsafe_path"
+	CodeNoServers     ErrorCode = "no_servers"
+	CodeNotFound      ErrorCode = "not_found"
+	CodeClientParse   ErrorCode = "client_parse"
+	CodeWriteFailed   ErrorCode = "write_failed"
+	CodeYAMLTooLarge  ErrorCode = "yaml_too_large"
+	CodeYAMLTooDeep   ErrorCode = "yaml_too_deep"
+	CodeNotInYAML     ErrorCode = "not_in_yaml"
+)
+
+// codedError attaches an ErrorCode to an error without changing its message.
+type codedError struct {
+	code ErrorCode
+	err  error
+}
+
+func (e *codedError) Error() string   { return e.err.Error() }
+func (e *codedError) Unwrap() error   { return e.err }
+func (e *codedError) Code() ErrorCode { return e.code }
+
+func withCode(code ErrorCode, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+// errorCode returns the code of the first error in the chain that has one.
+func errorCode(err error) ErrorCode {
+	var coded interface{ Code() ErrorCode }
+	if errors.As(err, &coded) {
+		return coded.Code()
+	}
+	return CodeError
+}
+
+// OutputSchemaVersion is the version of the JSON report. Fields may be added
+// within a version; removing or changing a field bumps it.
+const OutputSchemaVersion = 1
+
+// jsonReport is the document --output json writes to stdout.
+type jsonReport struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	Command       string       `json:"command"`
+	Config        string       `json:"config"`
+	ExitCode      int          `json:"exitCode"`
+	Clients       []jsonClient `json:"clients"`
+	Errors        []jsonError  `json:"errors"`
+
+	// apply only: nothing was written, statuses tell what would happen
+	DryRun bool `json:"dryRun,omitempty"`
+	// validate only
+	Valid    *bool       `json:"valid,omitempty"`
+	Warnings []jsonError `json:"warnings,omitempty"`
+	// import only: the YAML with the client servers merged in
+	YAML string `json:"yaml,omitempty"`
+}
+
+// jsonClient is the result for one client. Status is one of
+//
+//	apply:    updated, unchanged, skipped, failed
+//	diff:     changed, unchanged, skipped, failed
+//	status:   in_sync, drift, failed
+//	import:   imported, failed
+//	validate: valid, invalid
+type jsonClient struct {
+	Name        string         `json:"name"`
+	Path        string         `json:"path,omitempty"`
+	Status      string         `json:"status"`
+	Servers     jsonServers    `json:"servers"`
+	Conflicts   []jsonConflict `json:"conflicts,omitempty"`
+	Diff        string         `json:"diff,omitempty"`
+	LastApplied *time.Time     `json:"lastApplied,omitempty"`
+	Error       *jsonError     `json:"error,omitempty"`
+}
+
+// jsonServers lists server names by how they relate to the YAML. Added and
+// changed are what apply writes, or for import what is taken from the
+// client; extra servers are in the client file but not in the YAML.
+type jsonServers struct {
+	Added     []string `json:"added"`
+	Changed   []string `json:"changed"`
+	Unchanged []string `json:"unchanged"`
+	Extra     []string `json:"extra"`
+	// import only: servers disabled by `when` on this machine
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// jsonConflict is a server import found with different settings in the YAML
+// and the client, and how it was resolved.
+type jsonConflict struct {
+	Server string         `json:"server"`
+	Choice ConflictChoice `json:"choice"`
+}
+
+type jsonError struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	Client  string    `json:"client,omitempty"`
+	Server  string    `json:"server,omitempty"`
+}
+
+func newJSONReport(command, yamlFile string) *jsonReport {
+	return &jsonReport{
+		SchemaVersion: OutputSchemaVersion,
+		Command:       command,
+		Config:        yamlFile,
+		Clients:       []jsonClient{},
+		Errors:        []jsonError{},
+	}
+}
+
+// newJSONServers returns the server lists with nil lists as empty arrays, so
+// that consumers never see null.
+func newJSONServers(added, changed, unchanged, extra []string) jsonServers {
+	orEmpty := func(names []string) []string {
+		if names == nil {
+			return []string{}
+		}
+		return names
+	}
+	return jsonServers{Added: orEmpty(added), Changed: orEmpty(changed), Unchanged: orEmpty(unchanged), Extra: orEmpty(extra)}
+}
+
+func newJSONError(err error) *jsonError {
+	return &jsonError{Code: errorCode(err), Message: err.Error()}
+}
+
+func writeJSONReport(out io.Writer, report *jsonReport) {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	enc.Encode(report)
+}
+
+// failCommand returns an error that stopped command. In JSON mode it is
+// reported as a document on stdout; otherwise main prints it.
+func failCommand(output OutputFormat, command, yamlFile string, err error) error {
+	if output != OutputJSON {
+		return err
+	}
+	report := newJSONReport(command, yamlFile)
+	report.ExitCode = ExitError
+	report.Errors = append(report.Errors, *newJSONError(err))
+	writeJSONReport(os.Stdout, report)
+	return &exitError{code: ExitError, err: err, reported: true}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestErrorCode エラーコードの取り出しテスト
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, CodeError, errorCode(errors.New("plain")))
+	assert.Nil(t, withCode(CodeNoServers, nil))
+
+	err := fmt.Errorf("context: %w", withCode(CodeClientParse, errors.New("bad json")))
+	assert.Equal(t, CodeClientParse, errorCode(err))
+	assert.Equal(t, "context: bad json", err.Error(), "コードはメッセージを変えない")
+	assert.Equal(t, CodeWriteFailed, errorCode(withCode(CodeWriteFailed, err)), "外側のコードが優先される")
+}
+
+func setupOutputTest(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, ".state"))
+	yamlFile := filepath.Join(home, "servers.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: fetch
+      command: uvx
+    - name: git
+      command: uvx
+  broken:
+    path: .broken.json
+    servers:
+    - name: fetch
+      command: uvx
+  outside:
+    path: /etc/mcp.json
+    servers:
+    - name: fetch
+      command: uvx
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".claude.json"),
+		[]byte(`{"mcpServers": {"fetch": {"command": "npx"}, "old": {"command": "x"}}}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".broken.json"), []byte(`{"mcpServers": `), 0600))
+	return yamlFile
+}
+
+// encodeReport JSONとして出力した内容を読み戻す
+func encodeReport(t *testing.T, report *jsonReport) map[string]interface{} {
+	t.Helper()
+	var out bytes.Buffer
+	writeJSONReport(&out, report)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	return decoded
+}
+
+// TestApplyClients_JSON applyの結果とエラーコードのテスト
+func TestApplyClients_JSON(t *testing.T) {
+	yamlFile := setupOutputTest(t)
+
+	results, err := applyClients(yamlFile, ApplyOptions{})
+	require.NoError(t, err)
+	var clients []jsonClient
+	for _, result := range results {
+		clients = append(clients, result.jsonClient())
+	}
+	require.Len(t, clients, 3)
+
+	assert.Equal(t, "updated", clients[0].Status)
+	assert.Equal(t, []string{"git"}, clients[0].Servers.Added)
+	assert.Equal(t, []string{"fetch"}, clients[0].Servers.Changed)
+	assert.Equal(t, filepath.Join(os.Getenv("HOME"), ".claude.json"), clients[0].Path)
+
+	assert.Equal(t, "failed", clients[1].Status)
+	assert.Equal(t, CodeClientParse, clients[1].Error.Code)
+	assert.Equal(t, CodeUnsafePath, clients[2].Error.Code)
+
+	results, err = applyClients(yamlFile, ApplyOptions{Selection: Selection{Clients: []string{"claude"}}})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "unchanged", results[0].jsonClient().Status)
+	assert.Equal(t, []string{}, results[0].jsonClient().Servers.Added, "空のリストはnullではなく[]")
+}
+
+// TestStatusAndDiff_JSON statusとdiffのJSON変換テスト
+func TestStatusAndDiff_JSON(t *testing.T) {
+	yamlFile := setupOutputTest(t)
+
+	statuses, err := collectStatus(yamlFile)
+	require.NoError(t, err)
+	claude := statuses[0].jsonClient()
+	assert.Equal(t, "drift", claude.Status)
+	assert.Equal(t, []string{"git"}, claude.Servers.Added)
+	assert.Equal(t, []string{"fetch"}, claude.Servers.Changed)
+	assert.Equal(t, []string{"old"}, claude.Servers.Extra)
+	assert.Nil(t, claude.LastApplied)
+	assert.Equal(t, CodeClientParse, statuses[1].jsonClient().Error.Code)
+
+	diffs, err := planDiffs(yamlFile, DiffOptions{Client: "claude"})
+	require.NoError(t, err)
+	diff := diffs[0].jsonClient()
+	assert.Equal(t, "changed", diff.Status)
+	assert.Equal(t, []string{"git"}, diff.Servers.Added)
+	assert.Contains(t, diff.Diff, "@@ -1 +1 @@\n")
+
+	report := newJSONReport(CommandStatus, yamlFile)
+	report.Clients = append(report.Clients, claude)
+	decoded := encodeReport(t, report)
+	assert.Equal(t, float64(OutputSchemaVersion), decoded["schemaVersion"])
+	assert.Equal(t, []interface{}{}, decoded["errors"])
+	assert.NotContains(t, decoded, "valid")
+	assert.NotContains(t, decoded, "yaml")
+}
+
+// TestValidation_JSON validateのJSON変換テスト
+func TestValidation_JSON(t *testing.T) {
+	yamlFile := setupOutputTest(t)
+
+	result, err := validateYAML(yamlFile)
+	require.NoError(t, err)
+	decoded := encodeReport(t, result.jsonReport(yamlFile))
+	assert.Equal(t, false, decoded["valid"])
+	assert.Equal(t, float64(ExitValidation), decoded["exitCode"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"code":    string(CodeInvalidYAML),
+		"message": "access outside the home directory is not allowed: /etc/mcp.json",
+		"client":  "outside",
+	}}, decoded["errors"])
+	clients := decoded["clients"].([]interface{})
+	require.Len(t, clients, 3)
+	assert.Equal(t, "valid", clients[0].(map[string]interface{})["status"])
+	assert.Equal(t, "invalid", clients[2].(map[string]interface{})["status"])
+}
+
+// TestImport_JSON importのJSON変換テスト
+func TestImport_JSON(t *testing.T) {
+	yamlFile := setupOutputTest(t)
+
+	plan, err := planImport(yamlFile, Selection{})
+	require.NoError(t, err)
+	plan.resolveAll(ConflictTakeClient)
+	claude := plan.clients[0].jsonClient()
+	assert.Equal(t, "imported", claude.Status)
+	assert.Equal(t, []string{"old"}, claude.Servers.Added)
+	assert.Equal(t, []jsonConflict{{Server: "fetch", Choice: ConflictTakeClient}}, claude.Conflicts)
+
+	assert.True(t, plan.clients[1].failed())
+	assert.Equal(t, CodeClientParse, plan.clients[1].jsonClient().Error.Code)
+
+	require.NoError(t, os.Remove(filepath.Join(os.Getenv("HOME"), ".broken.json")))
+	plan, err = planImport(yamlFile, Selection{Clients: []string{"broken"}})
+	require.NoError(t, err)
+	assert.False(t, plan.clients[0].failed(), "未インストールのクライアントは失敗ではない")
+	assert.Equal(t, CodeNotFound, plan.clients[0].jsonClient().Error.Code)
+}
+
+// TestLoadErrorCodes YAMLの読み込みエラーのコードテスト
+func TestLoadErrorCodes(t *testing.T) {
+	dir := t.TempDir()
+	_, err := loadEffectiveYAML(filepath.Join(dir, "missing.yaml"))
+	assert.Equal(t, CodeNotFound, errorCode(err))
+
+	broken := filepath.Join(dir, "broken.yaml")
+	require.NoError(t, os.WriteFile(broken, []byte("clients: [\n"), 0600))
+	_, err = loadEffectiveYAML(broken)
+	assert.Equal(t, CodeInvalidYAML, errorCode(err))
+
+	noClients := filepath.Join(dir, "empty.yaml")
+	require.NoError(t, os.WriteFile(noClients, []byte("servers: []\n"), 0600))
+	_, err = loadEffectiveYAML(noClients)
+	assert.Equal(t, CodeInvalidYAML, errorCode(err))
+}
+
+// TestExitCode コマンドが返したエラーから終了コードを決めるテスト
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, ExitOK, exitCode(nil))
+	assert.Equal(t, ExitError, exitCode(errors.New("plain")))
+	err := fmt.Errorf("apply: %w", reportedExit(ExitDrift, "%d client(s) differ", 2))
+	assert.Equal(t, ExitDrift, exitCode(err))
+	assert.Equal(t, "apply: 2 client(s) differ", err.Error())
+}
@@ -1,14 +1,47 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
+	"bytes"
+	"errors"
 	"os"
-	"path/filepath"
 )
 
 type BaseProcessor struct{}
 
+// ApplyOptions controls how apply writes client files.
+type ApplyOptions struct {
+	// ForceReset replaces a client file that cannot be parsed with a new one
+	// holding only mcpServers. Without it such files are never written.
+	ForceReset bool
+
+	// Selection limits apply to some clients and servers.
+	Selection
+
+	// DryRun computes what apply would write without writing anything.
+	DryRun bool
+	// Yes applies without asking for confirmation on a terminal.
+	Yes bool
+
+	Output OutputFormat
+}
+
+// clientResult is what apply did to one client.
+type clientResult struct {
+	name    string
+	path    string
+	dryRun  bool
+	written bool     // with dryRun, whether the file would be written
+	added   []string // servers the file did not have
+	changed []string // servers whose settings were replaced
+	err     error
+}
+
+// clientFailure is a client that processClients could not process.
+type clientFailure struct {
+	name string
+	err  error
+}
+
 func (p *BaseProcessor) getHomeDir() (string, error) {
 	return os.UserHomeDir()
 }
@@ -17,131 +50,244 @@ func (p *BaseProcessor) loadAndValidateYAML(yamlFile string) (map[string]interfa
 	return loadAndValidateYAML(yamlFile)
 }
 
-func (p *BaseProcessor) processClients(yamlContent map[string]interface{}, processFunc func(string, map[string]interface{}, string) error) (int, error) {
+func (p *BaseProcessor) loadEffectiveYAML(yamlFile string) (map[string]interface{}, error) {
+	return loadEffectiveYAML(yamlFile)
+}
+
+// processClients calls processFunc for every client of the YAML in order and
+// returns how many succeeded and why the others failed.
+func (p *BaseProcessor) processClients(yamlContent map[string]interface{}, processFunc func(string, map[string]interface{}, string) error) (int, []clientFailure, error) {
 	homeDir, err := p.getHomeDir()
 	if err != nil {
-		return 0, fmt.Errorf("error getting home directory: %v", err)
+		return 0, nil, errorf("error getting home directory: %v", err)
 	}
 
-	clients := yamlContent["clients"].(map[string]interface{})
 	processedCount := 0
+	var failures []clientFailure
 
-	for clientName, clientConfig := range clients {
-		config, ok := clientConfig.(map[string]interface{})
+	for _, entry := range clientEntries(yamlContent) {
+		clientName := entry.name
+		config, ok := entry.config.(map[string]interface{})
 		if !ok {
-			fmt.Printf("Invalid configuration for client '%s'\n", clientName)
+			logSkip(clientName, "configuration is not a mapping")
+			failures = append(failures, clientFailure{clientName, withCode(CodeInvalidConfig, errorf("invalid configuration"))})
 			continue
 		}
 
 		if err := processFunc(clientName, config, homeDir); err != nil {
-			fmt.Printf("Processing failed for client '%s': %v\n", clientName, err)
+			switch errorCode(err) {
+			case CodeNoServers:
+				logSkip(clientName, "no servers")
+			case CodeUnsafePath:
+				logSkip(clientName, "unsafe path", "error", err)
+			default:
+				logSkip(clientName, "failed", "error", err)
+			}
+			failures = append(failures, clientFailure{clientName, err})
 			continue
 		}
 		processedCount++
 	}
 
-	return processedCount, nil
+	return processedCount, failures, nil
 }
 
-func processClientConfig(clientName string, clientConfig map[string]interface{}, homeDir string) error {
-	pathStr, ok := clientConfig["path"].(string)
-	if !ok {
-		return fmt.Errorf("クライアント'%s'にパスが指定されていません", clientName)
+// applyClient applies one client and records which servers it changed.
+func applyClient(clientName string, clientConfig map[string]interface{}, homeDir string, options ApplyOptions) *clientResult {
+	result := &clientResult{name: clientName, dryRun: options.DryRun}
+	before := compareClient(clientName, clientConfig, homeDir)
+	if options.DryRun {
+		result.path, result.written, result.err = planClientConfig(clientName, clientConfig, homeDir, options)
+	} else {
+		result.path, result.written, result.err = applyClientConfig(clientName, clientConfig, homeDir, options)
 	}
-	validatedPath, err := validateSafePath(pathStr, homeDir)
-	if err != nil {
-		return fmt.Errorf("セキュリティリスク検出（クライアント'%s'): %v", clientName, err)
+	if result.err != nil {
+		return result
 	}
-	servers := extractClientServers(clientConfig)
-	if len(servers) == 0 {
-		return fmt.Errorf("クライアント'%s'にサーバー設定が見つかりません", clientName)
+	if !result.written {
+		logSkip(clientName, "up to date", "path", result.path)
+		return result
 	}
+	if options.DryRun {
+		logger.Debug("client planned", "client", clientName, "path", result.path, "missing", before.missing, "modified", before.modified)
+	} else {
+		logger.Info("client applied", "client", clientName, "path", result.path, "missing", before.missing, "modified", before.modified)
+	}
+	if before.parseErr != nil {
+		// --force-reset replaced the file
+		result.added = extractClientServerNames(clientConfig)
+		return result
+	}
+	result.added, result.changed = before.missing, before.modified
+	return result
+}
 
-	existing := make(map[string]interface{})
-	if data, err := os.ReadFile(validatedPath); err == nil && len(data) > 0 {
-		if err := json.Unmarshal(data, &existing); err != nil {
-			return fmt.Errorf("JSON解析エラー（クライアント'%s'): %v", clientName, err)
-		}
+// skipped reports whether the client has no servers to apply, which leaves
+// its file alone without being a failure.
+func (r *clientResult) skipped() bool {
+	return errors.Is(r.err, ErrNoServers)
+}
+
+// failed reports whether the client could not be applied.
+func (r *clientResult) failed() bool {
+	return r.err != nil && !r.skipped()
+}
+
+// line describes the result for the CLI.
+func (r *clientResult) line() string {
+	switch {
+	case r.skipped():
+		return msgf("- %s has no servers; skipped", r.name)
+	case r.err != nil:
+		return msgf("Processing failed for client '%s': %v", r.name, r.err)
+	case !r.written:
+		return msgf("= %s is up to date: %s", r.name, r.path)
+	case r.dryRun:
+		return msgf("~ Would update %s: %s", r.name, r.path)
 	}
+	return msgf("✓ Updated %s: %s", r.name, r.path)
+}
 
-	if existing["mcpServers"] == nil {
-		existing["mcpServers"] = make(map[string]interface{})
+// planLines describes the result of a dry run with the servers that would
+// change.
+func (r *clientResult) planLines() []string {
+	lines := []string{r.line()}
+	for _, name := range r.added {
+		lines = append(lines, "  + "+name)
 	}
-	mcpServers := existing["mcpServers"].(map[string]interface{})
-	for name, server := range servers {
-		mcpServers[name] = server
+	for _, name := range r.changed {
+		lines = append(lines, "  ~ "+name)
 	}
+	return lines
+}
 
-	if err := os.MkdirAll(filepath.Dir(validatedPath), DirectoryMode); err != nil {
-		return fmt.Errorf("ディレクトリ作成エラー（クライアント'%s'): %v", clientName, err)
+// jsonClient converts the result for --output json.
+func (r *clientResult) jsonClient() jsonClient {
+	client := jsonClient{Name: r.name, Path: r.path, Status: "unchanged", Servers: newJSONServers(r.added, r.changed, nil, nil)}
+	switch {
+	case r.skipped():
+		client.Status = "skipped"
+	case r.err != nil:
+		client.Status = "failed"
+		client.Error = newJSONError(r.err)
+	case r.written:
+		client.Status = "updated"
 	}
+	return client
+}
 
-	output, err := json.MarshalIndent(existing, "", "  ")
+// applyClientConfig writes the servers of one client to its file and returns
+// the file path and whether it changed.
+func applyClientConfig(clientName string, clientConfig map[string]interface{}, homeDir string, options ApplyOptions) (string, bool, error) {
+	validatedPath, names, servers, err := clientTarget(clientName, clientConfig, homeDir)
 	if err != nil {
-		return fmt.Errorf("JSON生成エラー（クライアント'%s'): %v", clientName, err)
+		return validatedPath, false, err
 	}
-
-	if err := os.WriteFile(validatedPath, output, SecureFileMode); err != nil {
-		return fmt.Errorf("ファイル書き込みエラー（クライアント'%s'): %v", clientName, err)
+	written, err := updateClientFile(validatedPath, names, servers, options)
+	if err != nil {
+		if errorCode(err) == CodeError {
+			err = withCode(CodeWriteFailed, err)
+		}
+		return validatedPath, false, errorf("Error updating file (client '%s'): %w", clientName, err)
 	}
+	return validatedPath, written, nil
+}
 
-	fmt.Printf("✓ Updated %s: %s\n", clientName, validatedPath)
-	return nil
+// planClientConfig is applyClientConfig without writing: it reports whether
+// the file would change.
+func planClientConfig(clientName string, clientConfig map[string]interface{}, homeDir string, options ApplyOptions) (string, bool, error) {
+	validatedPath, names, servers, err := clientTarget(clientName, clientConfig, homeDir)
+	if err != nil {
+		return validatedPath, false, err
+	}
+	before, after, err := plannedClientData(validatedPath, names, servers, options)
+	if err != nil {
+		return validatedPath, false, errorf("Error updating file (client '%s'): %w", clientName, err)
+	}
+	return validatedPath, before == nil || !bytes.Equal(before, after), nil
 }
 
-func processSingleClientImportWithOutput(clientName string, config map[string]interface{}, homeDir string, outputClients map[string]interface{}) error {
-	pathStr, ok := config["path"].(string)
+// clientTarget checks the path and servers of one client.
+func clientTarget(clientName string, clientConfig map[string]interface{}, homeDir string) (string, []string, map[string]interface{}, error) {
+	pathStr, ok := clientConfig["path"].(string)
 	if !ok {
-		fmt.Printf("No 'path' specified for client '%s', skipping...\n", clientName)
-		return fmt.Errorf("no path specified")
+		return "", nil, nil, withCode(CodeInvalidConfig, errorf("client '%s' has no path", clientName))
 	}
-
-	originalPath := pathStr
 	validatedPath, err := validateSafePath(pathStr, homeDir)
 	if err != nil {
-		fmt.Printf("Security risk detected for client '%s': %v\n", clientName, err)
-		outputClients[clientName] = map[string]interface{}{
-			"path":    originalPath,
-			"servers": nil,
-		}
-		return err
+		return "", nil, nil, withCode(CodeUnsafePath, errorf("Unsafe path (client '%s'): %w", clientName, err))
 	}
+	servers := extractClientServers(clientConfig)
+	if len(servers) == 0 {
+		return validatedPath, nil, nil, newError(ErrNoServers, nil, "client '%s' has no servers", clientName)
+	}
+	return validatedPath, extractClientServerNames(clientConfig), servers, nil
+}
 
-	pathStr = validatedPath
-	jsonData, err := os.ReadFile(pathStr)
+// openClientData parses a client file for editing. A missing or empty file
+// starts empty. A file that cannot be parsed is refused so that apply never
+// replaces the user's settings with only mcpServers, unless
+// options.ForceReset asks for exactly that.
+func openClientData(path string, data []byte, options ApplyOptions) (*jsonFileEditor, error) {
+	editor, err := newJSONFileEditor(data)
+	if err != nil && options.ForceReset {
+		return newJSONFileEditor(nil)
+	}
 	if err != nil {
-		outputClients[clientName] = map[string]interface{}{
-			"path":    originalPath,
-			"servers": nil,
-		}
-		fmt.Printf("- %s: file not found (path preserved)\n", clientName)
-		return err
+		return nil, newError(ErrClientParse, err, "%s: %v (not written; use --force-reset to replace it)", path, err)
 	}
+	return editor, nil
+}
 
-	var jsonContent map[string]interface{}
-	if err := json.Unmarshal(jsonData, &jsonContent); err != nil {
-		outputClients[clientName] = map[string]interface{}{
-			"path":    originalPath,
-			"servers": nil,
+// mergeClientServers sets the given servers in the mcpServers section of a
+// client file and returns the new file content. Servers are edited one by one
+// inside mcpServers, so the rest of the file keeps its formatting, comments,
+// key order and trailing newline. Servers not managed by the YAML and servers
+// that are already up to date are left untouched.
+func mergeClientServers(editor *jsonFileEditor, names []string, servers map[string]interface{}) ([]byte, error) {
+	if _, ok := editor.memberObject("mcpServers"); !ok {
+		mcpServers := newJSONObject()
+		for _, name := range names {
+			mcpServers.set(name, servers[name])
 		}
-		fmt.Printf("- %s: parse error (path preserved)\n", clientName)
-		return err
+		return editor.setMember("mcpServers", mcpServers)
 	}
 
-	mcpServers, ok := jsonContent["mcpServers"].(map[string]interface{})
-	if !ok || len(mcpServers) == 0 {
-		outputClients[clientName] = map[string]interface{}{
-			"path":    originalPath,
-			"servers": nil,
+	for _, name := range names {
+		span, _ := editor.memberObject("mcpServers")
+		var current interface{}
+		if m, exists := span.member(name); exists {
+			var err error
+			current, err = decodeOrderedJSON(editor.std[m.valueStart:m.valueEnd])
+			if err == nil && sameServerConfig(current, servers[name]) {
+				continue
+			}
+		}
+		// a replaced server keeps the key order it had in the client file
+		output, err := editor.setObjectMember(span, name, orderedLike(servers[name], current))
+		if err != nil {
+			return nil, err
+		}
+		if editor, err = newJSONFileEditor(output); err != nil {
+			return nil, err
 		}
-		fmt.Printf("- %s: no mcpServers (path preserved)\n", clientName)
-		return fmt.Errorf("no mcpServers found")
 	}
+	return editor.src, nil
+}
 
-	outputClients[clientName] = map[string]interface{}{
-		"path":    originalPath,
-		"servers": convertMcpServersToYaml(mcpServers),
+// removeClientServers deletes the named servers from the mcpServers section
+// of a client file, leaving everything else as it is.
+func removeClientServers(data []byte, names []string) ([]byte, error) {
+	for _, name := range names {
+		editor, err := newJSONFileEditor(data)
+		if err != nil {
+			return nil, err
+		}
+		span, ok := editor.memberObject("mcpServers")
+		if !ok {
+			return data, nil
+		}
+		data, _ = editor.removeObjectMember(span, name)
 	}
-	fmt.Printf("✓ Imported %s from %s\n", clientName, pathStr)
-	return nil
+	return data, nil
 }
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadAndValidateYAML_KeepsClientOrder クライアントがYAMLの記述順で処理されるテスト
+func TestLoadAndValidateYAML_KeepsClientOrder(t *testing.T) {
+	yamlFile := filepath.Join(t.TempDir(), "servers.yaml")
+	yamlContent := "clients:\n  zed:\n    path: a.json\n  claude:\n    path: b.json\n  amazonq:\n    path: c.json\n"
+	require.NoError(t, os.WriteFile(yamlFile, []byte(yamlContent), 0600))
+
+	for i := 0; i < 5; i++ {
+		content, err := loadAndValidateYAML(yamlFile)
+		require.NoError(t, err)
+		var names []string
+		for _, entry := range clientEntries(content) {
+			names = append(names, entry.name)
+		}
+		assert.Equal(t, []string{"zed", "claude", "amazonq"}, names)
+	}
+}
+
+// TestApplyClient_KeepsKeyOrder 既存のキー順を保ち新しいキーを末尾に追加するテスト
+func TestApplyClient_KeepsKeyOrder(t *testing.T) {
+	home := t.TempDir()
+	clientFile := filepath.Join(home, ".claude.json")
+	existing := `{"zeta": 1, "mcpServers": {"zzz": {"command": "z"}, "aaa": {"command": "a"}}, "alpha": [1.5, 2]}`
+	require.NoError(t, os.WriteFile(clientFile, []byte(existing), 0600))
+
+	config := map[string]interface{}{
+		"path": ".claude.json",
+		"servers": []interface{}{
+			map[string]interface{}{"name": "new-b", "command": "b"},
+			map[string]interface{}{"name": "aaa", "command": "a2"},
+			map[string]interface{}{"name": "new-a", "command": "a"},
+		},
+	}
+	require.NoError(t, applyClient("claude", config, home, ApplyOptions{}).err)
+
+	data, err := os.ReadFile(clientFile)
+	require.NoError(t, err)
+	// 1行のファイルは1行のまま、mcpServers以外はそのまま残る
+	expected := `{"zeta": 1, "mcpServers": {"zzz": {"command": "z"}, "aaa": {"command":"a2"},"new-b":{"command":"b"},"new-a":{"command":"a"}}, "alpha": [1.5, 2]}`
+	assert.Equal(t, expected, string(data))
+}
+
+// TestApplyClient_RefusesUnparsableFile 解析できないファイルを上書きしないテスト
+func TestApplyClient_RefusesUnparsableFile(t *testing.T) {
+	home := t.TempDir()
+	clientFile := filepath.Join(home, ".claude.json")
+	broken := "{\n  \"theme\": \"dark\",\n  \"projects\": {\n}\n"
+	require.NoError(t, os.WriteFile(clientFile, []byte(broken), 0600))
+
+	config := map[string]interface{}{
+		"path":    ".claude.json",
+		"servers": []interface{}{map[string]interface{}{"name": "a", "command": "a"}},
+	}
+	err := applyClient("claude", config, home, ApplyOptions{}).err
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 5, column 1")
+	assert.Contains(t, err.Error(), "--force-reset")
+
+	data, err := os.ReadFile(clientFile)
+	require.NoError(t, err)
+	assert.Equal(t, broken, string(data))
+
+	require.NoError(t, applyClient("claude", config, home, ApplyOptions{ForceReset: true}).err)
+	data, err = os.ReadFile(clientFile)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"mcpServers\": {\n    \"a\": {\n      \"command\": \"a\"\n    }\n  }\n}\n", string(data))
+}
+
+// TestApplyConfig_DryRun --dry-runでファイルも状態も書き込まないテスト
+func TestApplyConfig_DryRun(t *testing.T) {
+	yamlFile := setupOutputTest(t)
+	clientFile := filepath.Join(os.Getenv("HOME"), ".claude.json")
+	before, err := os.ReadFile(clientFile)
+	require.NoError(t, err)
+
+	var applyErr error
+	out := captureStdout(t, func() {
+		applyErr = applyConfig(yamlFile, ApplyOptions{DryRun: true, Selection: Selection{Clients: []string{"claude"}}})
+	})
+	require.NoError(t, applyErr)
+	assert.Contains(t, out, "~ Would update claude: "+clientFile)
+	assert.Contains(t, out, "  + git")
+	assert.Contains(t, out, "  ~ fetch")
+	assert.Contains(t, out, "Dry run: 1 client(s) would be updated; nothing was written")
+
+	after, err := os.ReadFile(clientFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+	statePath, err := stateFilePath()
+	require.NoError(t, err)
+	assert.NoFileExists(t, statePath, "適用履歴も記録しない")
+
+	// 失敗するクライアントがあれば終了コード2
+	captureStdout(t, func() { applyErr = applyConfig(yamlFile, ApplyOptions{DryRun: true}) })
+	assert.Equal(t, ExitPartialFailure, exitCode(applyErr))
+}
+
+// TestApplyConfig_SkipsClientsWithoutServers サーバーのないクライアントは失敗にせず飛ばすテスト
+func TestApplyConfig_SkipsClientsWithoutServers(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, ".state"))
+	yamlFile := filepath.Join(home, "servers.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(defaultYAML), 0600))
+
+	var applyErr error
+	out := captureStdout(t, func() { applyErr = applyConfig(yamlFile, ApplyOptions{Yes: true}) })
+	require.NoError(t, applyErr)
+	assert.Contains(t, out, "- claude has no servers; skipped")
+
+	results, err := applyClients(yamlFile, ApplyOptions{})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "skipped", results[0].jsonClient().Status)
+}
+
+// TestApplyConfig_Confirm 端末では計画を表示して確認してから書き込むテスト
+func TestApplyConfig_Confirm(t *testing.T) {
+	yamlFile := setupOutputTest(t)
+	clientFile := filepath.Join(os.Getenv("HOME"), ".claude.json")
+	before, err := os.ReadFile(clientFile)
+	require.NoError(t, err)
+
+	originalInteractive, originalPrompt := isInteractive, promptApply
+	defer func() { isInteractive, promptApply = originalInteractive, originalPrompt }()
+	isInteractive = func() bool { return true }
+	asked := 0
+	answer := false
+	promptApply = func(*bufio.Reader, io.Writer) bool {
+		asked++
+		return answer
+	}
+	options := ApplyOptions{Selection: Selection{Clients: []string{"claude"}}}
+
+	out := captureStdout(t, func() { require.NoError(t, applyConfig(yamlFile, options)) })
+	assert.Equal(t, 1, asked)
+	assert.Contains(t, out, "  + git")
+	assert.Contains(t, out, "Nothing was written")
+	after, err := os.ReadFile(clientFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after), "断ったら書き込まない")
+
+	answer = true
+	out = captureStdout(t, func() { require.NoError(t, applyConfig(yamlFile, options)) })
+	assert.Equal(t, 2, asked)
+	assert.Contains(t, out, "✓ Updated claude: "+clientFile)
+
+	// 変更がなければ確認しない
+	out = captureStdout(t, func() { require.NoError(t, applyConfig(yamlFile, options)) })
+	assert.Equal(t, 2, asked)
+	assert.Contains(t, out, "All clients are up to date")
+
+	// --yesでは確認しない
+	promptApply = originalPrompt
+	require.NoError(t, os.WriteFile(clientFile, before, 0600))
+	out = captureStdout(t, func() {
+		require.NoError(t, applyConfig(yamlFile, ApplyOptions{Yes: true, Selection: options.Selection}))
+	})
+	assert.Contains(t, out, "✓ Updated claude: "+clientFile)
+}
+
+// TestPerformApply_SameAsApply TUIの適用がapplyと同じ結果を表示し書き込んだクライアントだけを記録するテスト
+func TestPerformApply_SameAsApply(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, ".state"))
+	yamlFile := filepath.Join(home, "servers.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`clients:
+  claude:
+    path: ~/.claude.json
+    servers:
+      - name: a
+        command: a
+  cursor:
+    path: /etc/mcp.json
+    servers:
+      - name: a
+        command: a
+  nopath:
+    servers:
+      - name: a
+        command: a
+  gemini:
+    path: ~/.gemini/settings.json
+    servers: []
+  vscode:
+    path: ~/vscode.json
+    servers:
+      - name: a
+        command: a
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(home, "vscode.json"), []byte(`{"mcpServers": {"a": {"command": "a"}}}`), 0600))
+
+	result, err := performApply(yamlFile, Selection{})
+	require.NoError(t, err)
+	assert.Contains(t, result, "✓ Updated claude")
+	assert.Contains(t, result, "✗ Skipped cursor")
+	assert.Contains(t, result, "✗ Skipped nopath")
+	assert.Contains(t, result, "- gemini has no servers; skipped")
+	assert.NotContains(t, result, "vscode")
+	assert.Contains(t, result, "Successfully processed 1 client(s)")
+
+	state, err := loadState()
+	require.NoError(t, err)
+	assert.Contains(t, state.Clients, filepath.Join(home, ".claude.json"))
+	assert.NotContains(t, state.Clients, filepath.Join(home, "vscode.json"), "変更のないクライアントは記録しない")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// Selection limits apply and import to some clients and servers, so that a
+// change can be rolled out to one tool first. Empty lists select everything.
+type Selection struct {
+	Clients []string
+	Servers []string
+}
+
+// parseNameList splits a comma separated flag value such as "claude,gemini".
+func parseNameList(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (s Selection) empty() bool {
+	return len(s.Clients) == 0 && len(s.Servers) == 0
+}
+
+func (s Selection) client(name string) bool {
+	return len(s.Clients) == 0 || slices.Contains(s.Clients, name)
+}
+
+func (s Selection) server(name string) bool {
+	return len(s.Servers) == 0 || slices.Contains(s.Servers, name)
+}
+
+// checkClients reports a selected client that the YAML does not define.
+func (s Selection) checkClients(yamlContent map[string]interface{}, yamlFile string) error {
+	for _, name := range s.Clients {
+		found := false
+		for _, entry := range clientEntries(yamlContent) {
+			if entry.name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return newError(ErrNotInYAML, nil, "client '%s' is not in %s", name, yamlFile)
+		}
+	}
+	return nil
+}
+
+// applySelection removes the clients and servers that are not selected from
+// the YAML content. With a server selection, clients that have none of the
+// servers are dropped too, so that they are not reported as empty.
+func applySelection(yamlContent map[string]interface{}, yamlFile string, selection Selection) error {
+	if selection.empty() {
+		return nil
+	}
+	if err := selection.checkClients(yamlContent, yamlFile); err != nil {
+		return err
+	}
+
+	var kept []clientEntry
+	found := make(map[string]bool)
+	for _, entry := range clientEntries(yamlContent) {
+		if !selection.client(entry.name) {
+			logSkip(entry.name, "not selected with --client")
+			continue
+		}
+		config, ok := entry.config.(map[string]interface{})
+		if !ok || len(selection.Servers) == 0 {
+			kept = append(kept, entry)
+			continue
+		}
+		servers, _ := config["servers"].([]interface{})
+		var filtered []interface{}
+		for _, serverData := range servers {
+			server, ok := serverData.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := server["name"].(string)
+			if selection.server(name) {
+				filtered = append(filtered, serverData)
+				found[name] = true
+			} else {
+				logger.Debug("server skipped", "client", entry.name, "server", name, "reason", "not selected with --server")
+			}
+		}
+		if len(filtered) == 0 {
+			logSkip(entry.name, "has none of the servers selected with --server")
+			continue
+		}
+		config["servers"] = filtered
+		kept = append(kept, entry)
+	}
+	for _, name := range selection.Servers {
+		if !found[name] {
+			return newError(ErrNotInYAML, nil, "server '%s' is not in the selected clients of %s", name, yamlFile)
+		}
+	}
+	setClientEntries(yamlContent, kept)
+	return nil
+}
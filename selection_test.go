@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const selectionYAML = `clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: fetch
+      command: uvx
+    - name: git
+      command: uvx
+  gemini:
+    path: .gemini/settings.json
+    servers:
+    - name: git
+      command: uvx
+  cursor:
+    path: .cursor/mcp.json
+    servers:
+    - name: time
+      command: uvx
+`
+
+func selectionNames(t *testing.T, yamlContent map[string]interface{}) map[string][]string {
+	t.Helper()
+	names := make(map[string][]string)
+	for _, entry := range clientEntries(yamlContent) {
+		names[entry.name] = extractClientServerNames(entry.config.(map[string]interface{}))
+	}
+	return names
+}
+
+// TestParseNameList カンマ区切りの名前リストのテスト
+func TestParseNameList(t *testing.T) {
+	assert.Nil(t, parseNameList(""))
+	assert.Equal(t, []string{"claude", "gemini"}, parseNameList(" claude,,gemini ,claude"))
+}
+
+// TestApplySelection クライアントとサーバーの絞り込みテスト
+func TestApplySelection(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	yamlFile := filepath.Join(home, "servers.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(selectionYAML), 0600))
+
+	tests := []struct {
+		name      string
+		selection Selection
+		want      map[string][]string
+		err       string
+	}{
+		{"everything", Selection{}, map[string][]string{"claude": {"fetch", "git"}, "gemini": {"git"}, "cursor": {"time"}}, ""},
+		{"clients", Selection{Clients: []string{"claude", "cursor"}}, map[string][]string{"claude": {"fetch", "git"}, "cursor": {"time"}}, ""},
+		{"servers drop clients without them", Selection{Servers: []string{"git"}}, map[string][]string{"claude": {"git"}, "gemini": {"git"}}, ""},
+		{"both", Selection{Clients: []string{"claude"}, Servers: []string{"fetch"}}, map[string][]string{"claude": {"fetch"}}, ""},
+		{"unknown client", Selection{Clients: []string{"zed"}}, nil, "client 'zed' is not in " + yamlFile},
+		{"server of another client", Selection{Clients: []string{"gemini"}, Servers: []string{"fetch"}}, nil,
+			"server 'fetch' is not in the selected clients of " + yamlFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			yamlContent, err := loadEffectiveYAML(yamlFile)
+			require.NoError(t, err)
+			err = applySelection(yamlContent, yamlFile, tt.selection)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, selectionNames(t, yamlContent))
+		})
+	}
+}
+
+// TestPerformApply_Selection TUIで選択したクライアントだけに適用するテスト
+func TestPerformApply_Selection(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, ".state"))
+	yamlFile := filepath.Join(home, "servers.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(selectionYAML), 0600))
+
+	preview, err := generateApplyPreview(yamlFile, Selection{Clients: []string{"gemini"}})
+	require.NoError(t, err)
+	assert.Contains(t, preview, "gemini")
+	assert.NotContains(t, preview, "claude")
+
+	_, err = performApply(yamlFile, Selection{Clients: []string{"gemini"}})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(home, ".gemini", "settings.json"))
+	assert.NoFileExists(t, filepath.Join(home, ".claude.json"))
+	assert.NoFileExists(t, filepath.Join(home, ".cursor", "mcp.json"))
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultTestTimeout is how long a server gets to answer the handshake.
+const DefaultTestTimeout = 10 * time.Second
+
+// TestOptions selects the servers `test` starts.
+type TestOptions struct {
+	Client  string
+	Server  string
+	Timeout time.Duration
+}
+
+// serverTest is one server of one client to test.
+type serverTest struct {
+	client string
+	name   string
+	config map[string]interface{}
+	probe  *serverProbe
+}
+
+// planServerTests lists the servers of the YAML that match options, in YAML
+// order.
+func planServerTests(yamlFile string, options TestOptions) ([]*serverTest, error) {
+	yamlContent, err := loadEffectiveYAML(yamlFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var tests []*serverTest
+	clientFound := false
+	for _, entry := range clientEntries(yamlContent) {
+		if options.Client != "" && entry.name != options.Client {
+			continue
+		}
+		clientFound = true
+		config, ok := entry.config.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		servers := extractClientServers(config)
+		for _, name := range extractClientServerNames(config) {
+			if options.Server != "" && name != options.Server {
+				continue
+			}
+			server, _ := servers[name].(map[string]interface{})
+			tests = append(tests, &serverTest{client: entry.name, name: name, config: server})
+		}
+	}
+	switch {
+	case options.Client != "" && !clientFound:
+		return nil, newError(ErrNotInYAML, nil, "client '%s' is not in %s", options.Client, yamlFile)
+	case options.Server != "" && len(tests) == 0:
+		return nil, newError(ErrNotInYAML, nil, "server '%s' is not in %s", options.Server, yamlFile)
+	}
+	return tests, nil
+}
+
+// runServerTests probes every server once; clients that share a server with
+// the same settings share its result.
+func runServerTests(ctx context.Context, tests []*serverTest, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultTestTimeout
+	}
+	probes := make(map[string]*serverProbe)
+	for _, st := range tests {
+		key, _ := json.Marshal(st.config)
+		if probe, ok := probes[string(key)]; ok {
+			st.probe = probe
+			continue
+		}
+		st.probe = probeServer(ctx, st.config, timeout)
+		probes[string(key)] = st.probe
+	}
+}
+
+// probeServer starts a server and runs the MCP handshake against it.
+func probeServer(ctx context.Context, server map[string]interface{}, timeout time.Duration) *serverProbe {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if serverURL, ok := server["url"].(string); ok {
+		return probeRemoteServer(ctx, serverURL, server)
+	}
+
+	probe := &serverProbe{transport: TransportStdio}
+	t, err := startStdioServer(server)
+	if err != nil {
+		probe.err = err
+		return probe
+	}
+	probe.err = mcpHandshake(ctx, t, probe)
+	t.close()
+	probe.stderr = t.stderr.String()
+	return probe
+}
+
+// probeRemoteServer runs the handshake over the transport named by the
+// server's type. Without a type, Streamable HTTP is tried first and legacy
+// SSE is used when the server rejects the POST, as the MCP specification
+// suggests for backwards compatibility.
+func probeRemoteServer(ctx context.Context, serverURL string, server map[string]interface{}) *serverProbe {
+	headers := remoteHeaders(server)
+	kind, _ := server["type"].(string)
+	if kind == "sse" {
+		return probeSSEServer(ctx, serverURL, headers)
+	}
+
+	probe := &serverProbe{transport: TransportStreamableHTTP}
+	t := newHTTPTransport(serverURL, headers)
+	probe.err = mcpHandshake(ctx, t, probe)
+	t.close()
+
+	var statusErr *httpStatusError
+	if kind == "" && errors.As(probe.err, &statusErr) && probe.protocolVersion == "" &&
+		(statusErr.code == http.StatusNotFound || statusErr.code == http.StatusMethodNotAllowed || statusErr.code == http.StatusBadRequest) {
+		return probeSSEServer(ctx, serverURL, headers)
+	}
+	return probe
+}
+
+func probeSSEServer(ctx context.Context, serverURL string, headers map[string]string) *serverProbe {
+	probe := &serverProbe{transport: TransportSSE}
+	t, err := startSSETransport(ctx, serverURL, headers)
+	if err != nil {
+		probe.err = err
+		return probe
+	}
+	probe.err = mcpHandshake(ctx, t, probe)
+	t.close()
+	return probe
+}
+
+// lines describes the result for the CLI.
+func (st *serverTest) lines() []string {
+	probe := st.probe
+	var lines []string
+	over := ""
+	if probe.transport != TransportStdio {
+		over = " over " + probe.transport
+	}
+	if probe.err != nil {
+		lines = append(lines, fmt.Sprintf("  ✗ %s: %v", st.name, probe.err))
+	} else {
+		lines = append(lines, fmt.Sprintf("  ✓ %s: %s %s (protocol %s%s), %d tool(s)",
+			st.name, probe.serverInfo.Name, probe.serverInfo.Version, probe.protocolVersion, over, len(probe.tools)))
+	}
+	if stderr := strings.TrimSpace(probe.stderr); stderr != "" {
+		lines = append(lines, "    stderr:")
+		for _, line := range strings.Split(stderr, "\n") {
+			lines = append(lines, "      "+strings.TrimRight(line, "\r"))
+		}
+	}
+	return lines
+}
+
+// printServerTests writes the results grouped by client and returns how many
+// servers failed.
+func printServerTests(out io.Writer, tests []*serverTest) int {
+	failed := 0
+	client := ""
+	for _, st := range tests {
+		if st.client != client {
+			if client != "" {
+				fmt.Fprintln(out)
+			}
+			client = st.client
+			fmt.Fprintln(out, client)
+		}
+		for _, line := range st.lines() {
+			fmt.Fprintln(out, line)
+		}
+		if st.probe.err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+func testConfigServers(yamlFile string, options TestOptions) error {
+	tests, err := planServerTests(yamlFile, options)
+	if err != nil {
+		return err
+	}
+	if len(tests) == 0 {
+		fmt.Println(msgf("No servers to test"))
+		return nil
+	}
+
+	runServerTests(context.Background(), tests, options.Timeout)
+	failed := printServerTests(os.Stdout, tests)
+	if failed > 0 {
+		fmt.Println("\n" + msgf("%d of %d server(s) failed", failed, len(tests)))
+		return reportedExit(ExitError, "%d of %d server(s) failed", failed, len(tests))
+	}
+	fmt.Println("\n" + msgf("All %d server(s) responded", len(tests)))
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	fakeMCPOnce sync.Once
+	fakeMCPDir  string
+	fakeMCPPath string
+	fakeMCPErr  error
+)
+
+// removeFakeMCP deletes the fake server built by buildFakeMCP. TestMain
+// calls it after the tests.
+func removeFakeMCP() {
+	if fakeMCPDir != "" {
+		os.RemoveAll(fakeMCPDir)
+	}
+}
+
+// buildFakeMCP builds testdata/fakemcp once per test run. Tests share it, so
+// it lives outside t.TempDir until removeFakeMCP.
+func buildFakeMCP(t *testing.T) string {
+	t.Helper()
+	fakeMCPOnce.Do(func() {
+		fakeMCPDir, fakeMCPErr = os.MkdirTemp("", "fakemcp")
+		if fakeMCPErr != nil {
+			return
+		}
+		fakeMCPPath = filepath.Join(fakeMCPDir, "fakemcp")
+		if runtime.GOOS == "windows" {
+			fakeMCPPath += ".exe"
+		}
+		out, err := exec.Command("go", "build", "-o", fakeMCPPath, "./testdata/fakemcp").CombinedOutput()
+		if err != nil {
+			fakeMCPErr = err
+			fakeMCPPath = string(out)
+		}
+	})
+	require.NoError(t, fakeMCPErr, fakeMCPPath)
+	return fakeMCPPath
+}
+
+func fakeServer(t *testing.T, mode string, args ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"command": buildFakeMCP(t),
+		"args":    args,
+		"env":     map[string]interface{}{"FAKEMCP_MODE": mode, "FAKEMCP_TOOLS": 5},
+	}
+}
+
+// TestProbeServer_Handshake initializeとtools/listのテスト
+func TestProbeServer_Handshake(t *testing.T) {
+	probe := probeServer(context.Background(), fakeServer(t, "", "--root", "/tmp"), 5*time.Second)
+	require.NoError(t, probe.err)
+	assert.Equal(t, "fakemcp", probe.serverInfo.Name)
+	assert.Equal(t, "1.2.3", probe.serverInfo.Version)
+	assert.Equal(t, "2025-06-18", probe.protocolVersion)
+	// five tools over three pages
+	require.Len(t, probe.tools, 5)
+	assert.Equal(t, "tool5", probe.tools[4].Name)
+	assert.Contains(t, probe.stderr, "fakemcp starting [--root /tmp]")
+}
+
+// TestProbeServer_ServerRequest サーバーからのpingに応答するテスト
+func TestProbeServer_ServerRequest(t *testing.T) {
+	probe := probeServer(context.Background(), fakeServer(t, "ping"), 5*time.Second)
+	require.NoError(t, probe.err)
+	assert.Len(t, probe.tools, 5)
+}
+
+// TestProbeServer_Failures 起動失敗・クラッシュ・タイムアウトのテスト
+func TestProbeServer_Failures(t *testing.T) {
+	t.Run("crash", func(t *testing.T) {
+		probe := probeServer(context.Background(), fakeServer(t, "crash"), 5*time.Second)
+		require.Error(t, probe.err)
+		assert.Contains(t, probe.err.Error(), "exit status 3")
+		assert.Contains(t, probe.stderr, "fatal: missing API key")
+	})
+	t.Run("hang", func(t *testing.T) {
+		start := time.Now()
+		probe := probeServer(context.Background(), fakeServer(t, "hang"), 300*time.Millisecond)
+		require.Error(t, probe.err)
+		assert.ErrorIs(t, probe.err, context.DeadlineExceeded)
+		assert.Contains(t, probe.err.Error(), "initialize")
+		assert.Less(t, time.Since(start), 3*time.Second)
+	})
+	t.Run("command not found", func(t *testing.T) {
+		probe := probeServer(context.Background(), map[string]interface{}{"command": "mcpyammy-no-such-server"}, time.Second)
+		require.Error(t, probe.err)
+		assert.Contains(t, probe.err.Error(), "mcpyammy-no-such-server")
+	})
+	t.Run("no command", func(t *testing.T) {
+		probe := probeServer(context.Background(), map[string]interface{}{"args": []interface{}{"x"}}, time.Second)
+		assert.EqualError(t, probe.err, "no command specified")
+	})
+}
+
+func writeTestYAML(t *testing.T, fake, remoteURL string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	yamlFile := filepath.Join(home, "servers.yaml")
+	content := `clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: good
+      command: ` + fake + `
+    - name: broken
+      command: ` + fake + `
+      env:
+        FAKEMCP_MODE: crash
+  cursor:
+    path: .cursor/mcp.json
+    servers:
+    - name: good
+      command: ` + fake + `
+    - name: remote
+      url: ` + remoteURL + `
+      headers:
+        Authorization: Bearer secret
+`
+	require.NoError(t, os.WriteFile(yamlFile, []byte(content), 0600))
+	return yamlFile
+}
+
+// TestPlanServerTests クライアント・サーバーの絞り込みテスト
+func TestPlanServerTests(t *testing.T) {
+	yamlFile := writeTestYAML(t, "fake", "https://example.com/mcp")
+
+	tests, err := planServerTests(yamlFile, TestOptions{})
+	require.NoError(t, err)
+	var names []string
+	for _, st := range tests {
+		names = append(names, st.client+"/"+st.name)
+	}
+	assert.Equal(t, []string{"claude/good", "claude/broken", "cursor/good", "cursor/remote"}, names)
+
+	tests, err = planServerTests(yamlFile, TestOptions{Client: "cursor", Server: "good"})
+	require.NoError(t, err)
+	require.Len(t, tests, 1)
+	assert.Equal(t, "cursor", tests[0].client)
+
+	_, err = planServerTests(yamlFile, TestOptions{Client: "vscode"})
+	assert.EqualError(t, err, "client 'vscode' is not in "+yamlFile)
+	_, err = planServerTests(yamlFile, TestOptions{Client: "claude", Server: "remote"})
+	assert.EqualError(t, err, "server 'remote' is not in "+yamlFile)
+}
+
+// TestRunServerTests 結果の表示と同一サーバーの再利用テスト
+func TestRunServerTests(t *testing.T) {
+	remote, _ := newStreamableServer(t, false)
+	yamlFile := writeTestYAML(t, buildFakeMCP(t), remote.URL)
+	tests, err := planServerTests(yamlFile, TestOptions{})
+	require.NoError(t, err)
+
+	runServerTests(context.Background(), tests, 5*time.Second)
+	assert.Same(t, tests[0].probe, tests[2].probe, "identical servers are started once")
+
+	var out bytes.Buffer
+	failed := printServerTests(&out, tests)
+	assert.Equal(t, 1, failed)
+	output := out.String()
+	assert.Contains(t, output, "claude\n  ✓ good: fakemcp 1.2.3 (protocol 2025-06-18), 3 tool(s)\n    stderr:\n      fakemcp starting []\n")
+	assert.Contains(t, output, "  ✗ broken: initialize: server exited: exit status 3")
+	assert.Contains(t, output, "      fatal: missing API key\n")
+	assert.Contains(t, output, "\ncursor\n")
+	assert.Contains(t, output, "  ✓ remote: remote 0.9 (protocol 2025-06-18 over streamable HTTP), 2 tool(s)\n")
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	StateFileName = "state.json"
+	stateVersion  = 1
+)
+
+// appState is what mcpyammy remembers between runs, keyed by the absolute
+// path of each client file.
+type appState struct {
+	Version int                     `json:"version"`
+	Clients map[string]*clientState `json:"clients"`
+}
+
+type clientState struct {
+	LastApplied time.Time `json:"lastApplied,omitempty"`
+	// LastSynced and Servers are the baseline of the last sync: the servers
+	// both the YAML and the client file agreed on at that point.
+	LastSynced time.Time              `json:"lastSynced,omitempty"`
+	Servers    map[string]interface{} `json:"servers,omitempty"`
+}
+
+// stateFilePath returns $XDG_STATE_HOME/mcpyammy/state.json, defaulting to
+// ~/.local/state when XDG_STATE_HOME is not set.
+func stateFilePath() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, ConfigDirName, StateFileName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", ConfigDirName, StateFileName), nil
+}
+
+// loadState reads the state file. A missing file is an empty state.
+func loadState() (*appState, error) {
+	state := &appState{Version: stateVersion, Clients: make(map[string]*clientState)}
+	path, err := stateFilePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, errorf("Error parsing state file (%s): %v", path, err)
+	}
+	if state.Clients == nil {
+		state.Clients = make(map[string]*clientState)
+	}
+	return state, nil
+}
+
+func (s *appState) save() error {
+	path, err := stateFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), DirectoryMode); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), SecureFileMode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// client returns the state of the client file at path, creating it when
+// needed.
+func (s *appState) client(path string) *clientState {
+	cs, ok := s.Clients[path]
+	if !ok {
+		cs = &clientState{}
+		s.Clients[path] = cs
+	}
+	return cs
+}
+
+// recordApplied stores the time apply last succeeded for each client file.
+func recordApplied(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	state, err := loadState()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, path := range paths {
+		state.client(path).LastApplied = now
+	}
+	return state.save()
+}
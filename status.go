@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// StatusOptions controls how `status` prints.
+type StatusOptions struct {
+	Output OutputFormat
+}
+
+// clientStatus compares one client file with the servers the YAML declares
+// for it on this machine.
+type clientStatus struct {
+	name        string
+	path        string
+	err         error // the path is missing or unsafe
+	exists      bool
+	parseErr    error
+	declared    int
+	inSync      []string
+	missing     []string // in the YAML but not in the client
+	modified    []string // in both with different settings
+	extra       []string // in the client but not in the YAML
+	lastApplied time.Time
+}
+
+// inDrift reports whether apply would change the client file, or could not.
+func (cs *clientStatus) inDrift() bool {
+	return cs.err != nil || cs.parseErr != nil || len(cs.missing) > 0 || len(cs.modified) > 0
+}
+
+// compareClient reads the client file of config and sorts its servers by how
+// they relate to the YAML.
+func compareClient(clientName string, config map[string]interface{}, homeDir string) *clientStatus {
+	cs := &clientStatus{name: clientName}
+	pathStr, ok := config["path"].(string)
+	if !ok {
+		cs.err = withCode(CodeInvalidConfig, errorf("no path given"))
+		return cs
+	}
+	validatedPath, err := validateSafePath(pathStr, homeDir)
+	if err != nil {
+		cs.path = pathStr
+		cs.err = withCode(CodeUnsafePath, err)
+		return cs
+	}
+	cs.path = validatedPath
+
+	servers := extractClientServers(config)
+	cs.declared = len(servers)
+	existingServers := newJSONObject()
+	data, err := os.ReadFile(validatedPath)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		cs.parseErr = ofKind(ErrClientParse, err)
+		return cs
+	default:
+		cs.exists = true
+		if len(data) > 0 {
+			root, err := readClientJSONObject(data)
+			if err != nil {
+				cs.parseErr = ofKind(ErrClientParse, err)
+				return cs
+			}
+			if mcpServers := mcpServersObject(root); mcpServers != nil {
+				existingServers = mcpServers
+			}
+		}
+	}
+
+	for _, name := range extractClientServerNames(config) {
+		existingServer, exists := existingServers.get(name)
+		switch {
+		case !exists:
+			cs.missing = append(cs.missing, name)
+			logger.Debug("server compared", "client", clientName, "server", name, "result", "missing")
+		case sameServerConfig(servers[name], existingServer):
+			cs.inSync = append(cs.inSync, name)
+			logger.Debug("server compared", "client", clientName, "server", name, "result", "in sync")
+		default:
+			cs.modified = append(cs.modified, name)
+			logger.Debug("server compared", "client", clientName, "server", name, "result", "modified")
+		}
+	}
+	for _, name := range existingServers.keys {
+		if _, exists := servers[name]; !exists {
+			cs.extra = append(cs.extra, name)
+			logger.Debug("server compared", "client", clientName, "server", name, "result", "extra")
+		}
+	}
+	return cs
+}
+
+// collectStatus compares every client in the YAML with its file.
+func collectStatus(yamlFile string) ([]*clientStatus, error) {
+	yamlContent, err := loadEffectiveYAML(yamlFile)
+	if err != nil {
+		return nil, err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errorf("error getting home directory: %v", err)
+	}
+	state, err := loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []*clientStatus
+	for _, entry := range clientEntries(yamlContent) {
+		config, ok := entry.config.(map[string]interface{})
+		if !ok {
+			statuses = append(statuses, &clientStatus{name: entry.name, err: withCode(CodeInvalidConfig, errorf("invalid configuration"))})
+			continue
+		}
+		cs := compareClient(entry.name, config, homeDir)
+		if applied, ok := state.Clients[cs.path]; ok {
+			cs.lastApplied = applied.LastApplied
+		}
+		statuses = append(statuses, cs)
+	}
+	return statuses, nil
+}
+
+// statusLineKind tells front ends how to style a status line.
+type statusLineKind int
+
+const (
+	statusHeader statusLineKind = iota
+	statusInfo
+	statusOK
+	statusAdd
+	statusChange
+	statusRemove
+	statusError
+)
+
+type statusLine struct {
+	kind statusLineKind
+	text string
+}
+
+// lines describes the status of one client for the CLI and the TUI.
+func (cs *clientStatus) lines() []statusLine {
+	lines := []statusLine{{statusHeader, cs.name}}
+	if cs.path != "" {
+		lines = append(lines, statusLine{statusInfo, "  → " + cs.path})
+	}
+	if cs.err != nil {
+		return append(lines, statusLine{statusError, fmt.Sprintf("  ✗ %v", cs.err)})
+	}
+	lastApplied := msgf("never")
+	if !cs.lastApplied.IsZero() {
+		lastApplied = cs.lastApplied.Local().Format("2006-01-02 15:04:05")
+	}
+	lines = append(lines, statusLine{statusInfo, msgf("  last applied: %s", lastApplied)})
+	if cs.parseErr != nil {
+		return append(lines, statusLine{statusError, msgf("  ✗ cannot parse: %v", cs.parseErr)})
+	}
+	if !cs.exists {
+		lines = append(lines, statusLine{statusError, msgf("  ✗ file does not exist")})
+	}
+	for _, name := range cs.inSync {
+		lines = append(lines, statusLine{statusOK, "  ✓ " + name})
+	}
+	for _, name := range cs.missing {
+		lines = append(lines, statusLine{statusAdd, msgf("  + %s (missing in client)", name)})
+	}
+	for _, name := range cs.modified {
+		lines = append(lines, statusLine{statusChange, msgf("  ~ %s (modified)", name)})
+	}
+	for _, name := range cs.extra {
+		lines = append(lines, statusLine{statusRemove, msgf("  - %s (not in YAML)", name)})
+	}
+	if cs.declared == 0 && len(cs.extra) == 0 {
+		lines = append(lines, statusLine{statusInfo, msgf("  (no servers)")})
+	}
+	return lines
+}
+
+// failure returns why the client could not be compared, if it could not.
+func (cs *clientStatus) failure() error {
+	if cs.err != nil {
+		return cs.err
+	}
+	return cs.parseErr
+}
+
+// jsonClient converts the status for --output json.
+func (cs *clientStatus) jsonClient() jsonClient {
+	client := jsonClient{Name: cs.name, Path: cs.path, Status: "in_sync",
+		Servers: newJSONServers(cs.missing, cs.modified, cs.inSync, cs.extra)}
+	if !cs.lastApplied.IsZero() {
+		client.LastApplied = &cs.lastApplied
+	}
+	switch {
+	case cs.failure() != nil:
+		client.Status = "failed"
+		client.Error = newJSONError(cs.failure())
+	case cs.inDrift():
+		client.Status = "drift"
+	}
+	return client
+}
+
+func showStatus(yamlFile string, options StatusOptions) error {
+	statuses, err := collectStatus(yamlFile)
+	if err != nil {
+		return failCommand(options.Output, CommandStatus, yamlFile, err)
+	}
+
+	exitCode := ExitOK
+	drifted, noFile, failed := 0, 0, 0
+	for _, cs := range statuses {
+		switch {
+		case cs.failure() != nil:
+			failed++
+			exitCode = ExitPartialFailure
+		case !cs.inDrift():
+			continue
+		case !cs.exists:
+			noFile++
+		default:
+			drifted++
+		}
+		if exitCode == ExitOK {
+			exitCode = ExitDrift
+		}
+	}
+
+	if options.Output == OutputJSON {
+		report := newJSONReport(CommandStatus, yamlFile)
+		report.ExitCode = exitCode
+		for _, cs := range statuses {
+			report.Clients = append(report.Clients, cs.jsonClient())
+			if err := cs.failure(); err != nil {
+				jsonErr := newJSONError(err)
+				jsonErr.Client = cs.name
+				report.Errors = append(report.Errors, *jsonErr)
+			}
+		}
+		writeJSONReport(os.Stdout, report)
+	} else {
+		for i, cs := range statuses {
+			if i > 0 {
+				fmt.Println()
+			}
+			for _, line := range cs.lines() {
+				fmt.Println(line.text)
+			}
+		}
+		fmt.Println()
+		if drifted > 0 {
+			fmt.Println(msgf("%d of %d client(s) differ from %s; run apply to update them", drifted, len(statuses), yamlFile))
+		}
+		if noFile > 0 {
+			fmt.Println(msgf("%d of %d client(s) have no client file yet; run apply to create them", noFile, len(statuses)))
+		}
+		if failed > 0 {
+			fmt.Println(msgf("%d of %d client(s) could not be checked", failed, len(statuses)))
+		}
+		if exitCode == ExitOK {
+			fmt.Println(msgf("All %d client(s) are in sync with %s", len(statuses), yamlFile))
+		}
+	}
+	switch {
+	case failed > 0:
+		return reportedExit(exitCode, "%d of %d client(s) could not be checked", failed, len(statuses))
+	case exitCode != ExitOK:
+		return reportedExit(exitCode, "%d of %d client(s) differ from %s", drifted+noFile, len(statuses), yamlFile)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupStatusTest(t *testing.T) (string, string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+
+	clientJSON := `{
+  // JSONC is fine here too
+  "mcpServers": {
+    "same": {"command": "x"},
+    "changed": {"command": "old"},
+    "manual": {"command": "m"}
+  }
+}`
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".claude.json"), []byte(clientJSON), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(home, "broken.json"), []byte(`{"a": `), 0600))
+
+	yamlFile := filepath.Join(home, "servers.yaml")
+	yamlContent := `clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: same
+      command: x
+    - name: changed
+      command: new
+    - name: added
+      command: a
+  gemini:
+    path: .gemini/settings.json
+    servers:
+    - name: same
+      command: x
+  broken:
+    path: broken.json
+    servers:
+    - name: same
+      command: x
+`
+	require.NoError(t, os.WriteFile(yamlFile, []byte(yamlContent), 0600))
+	return home, yamlFile
+}
+
+// TestCollectStatus クライアントごとの差分分類テスト
+func TestCollectStatus(t *testing.T) {
+	home, yamlFile := setupStatusTest(t)
+
+	statuses, err := collectStatus(yamlFile)
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+
+	claude := statuses[0]
+	assert.Equal(t, "claude", claude.name)
+	assert.True(t, claude.exists)
+	assert.NoError(t, claude.parseErr)
+	assert.Equal(t, []string{"same"}, claude.inSync)
+	assert.Equal(t, []string{"added"}, claude.missing)
+	assert.Equal(t, []string{"changed"}, claude.modified)
+	assert.Equal(t, []string{"manual"}, claude.extra)
+	assert.True(t, claude.lastApplied.IsZero())
+	assert.True(t, claude.inDrift())
+
+	gemini := statuses[1]
+	assert.False(t, gemini.exists)
+	assert.Equal(t, []string{"same"}, gemini.missing)
+
+	broken := statuses[2]
+	assert.True(t, broken.exists)
+	assert.ErrorContains(t, broken.parseErr, "line 1, column 7")
+
+	require.NoError(t, recordApplied([]string{filepath.Join(home, ".claude.json")}))
+	statuses, err = collectStatus(yamlFile)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), statuses[0].lastApplied, time.Minute)
+	assert.True(t, statuses[1].lastApplied.IsZero())
+}
+
+// TestClientStatus_Lines ステータス表示の行テスト
+func TestClientStatus_Lines(t *testing.T) {
+	cs := &clientStatus{
+		name:     "claude",
+		path:     "/home/u/.claude.json",
+		exists:   true,
+		declared: 2,
+		inSync:   []string{"a"},
+		missing:  []string{"b"},
+		extra:    []string{"c"},
+	}
+	var texts []string
+	for _, line := range cs.lines() {
+		texts = append(texts, line.text)
+	}
+	assert.Equal(t, []string{
+		"claude",
+		"  → /home/u/.claude.json",
+		"  last applied: never",
+		"  ✓ a",
+		"  + b (missing in client)",
+		"  - c (not in YAML)",
+	}, texts)
+}
+
+// TestShowStatus_Summary 差分・ファイルなし・確認できないクライアントを分けて数えるテスト
+func TestShowStatus_Summary(t *testing.T) {
+	_, yamlFile := setupStatusTest(t)
+
+	var err error
+	output := captureStdout(t, func() { err = showStatus(yamlFile, StatusOptions{}) })
+	assert.Equal(t, ExitPartialFailure, exitCode(err))
+	assert.EqualError(t, err, "1 of 3 client(s) could not be checked")
+	assert.Contains(t, output, "1 of 3 client(s) differ from "+yamlFile+"; run apply to update them")
+	assert.Contains(t, output, "1 of 3 client(s) have no client file yet; run apply to create them")
+	assert.Contains(t, output, "1 of 3 client(s) could not be checked")
+	assert.NotContains(t, output, "in sync with")
+
+	// a client file that does not exist is never reported as in sync
+	require.NoError(t, os.WriteFile(yamlFile, []byte("clients:\n  gemini:\n    path: .gemini/settings.json\n    servers:\n    - name: same\n      command: x\n"), 0600))
+	output = captureStdout(t, func() { err = showStatus(yamlFile, StatusOptions{}) })
+	assert.Equal(t, ExitDrift, exitCode(err))
+	assert.EqualError(t, err, "1 of 1 client(s) differ from "+yamlFile)
+	assert.NotContains(t, output, "in sync with")
+}
@@ -0,0 +1,491 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// SyncChoice resolves a server that changed on both sides since the last
+// sync.
+type SyncChoice string
+
+const (
+	SyncAsk        SyncChoice = "ask"
+	SyncKeepYAML   SyncChoice = "yaml"
+	SyncTakeClient SyncChoice = "client"
+	SyncSkip       SyncChoice = "skip"
+)
+
+var syncChoices = []SyncChoice{SyncAsk, SyncKeepYAML, SyncTakeClient, SyncSkip}
+
+// SyncOptions controls `mcpyammy sync`.
+type SyncOptions struct {
+	OnConflict SyncChoice
+}
+
+func parseSyncChoice(s string) (SyncChoice, error) {
+	for _, choice := range syncChoices {
+		if string(choice) == s {
+			return choice, nil
+		}
+	}
+	return "", errorf("invalid conflict choice %q (expected ask, yaml, client or skip)", s)
+}
+
+// syncAction is what sync does with one server.
+type syncAction int
+
+const (
+	syncInSync   syncAction = iota
+	syncPush                // the YAML changed: copy it to the client
+	syncPull                // the client changed: copy it to the YAML
+	syncConflict            // both changed differently
+)
+
+// serverSync is the three-way comparison of one server. A nil value means
+// the server does not exist on that side.
+type serverSync struct {
+	client       string
+	name         string
+	action       syncAction
+	yamlServer   interface{}
+	clientServer interface{}
+	baseServer   interface{}
+	choice       SyncChoice
+	// pulled is the server as the YAML holds it after a pull, which is what
+	// the baseline must record for the next sync to find the sides equal
+	pulled interface{}
+	// kept is set when the change could not be written, so the baseline
+	// stays as it was and the change is found again by the next sync
+	kept bool
+}
+
+// clientSync is the sync plan for one client file.
+type clientSync struct {
+	name    string
+	path    string
+	err     error
+	servers []*serverSync
+}
+
+// decideSync compares both sides with the baseline of the last sync. A side
+// changed when the server was added, removed or modified there since then;
+// without a baseline every server a side has counts as changed.
+func decideSync(yamlServer, clientServer, baseServer interface{}) syncAction {
+	if yamlServer != nil && clientServer != nil && sameServerConfig(yamlServer, clientServer) {
+		return syncInSync
+	}
+	yamlChanged := !sameOrBothAbsent(yamlServer, baseServer)
+	clientChanged := !sameOrBothAbsent(clientServer, baseServer)
+	switch {
+	case yamlChanged && clientChanged:
+		return syncConflict
+	case yamlChanged:
+		return syncPush
+	case clientChanged:
+		return syncPull
+	default:
+		return syncInSync
+	}
+}
+
+func sameOrBothAbsent(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return sameServerConfig(a, b)
+}
+
+// planSync compares every client of the YAML with its file and the baseline
+// stored in state. Servers disabled by `when` on this machine are left out on
+// both sides.
+func planSync(yamlFile string, state *appState) ([]*clientSync, error) {
+	allContent, err := loadAndValidateYAML(yamlFile)
+	if err != nil {
+		return nil, err
+	}
+	effective, err := loadEffectiveYAML(yamlFile)
+	if err != nil {
+		return nil, err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errorf("error getting home directory: %v", err)
+	}
+	allClients := make(map[string]interface{})
+	for _, entry := range clientEntries(allContent) {
+		allClients[entry.name] = entry.config
+	}
+
+	var plan []*clientSync
+	for _, entry := range clientEntries(effective) {
+		config, ok := entry.config.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		cs := &clientSync{name: entry.name}
+		plan = append(plan, cs)
+
+		pathStr, ok := config["path"].(string)
+		if !ok {
+			cs.err = withCode(CodeInvalidConfig, errorf("client '%s' has no path", entry.name))
+			continue
+		}
+		if cs.path, cs.err = validateSafePath(pathStr, homeDir); cs.err != nil {
+			continue
+		}
+		clientNames, clientServers, err := readClientServersForSync(cs.path)
+		if err != nil {
+			cs.err = err
+			continue
+		}
+
+		var declared map[string]interface{}
+		if allConfig, ok := allClients[entry.name].(map[string]interface{}); ok {
+			declared = extractClientServers(allConfig)
+		}
+		yamlServers := extractClientServers(config)
+		var baseline map[string]interface{}
+		if st, ok := state.Clients[cs.path]; ok {
+			baseline = st.Servers
+		}
+
+		names := extractClientServerNames(config)
+		for _, name := range clientNames {
+			if _, inYAML := yamlServers[name]; !inYAML {
+				names = append(names, name)
+			}
+		}
+		for _, name := range sortedKeys(baseline) {
+			_, inYAML := yamlServers[name]
+			_, inClient := clientServers[name]
+			if !inYAML && !inClient {
+				names = append(names, name)
+			}
+		}
+
+		for _, name := range names {
+			if _, isDeclared := declared[name]; isDeclared {
+				if _, enabled := yamlServers[name]; !enabled {
+					continue
+				}
+			}
+			ss := &serverSync{
+				client:       entry.name,
+				name:         name,
+				yamlServer:   yamlServers[name],
+				clientServer: clientServers[name],
+				baseServer:   baseline[name],
+			}
+			if ss.yamlServer == nil && ss.clientServer == nil {
+				// deleted on both sides; only the baseline entry goes
+				continue
+			}
+			ss.action = decideSync(ss.yamlServer, ss.clientServer, ss.baseServer)
+			cs.servers = append(cs.servers, ss)
+		}
+	}
+	return plan, nil
+}
+
+// readClientServersForSync reads the servers of a client file. A missing
+// file has no servers.
+func readClientServersForSync(path string) ([]string, map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, map[string]interface{}{}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, map[string]interface{}{}, nil
+	}
+	root, err := readClientJSONObject(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	mcpServers := mcpServersObject(root)
+	if mcpServers == nil {
+		return nil, map[string]interface{}{}, nil
+	}
+	return mcpServers.keys, plainJSONValue(mcpServers).(map[string]interface{}), nil
+}
+
+// syncConflicts returns the servers of the plan that changed on both sides.
+func syncConflicts(plan []*clientSync) []*serverSync {
+	var conflicts []*serverSync
+	for _, cs := range plan {
+		for _, ss := range cs.servers {
+			if ss.action == syncConflict {
+				conflicts = append(conflicts, ss)
+			}
+		}
+	}
+	return conflicts
+}
+
+// resolvedAction is the action of a server once its conflict is resolved.
+func (ss *serverSync) resolvedAction() syncAction {
+	if ss.action != syncConflict {
+		return ss.action
+	}
+	switch ss.choice {
+	case SyncKeepYAML:
+		return syncPush
+	case SyncTakeClient:
+		return syncPull
+	default:
+		return syncConflict
+	}
+}
+
+// agreed returns the value both sides hold after the sync, which becomes
+// the new baseline. Skipped conflicts and kept changes keep their old
+// baseline.
+func (ss *serverSync) agreed() interface{} {
+	if ss.kept {
+		return ss.baseServer
+	}
+	switch ss.resolvedAction() {
+	case syncPull:
+		if ss.pulled != nil {
+			return ss.pulled
+		}
+		return ss.clientServer
+	case syncConflict:
+		return ss.baseServer
+	default:
+		return ss.yamlServer
+	}
+}
+
+// syncResult is what executing a sync plan did to one client.
+type syncResult struct {
+	client *clientSync
+	lines  []string
+	err    error
+}
+
+// clientEdit is what sync writes to one client file: the servers pushed from
+// the YAML and the servers removed because they were removed from the YAML.
+type clientEdit struct {
+	names   []string
+	servers map[string]interface{}
+	remove  []string
+}
+
+// executeSync writes the plan. Pulled servers are edited into the YAML first,
+// and only once that edit renders and is written are pushed servers written
+// to the client files, so a YAML that cannot be edited leaves every file as
+// it was. The baseline in state is updated for every client whose file was
+// written successfully.
+func executeSync(yamlFile string, plan []*clientSync, state *appState) ([]syncResult, error) {
+	yamlSnapshot, err := readSnapshot(yamlFile)
+	if err != nil {
+		return nil, errorf("Error reading YAML file: %v", err)
+	}
+	doc := newYAMLDocument(yamlSnapshot.data)
+	yamlChanged := false
+
+	results := make([]syncResult, len(plan))
+	edits := make([]clientEdit, len(plan))
+	for i, cs := range plan {
+		result, edit := &results[i], &edits[i]
+		result.client = cs
+		if cs.err != nil {
+			result.err = cs.err
+			continue
+		}
+
+		edit.servers = make(map[string]interface{})
+		var appendEntries []interface{}
+		for _, ss := range cs.servers {
+			switch ss.resolvedAction() {
+			case syncPush:
+				if ss.yamlServer == nil {
+					edit.remove = append(edit.remove, ss.name)
+					result.lines = append(result.lines, msgf("  - %s removed from client", ss.name))
+				} else {
+					edit.names = append(edit.names, ss.name)
+					edit.servers[ss.name] = ss.yamlServer
+					result.lines = append(result.lines, msgf("  → %s (YAML → client)", ss.name))
+				}
+			case syncPull:
+				if ss.clientServer == nil {
+					removed, err := doc.removeServer(cs.name, ss.name)
+					if err != nil {
+						return nil, err
+					}
+					if !removed {
+						ss.kept = true
+						result.lines = append(result.lines, msgf("  ! %s was removed from the client but comes from an included file; remove it there", ss.name))
+						continue
+					}
+					yamlChanged = true
+					result.lines = append(result.lines, msgf("  - %s removed from YAML", ss.name))
+					continue
+				}
+				entry := convertMcpServerToYaml(ss.name, ss.clientServer.(map[string]interface{}))
+				replaced, err := doc.replaceServer(cs.name, ss.name, entry)
+				if err != nil {
+					return nil, err
+				}
+				if ss.pulled, err = yamlServerValue(entry); err != nil {
+					return nil, err
+				}
+				if !replaced {
+					appendEntries = append(appendEntries, entry)
+				}
+				yamlChanged = true
+				result.lines = append(result.lines, msgf("  ← %s (client → YAML)", ss.name))
+			case syncConflict:
+				result.lines = append(result.lines, msgf("  ! %s changed on both sides (skipped)", ss.name))
+			}
+		}
+		if err := doc.appendServers(cs.name, appendEntries); err != nil {
+			return nil, err
+		}
+	}
+
+	if yamlChanged {
+		if _, err := doc.parse(); err != nil {
+			return nil, err
+		}
+		if err := replaceLockedFile(yamlFile, doc.bytes(), yamlSnapshot); err != nil {
+			if errors.Is(err, errFileChanged) {
+				return nil, errorf("%s was modified while syncing; run sync again", yamlFile)
+			}
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	for i, cs := range plan {
+		result, edit := &results[i], edits[i]
+		if result.err != nil {
+			continue
+		}
+		if len(edit.names) > 0 || len(edit.remove) > 0 {
+			if _, err := editClientFile(cs.path, edit.names, edit.servers, edit.remove, ApplyOptions{}); err != nil {
+				result.err = err
+				continue
+			}
+		}
+
+		baseline := make(map[string]interface{})
+		for _, ss := range cs.servers {
+			if value := ss.agreed(); value != nil {
+				baseline[ss.name] = normalizeJSONValue(value)
+			}
+		}
+		st := state.client(cs.path)
+		st.Servers = baseline
+		st.LastSynced = now
+	}
+	if err := state.save(); err != nil {
+		return results, errorf("could not record sync state: %v", err)
+	}
+	return results, nil
+}
+
+// yamlServerValue returns entry as it reads back from the YAML once written,
+// without its name, so that the baseline matches the YAML side exactly.
+func yamlServerValue(entry OrderedServer) (interface{}, error) {
+	data, err := yaml.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	var value map[string]interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	delete(value, "name")
+	return value, nil
+}
+
+// promptSyncConflict asks how to resolve one conflict. Tests replace it.
+var promptSyncConflict = func(in *bufio.Reader, out io.Writer, ss *serverSync) SyncChoice {
+	fmt.Fprintln(out, "\n"+msgf("%s / %s changed in both the YAML and the client since the last sync", ss.client, ss.name))
+	fmt.Fprintf(out, "%s\n%s\n", msgf("YAML:"), describeSyncValue(ss.yamlServer))
+	fmt.Fprintf(out, "%s\n%s\n", msgf("Client:"), describeSyncValue(ss.clientServer))
+	for {
+		fmt.Fprint(out, msgf("Keep [y]aml, take [c]lient or [s]kip? "))
+		line, err := in.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yaml":
+			return SyncKeepYAML
+		case "c", "client":
+			return SyncTakeClient
+		case "s", "skip":
+			return SyncSkip
+		}
+		if err != nil {
+			fmt.Fprintln(out)
+			return SyncSkip
+		}
+	}
+}
+
+func describeSyncValue(v interface{}) string {
+	if v == nil {
+		return msgf("  (removed)")
+	}
+	data, _ := json.MarshalIndent(v, "  ", "  ")
+	return "  " + string(data)
+}
+
+func syncConfig(yamlFile string, options SyncOptions) error {
+	state, err := loadState()
+	if err != nil {
+		return err
+	}
+	plan, err := planSync(yamlFile, state)
+	if err != nil {
+		return err
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	for _, ss := range syncConflicts(plan) {
+		ss.choice = options.OnConflict
+		if ss.choice == "" || ss.choice == SyncAsk {
+			ss.choice = promptSyncConflict(in, os.Stdout, ss)
+		}
+	}
+
+	results, err := executeSync(yamlFile, plan, state)
+	if err != nil && results == nil {
+		return err
+	}
+	failedCount := 0
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Printf("✗ %s: %v\n", result.client.name, result.err)
+			failedCount++
+			continue
+		}
+		if len(result.lines) == 0 {
+			fmt.Println(msgf("= %s is in sync", result.client.name))
+			continue
+		}
+		fmt.Printf("✓ %s: %s\n", result.client.name, result.client.path)
+		for _, line := range result.lines {
+			fmt.Println(line)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, msgf("Warning: %v", err))
+	}
+	if failedCount > 0 {
+		return reportedExit(ExitPartialFailure, "%d client(s) could not be synced", failedCount)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDecideSync ベースラインとの三方向比較テスト
+func TestDecideSync(t *testing.T) {
+	a := map[string]interface{}{"command": "a"}
+	b := map[string]interface{}{"command": "b"}
+	c := map[string]interface{}{"command": "c"}
+
+	tests := []struct {
+		name               string
+		yaml, client, base interface{}
+		want               syncAction
+	}{
+		{"both equal", a, a, nil, syncInSync},
+		{"only in YAML, never synced", a, nil, nil, syncPush},
+		{"only in client, never synced", nil, a, nil, syncPull},
+		{"different, never synced", a, b, nil, syncConflict},
+		{"YAML modified", b, a, a, syncPush},
+		{"client modified", a, b, a, syncPull},
+		{"removed from YAML", nil, a, a, syncPush},
+		{"removed from client", a, nil, a, syncPull},
+		{"modified on both sides", b, c, a, syncConflict},
+		{"removed from YAML, modified in client", nil, b, a, syncConflict},
+		{"same change on both sides", b, b, a, syncInSync},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, decideSync(tt.yaml, tt.client, tt.base))
+		})
+	}
+}
+
+func setupSyncTest(t *testing.T) (string, string, string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+
+	yamlFile := filepath.Join(home, "servers.yaml")
+	yamlContent := `# my servers
+clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: a # shared
+      command: a
+    - name: b
+      command: b
+`
+	require.NoError(t, os.WriteFile(yamlFile, []byte(yamlContent), 0600))
+	clientFile := filepath.Join(home, ".claude.json")
+	clientJSON := `{
+  "theme": "dark",
+  "mcpServers": {
+    "a": {"command": "a"},
+    "c": {"command": "c"}
+  }
+}
+`
+	require.NoError(t, os.WriteFile(clientFile, []byte(clientJSON), 0600))
+	return home, yamlFile, clientFile
+}
+
+func runSync(t *testing.T, yamlFile string, choice SyncChoice) []syncResult {
+	t.Helper()
+	state, err := loadState()
+	require.NoError(t, err)
+	plan, err := planSync(yamlFile, state)
+	require.NoError(t, err)
+	for _, ss := range syncConflicts(plan) {
+		ss.choice = choice
+	}
+	results, err := executeSync(yamlFile, plan, state)
+	require.NoError(t, err)
+	return results
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// TestSync_TwoWay 双方向の同期とベースラインによる削除の検出テスト
+func TestSync_TwoWay(t *testing.T) {
+	_, yamlFile, clientFile := setupSyncTest(t)
+
+	// first sync: b goes to the client, c (added with `claude mcp add`) to the YAML
+	results := runSync(t, yamlFile, SyncSkip)
+	require.Len(t, results, 1)
+	assert.Equal(t, []string{"  → b (YAML → client)", "  ← c (client → YAML)"}, results[0].lines)
+	assert.Equal(t, `# my servers
+clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: a # shared
+      command: a
+    - name: b
+      command: b
+    - name: c
+      command: c
+`, readFile(t, yamlFile))
+	assert.Contains(t, readFile(t, clientFile), `"b": {`)
+	assert.Contains(t, readFile(t, clientFile), `"theme": "dark"`)
+
+	// nothing left to do
+	results = runSync(t, yamlFile, SyncSkip)
+	assert.Empty(t, results[0].lines)
+
+	// c removed from the YAML, b changed in the client
+	yamlContent := strings.Replace(readFile(t, yamlFile), "    - name: c\n      command: c\n", "", 1)
+	require.NoError(t, os.WriteFile(yamlFile, []byte(yamlContent), 0600))
+	clientJSON := strings.Replace(readFile(t, clientFile), `"command": "b"`, `"command": "b2"`, 1)
+	require.NoError(t, os.WriteFile(clientFile, []byte(clientJSON), 0600))
+
+	results = runSync(t, yamlFile, SyncSkip)
+	assert.Equal(t, []string{"  ← b (client → YAML)", "  - c removed from client"}, results[0].lines)
+	assert.Contains(t, readFile(t, yamlFile), "command: b2")
+	assert.NotContains(t, readFile(t, clientFile), `"c"`)
+	assert.Contains(t, readFile(t, yamlFile), "- name: a # shared")
+}
+
+// TestSync_NonStringValues 文字列以外のargsとenvの値が2回の同期で変わらないテスト
+func TestSync_NonStringValues(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	yamlFile := filepath.Join(home, "servers.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte("clients:\n  claude:\n    path: .claude.json\n    servers: []\n"), 0600))
+	clientFile := filepath.Join(home, ".claude.json")
+	clientJSON := `{"mcpServers": {"web": {"command": "npx", "args": ["--port", 8080], "env": {"DEBUG": true}}}}`
+	require.NoError(t, os.WriteFile(clientFile, []byte(clientJSON), 0600))
+
+	results := runSync(t, yamlFile, SyncSkip)
+	assert.Equal(t, []string{"  ← web (client → YAML)"}, results[0].lines)
+	assert.Equal(t, `clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: web
+      command: npx
+      args:
+      - --port
+      - 8080
+      env:
+        DEBUG: true
+`, readFile(t, yamlFile))
+
+	state, err := loadState()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"command": "npx",
+		"args":    []interface{}{"--port", float64(8080)},
+		"env":     map[string]interface{}{"DEBUG": true},
+	}, state.client(clientFile).Servers["web"], "ベースラインにはYAMLに書いた値を記録するべき")
+
+	results = runSync(t, yamlFile, SyncSkip)
+	assert.Empty(t, results[0].lines, "取り込んだ値はそのままなので2回目の同期では何も変わらないべき")
+	assert.Equal(t, clientJSON, readFile(t, clientFile), "クライアントの値は書き戻されないべき")
+}
+
+// TestSync_Conflicts 両側で変更されたサーバーの解決テスト
+func TestSync_Conflicts(t *testing.T) {
+	_, yamlFile, clientFile := setupSyncTest(t)
+	runSync(t, yamlFile, SyncSkip)
+
+	yamlContent := strings.Replace(readFile(t, yamlFile), "      command: a\n", "      command: from-yaml\n", 1)
+	require.NoError(t, os.WriteFile(yamlFile, []byte(yamlContent), 0600))
+	clientJSON := strings.Replace(readFile(t, clientFile), `"command": "a"`, `"command": "from-client"`, 1)
+	require.NoError(t, os.WriteFile(clientFile, []byte(clientJSON), 0600))
+
+	// skipped conflicts stay conflicts
+	results := runSync(t, yamlFile, SyncSkip)
+	assert.Equal(t, []string{"  ! a changed on both sides (skipped)"}, results[0].lines)
+	results = runSync(t, yamlFile, SyncSkip)
+	assert.Equal(t, []string{"  ! a changed on both sides (skipped)"}, results[0].lines)
+
+	results = runSync(t, yamlFile, SyncTakeClient)
+	assert.Equal(t, []string{"  ← a (client → YAML)"}, results[0].lines)
+	assert.Contains(t, readFile(t, yamlFile), "command: from-client")
+
+	results = runSync(t, yamlFile, SyncSkip)
+	assert.Empty(t, results[0].lines)
+}
+
+// TestPromptSyncConflict 競合時の入力テスト
+func TestPromptSyncConflict(t *testing.T) {
+	ss := &serverSync{client: "claude", name: "a", yamlServer: map[string]interface{}{"command": "a"}}
+
+	in := bufio.NewReader(strings.NewReader("what\nc\n"))
+	assert.Equal(t, SyncTakeClient, promptSyncConflict(in, io.Discard, ss))
+
+	in = bufio.NewReader(strings.NewReader(""))
+	assert.Equal(t, SyncSkip, promptSyncConflict(in, io.Discard, ss))
+}
+
+// TestSync_YAMLEditFails YAMLを編集できないときはクライアントも書き換えないテスト
+func TestSync_YAMLEditFails(t *testing.T) {
+	_, yamlFile, clientFile := setupSyncTest(t)
+	yamlContent := "{clients: {claude: {path: .claude.json, servers: [{name: a, command: a}, {name: b, command: b}]}}}\n"
+	require.NoError(t, os.WriteFile(yamlFile, []byte(yamlContent), 0600))
+	clientJSON := readFile(t, clientFile)
+
+	state, err := loadState()
+	require.NoError(t, err)
+	plan, err := planSync(yamlFile, state)
+	require.NoError(t, err)
+	_, err = executeSync(yamlFile, plan, state)
+	assert.EqualError(t, err, "'clients' in flow style cannot be edited")
+
+	assert.Equal(t, yamlContent, readFile(t, yamlFile))
+	assert.Equal(t, clientJSON, readFile(t, clientFile), "YAMLの編集に失敗したらbをクライアントに書き込まない")
+	state, err = loadState()
+	require.NoError(t, err)
+	assert.Empty(t, state.Clients)
+}
+
+// TestSyncConfig_PartialFailure 同期できないクライアントがあれば終了コード2を返すテスト
+func TestSyncConfig_PartialFailure(t *testing.T) {
+	_, yamlFile, clientFile := setupSyncTest(t)
+	require.NoError(t, os.WriteFile(clientFile, []byte(`{"mcpServers": `), 0600))
+
+	err := syncConfig(yamlFile, SyncOptions{OnConflict: SyncSkip})
+	assert.Equal(t, ExitPartialFailure, exitCode(err))
+	assert.EqualError(t, err, "1 client(s) could not be synced")
+}
@@ -0,0 +1,93 @@
+// Command fakemcp is a minimal MCP server for the tests of `mcpyammy test`.
+// It speaks newline-delimited JSON-RPC on stdin and stdout.
+//
+// FAKEMCP_MODE selects a misbehaviour: "crash" exits before answering,
+// "hang" never answers initialize, and "ping" sends a ping request to the
+// client before answering initialize. FAKEMCP_TOOLS sets how many tools
+// tools/list returns, two per page.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const pageSize = 2
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   interface{}     `json:"error,omitempty"`
+}
+
+func main() {
+	mode := os.Getenv("FAKEMCP_MODE")
+	fmt.Fprintln(os.Stderr, "fakemcp starting", os.Args[1:])
+	if mode == "crash" {
+		fmt.Fprintln(os.Stderr, "fatal: missing API key")
+		os.Exit(3)
+	}
+	toolCount := 3
+	if n, err := strconv.Atoi(os.Getenv("FAKEMCP_TOOLS")); err == nil {
+		toolCount = n
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	in := bufio.NewScanner(os.Stdin)
+	for in.Scan() {
+		var msg message
+		if err := json.Unmarshal(in.Bytes(), &msg); err != nil {
+			fmt.Fprintln(os.Stderr, "bad message:", err)
+			os.Exit(1)
+		}
+		if msg.Method == "" || len(msg.ID) == 0 {
+			// responses and notifications need no answer
+			continue
+		}
+		reply := message{JSONRPC: "2.0", ID: msg.ID}
+		switch msg.Method {
+		case "initialize":
+			if mode == "hang" {
+				continue
+			}
+			if mode == "ping" {
+				out.Encode(message{JSONRPC: "2.0", ID: json.RawMessage(`"srv-1"`), Method: "ping"})
+			}
+			out.Encode(message{JSONRPC: "2.0", Method: "notifications/message", Params: json.RawMessage(`{"level":"info","data":"hello"}`)})
+			reply.Result = map[string]interface{}{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+				"serverInfo":      map[string]interface{}{"name": "fakemcp", "version": "1.2.3"},
+			}
+		case "tools/list":
+			var params struct {
+				Cursor string `json:"cursor"`
+			}
+			json.Unmarshal(msg.Params, &params)
+			start, _ := strconv.Atoi(params.Cursor)
+			end := min(start+pageSize, toolCount)
+			tools := []interface{}{}
+			for i := start; i < end; i++ {
+				tools = append(tools, map[string]interface{}{
+					"name":        fmt.Sprintf("tool%d", i+1),
+					"description": "does something",
+					"inputSchema": map[string]interface{}{"type": "object"},
+				})
+			}
+			result := map[string]interface{}{"tools": tools}
+			if end < toolCount {
+				result["nextCursor"] = strconv.Itoa(end)
+			}
+			reply.Result = result
+		default:
+			reply.Error = map[string]interface{}{"code": -32601, "message": "method not found"}
+		}
+		out.Encode(reply)
+	}
+}
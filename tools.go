@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultToolTokenBudget is the tool definition size per client above which
+// `tools` warns. Every tool definition is sent with each request, so large
+// inventories slow sessions down and crowd out the conversation.
+const DefaultToolTokenBudget = 25000
+
+// ToolsOptions selects the servers `tools` lists and the budget to check.
+type ToolsOptions struct {
+	Client  string
+	Server  string
+	Budget  int
+	Timeout time.Duration
+}
+
+// estimateTokens approximates the tokens a text costs as one token per four
+// characters.
+func estimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
+
+// toolTokens estimates the size of a tool definition as the model sees it:
+// its name, description and input schema.
+func toolTokens(tool mcpTool) int {
+	return estimateTokens(tool.Name + tool.Description + string(tool.InputSchema))
+}
+
+// clientTools is the tool inventory of one client.
+type clientTools struct {
+	name       string
+	servers    []*serverTest
+	tools      int
+	tokens     int
+	failed     int
+	duplicates []toolDuplicate
+}
+
+// toolDuplicate is a tool name offered by more than one server of a client.
+// Clients either reject or silently shadow such tools.
+type toolDuplicate struct {
+	tool    string
+	servers []string
+}
+
+// overBudget reports whether the client's tools exceed budget tokens.
+func (ct *clientTools) overBudget(budget int) bool {
+	return budget > 0 && ct.tokens > budget
+}
+
+// buildToolInventory groups probed servers by client and totals their tools.
+func buildToolInventory(tests []*serverTest) []*clientTools {
+	var inventory []*clientTools
+	var current *clientTools
+	for _, st := range tests {
+		if current == nil || current.name != st.client {
+			current = &clientTools{name: st.client}
+			inventory = append(inventory, current)
+		}
+		current.servers = append(current.servers, st)
+		if st.probe.err != nil {
+			current.failed++
+			continue
+		}
+		for _, tool := range st.probe.tools {
+			current.tools++
+			current.tokens += toolTokens(tool)
+		}
+	}
+	for _, ct := range inventory {
+		ct.duplicates = findDuplicateTools(ct.servers)
+	}
+	return inventory
+}
+
+// findDuplicateTools lists tool names that more than one server offers, in
+// the order they first appear.
+func findDuplicateTools(servers []*serverTest) []toolDuplicate {
+	owners := make(map[string][]string)
+	var order []string
+	for _, st := range servers {
+		if st.probe.err != nil {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, tool := range st.probe.tools {
+			if seen[tool.Name] {
+				continue
+			}
+			seen[tool.Name] = true
+			if _, ok := owners[tool.Name]; !ok {
+				order = append(order, tool.Name)
+			}
+			owners[tool.Name] = append(owners[tool.Name], st.name)
+		}
+	}
+	var duplicates []toolDuplicate
+	for _, name := range order {
+		if len(owners[name]) > 1 {
+			duplicates = append(duplicates, toolDuplicate{tool: name, servers: owners[name]})
+		}
+	}
+	return duplicates
+}
+
+// lines describes the inventory of one client for the CLI.
+func (ct *clientTools) lines(budget int) []string {
+	lines := []string{ct.name}
+	for _, st := range ct.servers {
+		if st.probe.err != nil {
+			lines = append(lines, fmt.Sprintf("  ✗ %s: %v", st.name, st.probe.err))
+			continue
+		}
+		serverTokens := 0
+		for _, tool := range st.probe.tools {
+			serverTokens += toolTokens(tool)
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %d tool(s), ~%d tokens", st.name, len(st.probe.tools), serverTokens))
+		for _, tool := range st.probe.tools {
+			lines = append(lines, fmt.Sprintf("    %-32s ~%d", tool.Name, toolTokens(tool)))
+		}
+	}
+	for _, dup := range ct.duplicates {
+		lines = append(lines, fmt.Sprintf("  ! duplicate tool '%s' in %s", dup.tool, strings.Join(dup.servers, ", ")))
+	}
+	lines = append(lines, fmt.Sprintf("  total: %d tool(s), ~%d tokens", ct.tools, ct.tokens))
+	if ct.overBudget(budget) {
+		lines = append(lines, fmt.Sprintf("  ⚠ exceeds the budget of %d tokens; disable servers this client does not need", budget))
+	}
+	return lines
+}
+
+// printToolInventory writes the inventory and returns how many servers could
+// not be listed and how many clients are over budget.
+func printToolInventory(out io.Writer, inventory []*clientTools, budget int) (failed, overBudget int) {
+	for i, ct := range inventory {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		for _, line := range ct.lines(budget) {
+			fmt.Fprintln(out, line)
+		}
+		failed += ct.failed
+		if ct.overBudget(budget) {
+			overBudget++
+		}
+	}
+	return failed, overBudget
+}
+
+func listConfigTools(yamlFile string, options ToolsOptions) error {
+	tests, err := planServerTests(yamlFile, TestOptions{Client: options.Client, Server: options.Server})
+	if err != nil {
+		return err
+	}
+	if len(tests) == 0 {
+		fmt.Println(msgf("No servers to list"))
+		return nil
+	}
+
+	runServerTests(context.Background(), tests, options.Timeout)
+	inventory := buildToolInventory(tests)
+	failed, overBudget := printToolInventory(os.Stdout, inventory, options.Budget)
+	if overBudget > 0 {
+		fmt.Println("\n" + msgf("%d client(s) exceed the tool budget of %d tokens", overBudget, options.Budget))
+	}
+	if failed > 0 {
+		fmt.Println("\n" + msgf("%d server(s) could not be listed", failed))
+		return reportedExit(ExitError, "%d server(s) could not be listed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func probedServer(client, name string, tools ...mcpTool) *serverTest {
+	return &serverTest{client: client, name: name, probe: &serverProbe{transport: TransportStdio, tools: tools}}
+}
+
+// TestEstimateTokens 4文字で1トークンの見積もりテスト
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, estimateTokens(""))
+	assert.Equal(t, 1, estimateTokens("abc"))
+	assert.Equal(t, 2, estimateTokens("abcde"))
+	assert.Equal(t, 1, estimateTokens("日本語"), "characters, not bytes")
+	assert.Equal(t, 6, toolTokens(mcpTool{Name: "read", Description: "Read a file", InputSchema: json.RawMessage(`{"a":1}`)}))
+}
+
+// TestBuildToolInventory クライアントごとの集計と重複ツールの検出テスト
+func TestBuildToolInventory(t *testing.T) {
+	search := mcpTool{Name: "search", Description: "Search the web for a query"}
+	fetch := mcpTool{Name: "fetch", Description: "Fetch a URL"}
+	failed := probedServer("claude", "broken")
+	failed.probe.err = errors.New("server exited: exit status 1")
+
+	inventory := buildToolInventory([]*serverTest{
+		probedServer("claude", "brave", search),
+		probedServer("claude", "exa", search, fetch),
+		probedServer("claude", "web", fetch),
+		failed,
+		probedServer("cursor", "brave", search),
+	})
+	require.Len(t, inventory, 2)
+
+	claude := inventory[0]
+	assert.Equal(t, 4, claude.tools)
+	assert.Equal(t, 2*toolTokens(search)+2*toolTokens(fetch), claude.tokens)
+	assert.Equal(t, 1, claude.failed)
+	assert.Equal(t, []toolDuplicate{
+		{tool: "search", servers: []string{"brave", "exa"}},
+		{tool: "fetch", servers: []string{"exa", "web"}},
+	}, claude.duplicates)
+
+	cursor := inventory[1]
+	assert.Equal(t, 1, cursor.tools)
+	assert.Empty(t, cursor.duplicates)
+}
+
+// TestPrintToolInventory 一覧表示と予算超過の警告テスト
+func TestPrintToolInventory(t *testing.T) {
+	big := mcpTool{Name: "query", Description: string(bytes.Repeat([]byte("x"), 400))}
+	inventory := buildToolInventory([]*serverTest{
+		probedServer("claude", "db", big, mcpTool{Name: "list"}),
+		probedServer("cursor", "db", mcpTool{Name: "list"}),
+	})
+
+	var out bytes.Buffer
+	failed, over := printToolInventory(&out, inventory, 50)
+	assert.Equal(t, 0, failed)
+	assert.Equal(t, 1, over)
+	assert.Equal(t, `claude
+  db: 2 tool(s), ~103 tokens
+    query                            ~102
+    list                             ~1
+  total: 2 tool(s), ~103 tokens
+  ⚠ exceeds the budget of 50 tokens; disable servers this client does not need
+
+cursor
+  db: 1 tool(s), ~1 tokens
+    list                             ~1
+  total: 1 tool(s), ~1 tokens
+`, out.String())
+
+	out.Reset()
+	_, over = printToolInventory(&out, inventory, 0)
+	assert.Equal(t, 0, over, "a budget of 0 disables the check")
+	assert.NotContains(t, out.String(), "⚠")
+}
@@ -4,14 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/goccy/go-yaml"
 )
 
 const (
@@ -19,10 +19,13 @@ const (
 	DefaultViewportHeight     = 20
 	ViewportHorizontalPadding = 2
 	ViewportVerticalPadding   = 6
-	TUIFileMode               = 0600
 	TUIDirectoryMode          = 0755
 )
 
+// noApplyChanges is the apply preview when every client is up to date; the
+// confirm screen looks for it to offer a way back instead of Yes/No.
+const noApplyChanges = "No changes detected. All configurations are up to date."
+
 const defaultYAML = `clients:
   amazonq:
     path: .aws/amazonq/mcp.json
@@ -43,20 +46,36 @@ const (
 	stateApply
 	stateConfirm
 	stateResult
+	stateSelectConfig
+	stateConfigInput
+	stateConflict
+	stateStatus
+	stateSelectClients
 )
 
 type model struct {
 	state       state
 	list        list.Model
+	configList  list.Model
+	pathInput   textinput.Model
 	viewport    viewport.Model
+	configFlag  string
 	yamlFile    string
 	yamlContent string
+	importPlan  *importPlan
+	conflictIdx int
+	choiceIdx   int
 	action      string
 	result      string
 	width       int
 	height      int
 	err         error
 	yesNoIndex  int
+
+	// the client checklist shown before the apply and import previews
+	clientNames   []string
+	clientChecked []bool
+	clientCursor  int
 }
 
 var (
@@ -81,29 +100,77 @@ var (
 			Foreground(lipgloss.Color("196"))
 )
 
-func initialModel() model {
-	items := []list.Item{
-		item{title: "Import", desc: "Import existing mcp.json files to YAML"},
-		item{title: "Apply", desc: "Apply YAML configuration to mcp.json files"},
-		item{title: "Quit", desc: "Exit the program"},
-	}
-
-	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
-	l.Title = "MCP Setup"
+func initialModel(configFlag string) model {
+	l := list.New(nil, list.NewDefaultDelegate(), 0, 0)
+	l.Title = msgf("MCP Setup")
 	l.SetShowStatusBar(false)
 
+	cl := list.New(nil, list.NewDefaultDelegate(), 0, 0)
+	cl.Title = msgf("Select configuration file")
+	cl.SetShowStatusBar(false)
+
+	ti := textinput.New()
+	ti.Placeholder = "~/path/to/servers.yaml"
+
 	vp := viewport.New(DefaultViewportWidth, DefaultViewportHeight)
 
-	return model{
-		state:    stateMenu,
-		list:     l,
-		viewport: vp,
-		yamlFile: "servers.yaml",
+	m := model{
+		state:      stateMenu,
+		list:       l,
+		configList: cl,
+		pathInput:  ti,
+		viewport:   vp,
+		configFlag: configFlag,
+	}
+
+	yamlFile, found := resolveConfigPath(configFlag)
+	if found {
+		m.setYAMLFile(yamlFile)
+	} else {
+		m.showConfigSelection()
+	}
+	return m
+}
+
+func menuItems(yamlFile string) []list.Item {
+	return []list.Item{
+		item{action: CommandImport, title: msgf("Import"), desc: msgf("Import existing mcp.json files to YAML")},
+		item{action: CommandApply, title: msgf("Apply"), desc: msgf("Apply YAML configuration to mcp.json files")},
+		item{action: CommandStatus, title: msgf("Status"), desc: msgf("Show which clients differ from the YAML")},
+		item{action: CommandConfig, title: msgf("Config"), desc: msgf("Select or create the YAML file (current: %s)", yamlFile)},
+		item{action: menuQuit, title: msgf("Quit"), desc: msgf("Exit the program")},
 	}
 }
 
+func (m *model) setYAMLFile(yamlFile string) {
+	m.yamlFile = yamlFile
+	m.list.SetItems(menuItems(yamlFile))
+	m.state = stateMenu
+}
+
+func (m *model) showConfigSelection() {
+	var items []list.Item
+	for _, candidate := range configCandidates(m.configFlag) {
+		desc := msgf("%s (create new file)", candidate.source)
+		if candidate.exists {
+			desc = candidate.source
+		}
+		items = append(items, item{title: candidate.path, desc: desc, path: candidate.path})
+	}
+	items = append(items, item{title: msgf("Other path..."), desc: msgf("Enter a path to an existing or new YAML file")})
+	m.configList.SetItems(items)
+	m.configList.Select(0)
+	m.state = stateSelectConfig
+}
+
+// menuQuit is the action of the Quit menu item.
+const menuQuit = "quit"
+
 type item struct {
 	title, desc string
+	path        string
+	// action is the command a menu item runs; titles are translated
+	action string
 }
 
 func (i item) Title() string       { return i.title }
@@ -111,13 +178,17 @@ func (i item) Description() string { return i.desc }
 func (i item) FilterValue() string { return i.title }
 
 func (m model) Init() tea.Cmd {
-	if _, err := os.Stat(m.yamlFile); os.IsNotExist(err) {
-		_ = os.WriteFile(m.yamlFile, []byte(defaultYAML), TUIFileMode)
-	}
 	return nil
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.state == stateConfigInput {
+		return m.updateConfigInput(keyMsg)
+	}
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.state == stateSelectClients {
+		return m.updateClientSelection(keyMsg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -125,6 +196,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch m.state {
 			case stateMenu:
 				return m, tea.Quit
+			case stateSelectConfig:
+				if m.configList.FilterState() == list.Filtering {
+					break
+				}
+				if m.yamlFile == "" {
+					return m, tea.Quit
+				}
+				m.state = stateMenu
+				return m, nil
 			default:
 				m.state = stateMenu
 				return m, nil
@@ -133,32 +213,57 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch m.state {
 			case stateConfirm:
 				m.yesNoIndex = 0 // Yes
+			case stateConflict:
+				if m.choiceIdx > 0 {
+					m.choiceIdx--
+				}
 			}
 		case "right", "l":
 			switch m.state {
 			case stateConfirm:
 				m.yesNoIndex = 1 // No
+			case stateConflict:
+				if m.choiceIdx < len(conflictChoices)-1 {
+					m.choiceIdx++
+				}
 			}
 		case "enter":
 			switch m.state {
 			case stateMenu:
-				selected := m.list.SelectedItem().(item).title
+				selected := m.list.SelectedItem().(item).action
 				switch selected {
-				case "Import":
+				case CommandImport:
 					m.action = CommandImport
-					m.state = stateImport
 					m.yesNoIndex = 0
-					return m, m.runImport()
-				case "Apply":
+					return m, m.loadClientNames()
+				case CommandApply:
 					m.action = CommandApply
-					m.state = stateApply
 					m.yesNoIndex = 0
-					return m, m.runApplyPreview()
-				case "Quit":
+					return m, m.loadClientNames()
+				case CommandStatus:
+					return m, m.runStatus()
+				case CommandConfig:
+					m.showConfigSelection()
+					return m, nil
+				case menuQuit:
 					return m, tea.Quit
 				}
+			case stateSelectConfig:
+				if m.configList.FilterState() == list.Filtering {
+					break
+				}
+				selected, ok := m.configList.SelectedItem().(item)
+				if !ok {
+					return m, nil
+				}
+				if selected.path == "" {
+					m.pathInput.SetValue("")
+					m.state = stateConfigInput
+					return m, m.pathInput.Focus()
+				}
+				return m, selectConfig(selected.path)
 			case stateConfirm:
-				if m.action == CommandApply && strings.Contains(m.viewport.View(), "No changes detected") {
+				if m.action == CommandApply && strings.Contains(m.viewport.View(), msgf(noApplyChanges)) {
 					m.state = stateMenu
 					return m, nil
 				}
@@ -167,28 +272,69 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				} else {
 					m.state = stateMenu
 				}
-			case stateResult:
+			case stateConflict:
+				m.importPlan.conflicts[m.conflictIdx].choice = conflictChoices[m.choiceIdx]
+				m.conflictIdx++
+				m.choiceIdx = 0
+				if m.conflictIdx < len(m.importPlan.conflicts) {
+					return m, nil
+				}
+				return m, renderImport(m.importPlan)
+			case stateResult, stateStatus:
 				m.state = stateMenu
 			}
+		case "r":
+			if m.state == stateStatus {
+				return m, m.runStatus()
+			}
 		}
 
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 		m.list.SetSize(msg.Width, msg.Height-2)
+		m.configList.SetSize(msg.Width, msg.Height-2)
 		m.viewport.Width = msg.Width - ViewportHorizontalPadding
 		m.viewport.Height = msg.Height - ViewportVerticalPadding
 
+	case clientNamesResult:
+		m.clientNames = msg
+		m.clientChecked = make([]bool, len(msg))
+		for i := range m.clientChecked {
+			m.clientChecked[i] = true
+		}
+		m.clientCursor = 0
+		m.state = stateSelectClients
+
+	case importPlanResult:
+		m.importPlan = msg.plan
+		if len(msg.plan.conflicts) > 0 {
+			m.conflictIdx = 0
+			m.choiceIdx = 0
+			m.state = stateConflict
+			return m, nil
+		}
+		return m, renderImport(msg.plan)
+
 	case importResult:
-		m.yamlContent = string(msg)
+		m.yamlContent = msg.content
 		m.state = stateConfirm
-		m.viewport.SetContent(m.yamlContent)
+		m.viewport.SetContent(msg.summary + "\n" + msg.content)
+		m.viewport.GotoTop()
 
 	case applyPreviewResult:
 		m.result = string(msg)
 		m.state = stateConfirm
 		m.viewport.SetContent(m.result)
 
+	case statusResult:
+		m.state = stateStatus
+		m.viewport.SetContent(string(msg))
+
+	case configSelected:
+		m.setYAMLFile(string(msg))
+		return m, nil
+
 	case actionComplete:
 		m.result = string(msg)
 		m.state = stateResult
@@ -197,39 +343,134 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case errMsg:
 		m.err = msg.err
 		m.state = stateResult
-		m.result = errorStyle.Render("Error: " + msg.err.Error())
+		m.result = errorStyle.Render(msgf("Error: %v", msg.err))
 		m.viewport.SetContent(m.result)
 	}
 
 	switch m.state {
 	case stateMenu:
 		m.list, _ = m.list.Update(msg)
-	case stateImport, stateApply, stateConfirm, stateResult:
+	case stateSelectConfig:
+		var cmd tea.Cmd
+		m.configList, cmd = m.configList.Update(msg)
+		return m, cmd
+	case stateImport, stateApply, stateConfirm, stateResult, stateStatus:
 		m.viewport, _ = m.viewport.Update(msg)
 	}
 	return m, nil
 }
 
+// updateConfigInput routes keys to the path input so that typing "q" or
+// "h" does not trigger menu shortcuts.
+func (m model) updateConfigInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.pathInput.Blur()
+		m.state = stateSelectConfig
+		return m, nil
+	case "enter":
+		path := expandHomePath(strings.TrimSpace(m.pathInput.Value()))
+		if path == "" {
+			return m, nil
+		}
+		m.pathInput.Blur()
+		return m, selectConfig(path)
+	}
+	var cmd tea.Cmd
+	m.pathInput, cmd = m.pathInput.Update(msg)
+	return m, cmd
+}
+
+// updateClientSelection handles the client checklist. Every client starts
+// checked, so Enter alone keeps the previous behavior of using them all.
+func (m model) updateClientSelection(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "q", "esc":
+		m.state = stateMenu
+	case "up", "k":
+		if m.clientCursor > 0 {
+			m.clientCursor--
+		}
+	case "down", "j":
+		if m.clientCursor < len(m.clientNames)-1 {
+			m.clientCursor++
+		}
+	case " ", "x":
+		if m.clientCursor < len(m.clientChecked) {
+			m.clientChecked[m.clientCursor] = !m.clientChecked[m.clientCursor]
+		}
+	case "a":
+		all := !slices.Contains(m.clientChecked, false)
+		for i := range m.clientChecked {
+			m.clientChecked[i] = !all
+		}
+	case "enter":
+		if !slices.Contains(m.clientChecked, true) {
+			return m, nil
+		}
+		if m.action == CommandImport {
+			m.state = stateImport
+			return m, m.runImport()
+		}
+		m.state = stateApply
+		return m, m.runApplyPreview()
+	}
+	return m, nil
+}
+
+// selection returns the clients checked in the checklist. When all of them
+// are checked nothing is filtered.
+func (m model) selection() Selection {
+	var selection Selection
+	if !slices.Contains(m.clientChecked, false) {
+		return selection
+	}
+	for i, name := range m.clientNames {
+		if m.clientChecked[i] {
+			selection.Clients = append(selection.Clients, name)
+		}
+	}
+	return selection
+}
+
 func (m model) View() string {
 	switch m.state {
 	case stateMenu:
 		return m.list.View()
 
+	case stateSelectConfig:
+		return m.configList.View()
+
+	case stateConfigInput:
+		return titleStyle.Render(msgf("Configuration file path")) + "\n\n" +
+			m.pathInput.View() + "\n\n" +
+			infoStyle.Render(msgf("Enter to use (a new file is created if missing), Esc to go back"))
+
+	case stateSelectClients:
+		return m.clientSelectionView()
+
 	case stateImport:
-		return titleStyle.Render("Importing...") + "\n\n" +
-			infoStyle.Render("Reading mcp.json files from paths in servers.yaml...")
+		return titleStyle.Render(msgf("Importing...")) + "\n\n" +
+			infoStyle.Render(msgf("Reading mcp.json files from paths in %s...", m.yamlFile))
 
 	case stateApply:
-		return titleStyle.Render("Calculating changes...") + "\n\n" +
-			infoStyle.Render("Analyzing differences between YAML and current configurations...")
+		return titleStyle.Render(msgf("Calculating changes...")) + "\n\n" +
+			infoStyle.Render(msgf("Analyzing differences between YAML and current configurations..."))
+
+	case stateConflict:
+		return m.conflictView()
 
 	case stateConfirm:
 		title := ""
 		prompt := ""
 		if m.action == CommandImport {
-			title = "Import Preview"
-			yesButton := "Yes"
-			noButton := "No"
+			title = msgf("Import Preview")
+			yesButton := msgf("Yes")
+			noButton := msgf("No")
 			if m.yesNoIndex == 0 {
 				yesButton = successStyle.Render("▶ " + yesButton)
 				noButton = infoStyle.Render(noButton)
@@ -238,15 +479,15 @@ func (m model) View() string {
 				noButton = errorStyle.Render("▶ " + noButton)
 			}
 			prompt = "\n" + yesButton + "   " + noButton + "\n" +
-				infoStyle.Render("Use ← → to select, Enter to confirm") + "\n" +
-				infoStyle.Render("Write this configuration to servers.yaml?")
+				infoStyle.Render(msgf("Use ← → to select, Enter to confirm")) + "\n" +
+				infoStyle.Render(msgf("Write this configuration to %s?", m.yamlFile))
 		} else {
-			title = "Apply Preview"
-			if strings.Contains(m.viewport.View(), "No changes detected") {
-				prompt = "\n" + infoStyle.Render("No changes to apply. Press Enter to return to menu.")
+			title = msgf("Apply Preview")
+			if strings.Contains(m.viewport.View(), msgf(noApplyChanges)) {
+				prompt = "\n" + infoStyle.Render(msgf("No changes to apply. Press Enter to return to menu."))
 			} else {
-				yesButton := "Yes"
-				noButton := "No"
+				yesButton := msgf("Yes")
+				noButton := msgf("No")
 				if m.yesNoIndex == 0 {
 					yesButton = successStyle.Render("▶ " + yesButton)
 					noButton = infoStyle.Render(noButton)
@@ -255,306 +496,293 @@ func (m model) View() string {
 					noButton = errorStyle.Render("▶ " + noButton)
 				}
 				prompt = "\n" + yesButton + "   " + noButton + "\n" +
-					infoStyle.Render("Use ← → to select, Enter to confirm") + "\n" +
-					infoStyle.Render("Apply these changes?")
+					infoStyle.Render(msgf("Use ← → to select, Enter to confirm")) + "\n" +
+					infoStyle.Render(msgf("Apply these changes?"))
 			}
 		}
 		return titleStyle.Render(title) + "\n" +
 			m.viewport.View() + "\n" +
 			prompt
 
+	case stateStatus:
+		return titleStyle.Render(msgf("Status")) + "\n" +
+			m.viewport.View() + "\n\n" +
+			infoStyle.Render(msgf("Press r to refresh, Enter to return to menu"))
+
 	case stateResult:
-		return titleStyle.Render("Result") + "\n" +
+		return titleStyle.Render(msgf("Result")) + "\n" +
 			m.viewport.View() + "\n\n" +
-			infoStyle.Render("Press Enter to return to menu")
+			infoStyle.Render(msgf("Press Enter to return to menu"))
 	default:
 		return ""
 	}
 }
 
+func (m model) clientSelectionView() string {
+	title := msgf("Apply to clients")
+	if m.action == CommandImport {
+		title = msgf("Import from clients")
+	}
+	var b strings.Builder
+	for i, name := range m.clientNames {
+		cursor := "  "
+		if i == m.clientCursor {
+			cursor = "▶ "
+		}
+		check := "[ ]"
+		if m.clientChecked[i] {
+			check = "[x]"
+		}
+		line := cursor + check + " " + name
+		if i == m.clientCursor {
+			line = successStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(m.clientNames) == 0 {
+		b.WriteString(infoStyle.Render(msgf("No clients are defined in the YAML.")) + "\n")
+	}
+	return titleStyle.Render(title) + "\n" +
+		b.String() + "\n" +
+		infoStyle.Render(msgf("Space to toggle, a to toggle all, Enter to preview, q to go back"))
+}
+
+func (m model) conflictView() string {
+	conflict := m.importPlan.conflicts[m.conflictIdx]
+	yamlJSON, _ := json.MarshalIndent(conflict.yamlServer, "", "  ")
+	clientJSON, _ := json.MarshalIndent(conflict.clientServer, "", "  ")
+
+	var buttons []string
+	for i, choice := range conflictChoices {
+		if i == m.choiceIdx {
+			buttons = append(buttons, successStyle.Render("▶ "+choice.label()))
+		} else {
+			buttons = append(buttons, infoStyle.Render(choice.label()))
+		}
+	}
+
+	return titleStyle.Render(msgf("Import Conflict (%d/%d)", m.conflictIdx+1, len(m.importPlan.conflicts))) + "\n" +
+		msgf("%s / %s differs between YAML and client", conflict.client, conflict.name) + "\n\n" +
+		infoStyle.Render(msgf("YAML:")) + "\n" + string(yamlJSON) + "\n\n" +
+		infoStyle.Render(msgf("Client:")) + "\n" + string(clientJSON) + "\n\n" +
+		strings.Join(buttons, "   ") + "\n" +
+		infoStyle.Render(msgf("Use ← → to select, Enter to confirm"))
+}
+
 // Commands
-type importResult string
+type clientNamesResult []string
+type importPlanResult struct{ plan *importPlan }
+type importResult struct{ summary, content string }
 type applyPreviewResult string
+type statusResult string
 type actionComplete string
+type configSelected string
 type errMsg struct{ err error }
 
-func (m model) runImport() tea.Cmd {
+// selectConfig switches to path, creating it from the default template when
+// it does not exist yet.
+func selectConfig(path string) tea.Cmd {
 	return func() tea.Msg {
-		content, err := performImport(m.yamlFile)
+		if err := createDefaultConfig(path); err != nil {
+			return errMsg{err}
+		}
+		return configSelected(path)
+	}
+}
+
+// loadClientNames reads the clients of the YAML for the checklist.
+func (m model) loadClientNames() tea.Cmd {
+	return func() tea.Msg {
+		yamlContent, err := loadEffectiveYAML(m.yamlFile)
 		if err != nil {
 			return errMsg{err}
 		}
-		return importResult(content)
+		var names []string
+		for _, entry := range clientEntries(yamlContent) {
+			names = append(names, entry.name)
+		}
+		return clientNamesResult(names)
 	}
 }
 
-func (m model) runApplyPreview() tea.Cmd {
+func (m model) runImport() tea.Cmd {
 	return func() tea.Msg {
-		preview, err := generateApplyPreview(m.yamlFile)
+		plan, err := planImport(m.yamlFile, m.selection())
 		if err != nil {
 			return errMsg{err}
 		}
-		return applyPreviewResult(preview)
+		return importPlanResult{plan}
 	}
 }
 
-func (m model) executeAction() tea.Cmd {
+// renderImport renders the merged YAML once every conflict has a choice.
+func renderImport(plan *importPlan) tea.Cmd {
 	return func() tea.Msg {
-		if m.action == CommandImport {
-			err := os.WriteFile(m.yamlFile, []byte(m.yamlContent), TUIFileMode)
-			if err != nil {
-				return errMsg{err}
+		content, err := plan.render()
+		if err != nil {
+			return errMsg{err}
+		}
+		var summary strings.Builder
+		for _, ci := range plan.clients {
+			if ci.err != nil {
+				summary.WriteString(infoStyle.Render(msgf("- %s: %s (path preserved)", ci.name, ci.status)) + "\n")
+				continue
 			}
-			return actionComplete(successStyle.Render("✓ Successfully wrote configuration to " + m.yamlFile))
-		} else {
-			result, err := performApply(m.yamlFile)
-			if err != nil {
-				return errMsg{err}
+			summary.WriteString(titleStyle.Render(ci.name) + "\n")
+			for _, line := range ci.summaryLines() {
+				summary.WriteString(infoStyle.Render(line) + "\n")
 			}
-			return actionComplete(result)
 		}
+		return importResult{summary: summary.String(), content: string(content)}
 	}
 }
 
-// Helper functions
-func processSingleClientImport(clientConfig map[string]interface{}, homeDir string) map[string]interface{} {
-	pathStr, ok := clientConfig["path"].(string)
-	if !ok {
-		return map[string]interface{}{
-			"path":    "",
-			"servers": nil,
+func (m model) runApplyPreview() tea.Cmd {
+	return func() tea.Msg {
+		preview, err := generateApplyPreview(m.yamlFile, m.selection())
+		if err != nil {
+			return errMsg{err}
 		}
+		return applyPreviewResult(preview)
 	}
+}
 
-	originalPath := pathStr
-	validatedPath, err := validateSafePath(pathStr, homeDir)
-	if err != nil {
-		return map[string]interface{}{
-			"path":    originalPath,
-			"servers": nil,
-		}
-	}
-	pathStr = validatedPath
-	jsonData, err := os.ReadFile(pathStr)
-	if err != nil {
-		return map[string]interface{}{
-			"path":    originalPath,
-			"servers": nil,
+func (m model) runStatus() tea.Cmd {
+	return func() tea.Msg {
+		statuses, err := collectStatus(m.yamlFile)
+		if err != nil {
+			return errMsg{err}
 		}
+		return statusResult(renderStatus(statuses))
 	}
+}
 
-	var jsonContent map[string]interface{}
-	if err := json.Unmarshal(jsonData, &jsonContent); err != nil {
-		return map[string]interface{}{
-			"path":    originalPath,
-			"servers": nil,
-		}
+// renderStatus styles the status lines of every client for the dashboard.
+func renderStatus(statuses []*clientStatus) string {
+	styles := map[statusLineKind]lipgloss.Style{
+		statusHeader: titleStyle.MarginBottom(0),
+		statusInfo:   infoStyle,
+		statusOK:     successStyle,
+		statusAdd:    diffAddStyle,
+		statusChange: infoStyle,
+		statusRemove: diffRemoveStyle,
+		statusError:  errorStyle,
 	}
-
-	mcpServers, ok := jsonContent["mcpServers"].(map[string]interface{})
-	if !ok || len(mcpServers) == 0 {
-		return map[string]interface{}{
-			"path":    originalPath,
-			"servers": nil,
+	var b strings.Builder
+	for i, cs := range statuses {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		for _, line := range cs.lines() {
+			b.WriteString(styles[line.kind].Render(line.text) + "\n")
 		}
 	}
-
-	return map[string]interface{}{
-		"path":    originalPath,
-		"servers": convertMcpServersToYaml(mcpServers),
+	if len(statuses) == 0 {
+		b.WriteString(infoStyle.Render(msgf("No clients are defined in the YAML.")))
 	}
+	return b.String()
 }
 
-func performImport(yamlFile string) (string, error) {
-	yamlContent, err := loadAndValidateYAML(yamlFile)
-	if err != nil {
-		return "", err
-	}
-	home, _ := os.UserHomeDir()
-	clients := yamlContent["clients"].(map[string]interface{})
-	outputYaml := map[string]interface{}{
-		"clients": make(map[string]interface{}),
-	}
-	outputClients := outputYaml["clients"].(map[string]interface{})
-	for clientName, clientConfig := range clients {
-		config, ok := clientConfig.(map[string]interface{})
-		if !ok {
-			continue
+func (m model) executeAction() tea.Cmd {
+	return func() tea.Msg {
+		if m.action == CommandImport {
+			if err := m.importPlan.write([]byte(m.yamlContent)); err != nil {
+				return errMsg{err}
+			}
+			return actionComplete(successStyle.Render(msgf("✓ Successfully wrote configuration to %s", m.yamlFile)))
+		} else {
+			result, err := performApply(m.yamlFile, m.selection())
+			if err != nil {
+				return errMsg{err}
+			}
+			return actionComplete(result)
 		}
-		outputClients[clientName] = processSingleClientImport(config, home)
 	}
-	yamlBytes, err := yaml.Marshal(outputYaml)
-	if err != nil {
-		return "", err
-	}
-	return string(yamlBytes), nil
 }
 
-func generateApplyPreview(yamlFile string) (string, error) {
-	yamlContent, err := loadAndValidateYAML(yamlFile)
+func generateApplyPreview(yamlFile string, selection Selection) (string, error) {
+	yamlContent, err := loadEffectiveYAML(yamlFile)
 	if err != nil {
 		return "", err
 	}
+	if err := applySelection(yamlContent, yamlFile, selection); err != nil {
+		return "", err
+	}
 	home, _ := os.UserHomeDir()
-	clients := yamlContent["clients"].(map[string]interface{})
 	var preview strings.Builder
 	hasChanges := false
-	for clientName, clientConfig := range clients {
-		config, ok := clientConfig.(map[string]interface{})
-		if !ok {
-			continue
-		}
-		pathStr, ok := config["path"].(string)
-		if !ok {
+	for _, entry := range clientEntries(yamlContent) {
+		config, ok := entry.config.(map[string]interface{})
+		if !ok || len(extractClientServers(config)) == 0 {
+			logSkip(entry.name, "no servers")
 			continue
 		}
-		servers := extractClientServers(config)
-		if len(servers) == 0 {
-			continue
-		}
-		validatedPath, err := validateSafePath(pathStr, home)
-		if err != nil {
+		cs := compareClient(entry.name, config, home)
+		if cs.err != nil {
+			logSkip(entry.name, "cannot be compared", "error", cs.err)
 			continue
 		}
-		pathStr = validatedPath
-		existing := make(map[string]interface{})
-		if data, err := os.ReadFile(pathStr); err == nil && len(data) > 0 {
-			_ = json.Unmarshal(data, &existing)
-		}
-		existingServers := make(map[string]interface{})
-		if existing["mcpServers"] != nil {
-			existingServers = existing["mcpServers"].(map[string]interface{})
-		}
-		clientHasChanges := false
 		var clientChanges strings.Builder
-		for name := range servers {
-			if _, exists := existingServers[name]; !exists {
-				clientChanges.WriteString(diffAddStyle.Render(fmt.Sprintf("  + %s", name)) + "\n")
-				clientHasChanges = true
-			}
+		if cs.parseErr != nil {
+			clientChanges.WriteString(errorStyle.Render(msgf("  ✗ cannot parse (%v); this file will not be changed", cs.parseErr)) + "\n")
 		}
-		for name := range existingServers {
-			if _, exists := servers[name]; !exists {
-				clientChanges.WriteString(diffRemoveStyle.Render(fmt.Sprintf("  - %s", name)) + "\n")
-				clientHasChanges = true
-			}
+		for _, name := range cs.missing {
+			clientChanges.WriteString(diffAddStyle.Render(msgf("  + %s (missing in client)", name)) + "\n")
 		}
-		for name := range servers {
-			if existingServer, exists := existingServers[name]; exists {
-				newServerBytes, _ := json.Marshal(servers[name])
-				existingServerBytes, _ := json.Marshal(existingServer)
-				if string(newServerBytes) != string(existingServerBytes) {
-					clientChanges.WriteString(infoStyle.Render(fmt.Sprintf("  ~ %s (updated)", name)) + "\n")
-					clientHasChanges = true
-				}
-			}
+		for _, name := range cs.extra {
+			clientChanges.WriteString(diffRemoveStyle.Render(msgf("  - %s (not in YAML)", name)) + "\n")
 		}
-		if clientHasChanges {
-			preview.WriteString(fmt.Sprintf("\n%s:\n", titleStyle.Render(clientName)))
-			preview.WriteString(infoStyle.Render(fmt.Sprintf("  → %s", pathStr)) + "\n")
+		for _, name := range cs.modified {
+			clientChanges.WriteString(infoStyle.Render(msgf("  ~ %s (updated)", name)) + "\n")
+		}
+		if clientChanges.Len() > 0 {
+			preview.WriteString(fmt.Sprintf("\n%s:\n", titleStyle.Render(cs.name)))
+			preview.WriteString(infoStyle.Render(fmt.Sprintf("  → %s", cs.path)) + "\n")
 			preview.WriteString(clientChanges.String())
 			hasChanges = true
 		}
 	}
 	if !hasChanges {
-		return infoStyle.Render("No changes detected. All configurations are up to date."), nil
+		return infoStyle.Render(msgf(noApplyChanges)), nil
 	}
 	return preview.String(), nil
 }
 
-func performApply(yamlFile string) (string, error) {
-	yamlContent, err := loadAndValidateYAML(yamlFile)
+func performApply(yamlFile string, selection Selection) (string, error) {
+	results, err := applyClients(yamlFile, ApplyOptions{Selection: selection})
 	if err != nil {
 		return "", err
 	}
-	home, _ := os.UserHomeDir()
-	clients := yamlContent["clients"].(map[string]interface{})
 	var result strings.Builder
 	processedCount := 0
-	for clientName, clientConfig := range clients {
-		config, ok := clientConfig.(map[string]interface{})
-		if !ok {
-			continue
-		}
-		pathStr, ok := config["path"].(string)
-		if !ok {
-			continue
-		}
-		servers := extractClientServers(config)
-		if len(servers) == 0 {
-			continue
-		}
-		validatedPath, err := validateSafePath(pathStr, home)
-		if err != nil {
-			continue
-		}
-		pathStr = validatedPath
-		existing := make(map[string]interface{})
-		if data, err := os.ReadFile(pathStr); err == nil && len(data) > 0 {
-			_ = json.Unmarshal(data, &existing)
+	for _, r := range results {
+		switch {
+		case r.skipped():
+			result.WriteString(infoStyle.Render(r.line()) + "\n\n")
+		case r.err != nil:
+			result.WriteString(errorStyle.Render(msgf("✗ Skipped %s", r.name)) + "\n")
+			result.WriteString(infoStyle.Render(fmt.Sprintf("  %v", r.err)) + "\n\n")
+		case r.written:
+			result.WriteString(successStyle.Render(msgf("✓ Updated %s", r.name)) + "\n")
+			result.WriteString(infoStyle.Render(fmt.Sprintf("  → %s", r.path)) + "\n\n")
+			processedCount++
 		}
-		existingServers := make(map[string]interface{})
-		if existing["mcpServers"] != nil {
-			existingServers = existing["mcpServers"].(map[string]interface{})
-		}
-		hasChanges := false
-		for name, server := range servers {
-			if existingServer, exists := existingServers[name]; !exists {
-				hasChanges = true
-				break
-			} else {
-				newServerBytes, _ := json.Marshal(server)
-				existingServerBytes, _ := json.Marshal(existingServer)
-				if string(newServerBytes) != string(existingServerBytes) {
-					hasChanges = true
-					break
-				}
-			}
-		}
-		if !hasChanges {
-			for name := range existingServers {
-				if _, exists := servers[name]; !exists {
-					hasChanges = true
-					break
-				}
-			}
-		}
-		if !hasChanges {
-			continue
-		}
-		if existing["mcpServers"] == nil {
-			existing["mcpServers"] = make(map[string]interface{})
-		}
-		mcpServers := existing["mcpServers"].(map[string]interface{})
-		for name, server := range servers {
-			mcpServers[name] = server
-		}
-		if err := os.MkdirAll(filepath.Dir(pathStr), TUIDirectoryMode); err != nil {
-			continue
-		}
-		output, err := json.MarshalIndent(existing, "", "  ")
-		if err != nil {
-			continue
-		}
-		if err := os.WriteFile(pathStr, output, TUIFileMode); err != nil {
-			continue
-		}
-		result.WriteString(successStyle.Render(fmt.Sprintf("✓ Updated %s", clientName)) + "\n")
-		result.WriteString(infoStyle.Render(fmt.Sprintf("  → %s", pathStr)) + "\n\n")
-		processedCount++
 	}
 	if processedCount > 0 {
-		result.WriteString(successStyle.Render(fmt.Sprintf("Successfully processed %d client(s)", processedCount)))
+		result.WriteString(successStyle.Render(msgf("Successfully processed %d client(s)", processedCount)))
 	} else {
-		result.WriteString(infoStyle.Render("No changes were applied (all configurations were up to date)"))
+		result.WriteString(infoStyle.Render(msgf("No changes were applied (all configurations were up to date)")))
+	}
+	if err := recordResults(results); err != nil {
+		result.WriteString("\n" + errorStyle.Render(msgf("Could not record apply state: %v", err)))
 	}
 	return result.String(), nil
 }
 
-func runTUI() {
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
+func runTUI(configFlag string) error {
+	p := tea.NewProgram(initialModel(configFlag), tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return errorf("Error: %v", err)
 	}
+	return nil
 }
@@ -1,44 +1,73 @@
 package main
 
-func convertMcpServersToYaml(mcpServers map[string]interface{}) []interface{} {
-	var servers []interface{}
+import (
+	"fmt"
+	"sort"
 
-	for name, serverData := range mcpServers {
-		if server, ok := serverData.(map[string]interface{}); ok {
-			orderedServer := OrderedServer{
-				Name:  name,
-				Extra: make(map[string]interface{}),
-			}
-			if cmd, ok := server["command"].(string); ok {
+	"github.com/goccy/go-yaml"
+)
+
+// clientEntry is one client of servers.yaml.
+type clientEntry struct {
+	name   string
+	config interface{}
+}
+
+// clientEntries returns the clients in the order they are written in the
+// YAML. A plain map carries no order, so its clients are sorted by name.
+func clientEntries(yamlContent map[string]interface{}) []clientEntry {
+	var entries []clientEntry
+	switch clients := yamlContent["clients"].(type) {
+	case yaml.MapSlice:
+		for _, item := range clients {
+			entries = append(entries, clientEntry{name: fmt.Sprint(item.Key), config: item.Value})
+		}
+	case map[string]interface{}:
+		for _, name := range sortedKeys(clients) {
+			entries = append(entries, clientEntry{name: name, config: clients[name]})
+		}
+	}
+	return entries
+}
+
+// setClientEntries replaces the clients of yamlContent, keeping the order.
+func setClientEntries(yamlContent map[string]interface{}, entries []clientEntry) {
+	clients := make(yaml.MapSlice, 0, len(entries))
+	for _, entry := range entries {
+		clients = append(clients, yaml.MapItem{Key: entry.name, Value: entry.config})
+	}
+	yamlContent["clients"] = clients
+}
+
+// convertMcpServerToYaml turns a server read from a client file into a YAML
+// entry. Values are kept as they are, so numbers and booleans in args and env
+// are not lost; args or env of an unexpected type are kept under Extra.
+func convertMcpServerToYaml(name string, server map[string]interface{}) OrderedServer {
+	orderedServer := OrderedServer{
+		Name:  name,
+		Extra: make(map[string]interface{}),
+	}
+	for k, v := range server {
+		switch k {
+		case "command":
+			if cmd, ok := v.(string); ok {
 				orderedServer.Command = cmd
+				continue
 			}
-			if args, ok := server["args"].([]interface{}); ok {
-				stringArgs := make([]string, len(args))
-				for i, arg := range args {
-					if s, ok := arg.(string); ok {
-						stringArgs[i] = s
-					}
-				}
-				orderedServer.Args = stringArgs
+		case "args":
+			if args, ok := v.([]interface{}); ok {
+				orderedServer.Args = args
+				continue
 			}
-			if env, ok := server["env"].(map[string]interface{}); ok {
-				envMap := make(map[string]string)
-				for k, v := range env {
-					if s, ok := v.(string); ok {
-						envMap[k] = s
-					}
-				}
-				orderedServer.Env = envMap
+		case "env":
+			if env, ok := v.(map[string]interface{}); ok {
+				orderedServer.Env = env
+				continue
 			}
-			for k, v := range server {
-				if k != "command" && k != "args" && k != "env" {
-					orderedServer.Extra[k] = v
-				}
-			}
-			servers = append(servers, orderedServer)
 		}
+		orderedServer.Extra[k] = v
 	}
-	return servers
+	return orderedServer
 }
 
 func extractClientServers(config map[string]interface{}) map[string]interface{} {
@@ -55,6 +84,32 @@ func extractClientServers(config map[string]interface{}) map[string]interface{}
 	return servers
 }
 
+// extractClientServerNames returns the server names of a client in YAML order.
+func extractClientServerNames(config map[string]interface{}) []string {
+	var names []string
+	clientServers, ok := config["servers"].([]interface{})
+	if !ok {
+		return names
+	}
+	seen := make(map[string]bool)
+	for _, serverData := range clientServers {
+		if name, _, isValid := processSingleServerConfig(serverData); isValid && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func processSingleServerConfig(serverData interface{}) (serverName string, serverConfig map[string]interface{}, ok bool) {
 	server, isValidMap := serverData.(map[string]interface{})
 	if !isValidMap {
@@ -66,7 +121,7 @@ func processSingleServerConfig(serverData interface{}) (serverName string, serve
 	}
 	serverCopy := make(map[string]interface{})
 	for k, v := range server {
-		if k != "name" {
+		if k != "name" && k != whenKey {
 			serverCopy[k] = v
 		}
 	}
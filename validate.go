@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// validationIssue is one mistake in the YAML.
+type validationIssue struct {
+	client  string
+	server  string
+	message string
+}
+
+func (v validationIssue) String() string {
+	switch {
+	case v.server != "":
+		return msgf("client '%s', server '%s': %s", v.client, v.server, v.message)
+	case v.client != "":
+		return msgf("client '%s': %s", v.client, v.message)
+	}
+	return v.message
+}
+
+// ValidateOptions controls how `validate` prints.
+type ValidateOptions struct {
+	Output OutputFormat
+}
+
+// validationResult sums up the clients and servers of a YAML. Warnings are
+// worth a look but do not make the YAML invalid.
+type validationResult struct {
+	clients     int
+	servers     int
+	clientNames []string
+	issues      []validationIssue
+	warnings    []validationIssue
+}
+
+// validateYAML checks every client and server of the YAML, including those
+// whose `when` conditions do not match this machine. Every `when` block is
+// evaluated on its own, so a broken condition is reported even under a
+// client whose own condition does not match.
+func validateYAML(yamlFile string) (*validationResult, error) {
+	yamlContent, err := loadAndValidateYAML(yamlFile)
+	if err != nil {
+		return nil, err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errorf("error getting home directory: %v", err)
+	}
+
+	result := &validationResult{}
+	for _, entry := range clientEntries(yamlContent) {
+		result.clients++
+		result.clientNames = append(result.clientNames, entry.name)
+		issue := func(server, message string) {
+			result.issues = append(result.issues, validationIssue{client: entry.name, server: server, message: message})
+		}
+		warning := func(message string) {
+			result.warnings = append(result.warnings, validationIssue{client: entry.name, message: message})
+		}
+		config, ok := entry.config.(map[string]interface{})
+		if !ok {
+			issue("", msgf("must be a mapping with path and servers"))
+			continue
+		}
+		if _, err := evaluateWhen(config[whenKey]); err != nil {
+			issue("", msgf("invalid when condition: %v", err))
+		}
+		if pathStr, ok := config["path"].(string); !ok || pathStr == "" {
+			issue("", msgf("path is missing"))
+		} else if _, err := validateSafePath(pathStr, homeDir); err != nil {
+			issue("", err.Error())
+		}
+
+		servers, ok := config["servers"].([]interface{})
+		if !ok {
+			if config["servers"] != nil {
+				issue("", msgf("servers must be a list"))
+			} else {
+				warning(msgf("no servers"))
+			}
+			continue
+		}
+		if len(servers) == 0 {
+			warning(msgf("no servers"))
+		}
+		seen := make(map[string]bool)
+		for i, serverData := range servers {
+			result.servers++
+			server, ok := serverData.(map[string]interface{})
+			if !ok {
+				issue("", msgf("server #%d must be a mapping", i+1))
+				continue
+			}
+			name, _ := server["name"].(string)
+			if name == "" {
+				issue("", msgf("server #%d has no name", i+1))
+				continue
+			}
+			if seen[name] {
+				issue(name, msgf("defined more than once; the last definition is used"))
+			}
+			seen[name] = true
+			if _, err := evaluateWhen(server[whenKey]); err != nil {
+				issue(name, msgf("invalid when condition: %v", err))
+			}
+			for _, message := range validateServer(server) {
+				issue(name, message)
+			}
+		}
+	}
+	return result, nil
+}
+
+// validateServer checks the fields of one server.
+func validateServer(server map[string]interface{}) []string {
+	var messages []string
+	_, hasCommand := server["command"]
+	_, hasURL := server["url"]
+	switch {
+	case hasCommand && hasURL:
+		messages = append(messages, msgf("has both command and url"))
+	case !hasCommand && !hasURL:
+		messages = append(messages, msgf("needs a command or a url"))
+	}
+	if command, ok := server["command"]; ok {
+		if s, isString := command.(string); !isString || s == "" {
+			messages = append(messages, msgf("command must be a non-empty string"))
+		}
+	}
+	if url, ok := server["url"]; ok {
+		if s, isString := url.(string); !isString || s == "" {
+			messages = append(messages, msgf("url must be a non-empty string"))
+		}
+	}
+	if args, ok := server["args"]; ok {
+		list, isList := args.([]interface{})
+		if !isList {
+			messages = append(messages, msgf("args must be a list"))
+		}
+		for _, arg := range list {
+			switch arg.(type) {
+			case map[string]interface{}, []interface{}, nil:
+				messages = append(messages, msgf("args must be strings, got %v", arg))
+			}
+		}
+	}
+	for _, key := range []string{"env", "headers"} {
+		if value, ok := server[key]; ok {
+			if _, isMap := value.(map[string]interface{}); !isMap {
+				messages = append(messages, msgf("%s must be a mapping", key))
+			}
+		}
+	}
+	return messages
+}
+
+// printValidation writes the issues, the warnings and a summary.
+func printValidation(out io.Writer, yamlFile string, result *validationResult) {
+	for _, issue := range result.issues {
+		fmt.Fprintf(out, "✗ %s\n", issue)
+	}
+	for _, warning := range result.warnings {
+		fmt.Fprintf(out, "! %s\n", warning)
+	}
+	if len(result.issues) > 0 {
+		fmt.Fprintln(out, "\n"+msgf("%s has %d problem(s)", yamlFile, len(result.issues)))
+		return
+	}
+	fmt.Fprintln(out, msgf("✓ %s is valid: %d client(s), %d server(s)", yamlFile, result.clients, result.servers))
+}
+
+// jsonReport converts the result for --output json.
+func (result *validationResult) jsonReport(yamlFile string) *jsonReport {
+	report := newJSONReport(CommandValidate, yamlFile)
+	valid := len(result.issues) == 0
+	report.Valid = &valid
+	if !valid {
+		report.ExitCode = ExitValidation
+	}
+	invalid := make(map[string]bool)
+	for _, issue := range result.issues {
+		invalid[issue.client] = true
+		report.Errors = append(report.Errors, jsonError{Code: CodeInvalidYAML, Message: issue.message, Client: issue.client, Server: issue.server})
+	}
+	for _, warning := range result.warnings {
+		report.Warnings = append(report.Warnings, jsonError{Code: CodeNoServers, Message: warning.message, Client: warning.client})
+	}
+	for _, name := range result.clientNames {
+		client := jsonClient{Name: name, Status: "valid", Servers: newJSONServers(nil, nil, nil, nil)}
+		if invalid[name] {
+			client.Status = "invalid"
+		}
+		report.Clients = append(report.Clients, client)
+	}
+	return report
+}
+
+func validateConfig(yamlFile string, options ValidateOptions) error {
+	result, err := validateYAML(yamlFile)
+	if invalidYAML(err) {
+		// a YAML that cannot be loaded is invalid rather than an error
+		if options.Output == OutputJSON {
+			report := newJSONReport(CommandValidate, yamlFile)
+			valid := false
+			report.Valid = &valid
+			report.ExitCode = ExitValidation
+			report.Errors = append(report.Errors, *newJSONError(err))
+			writeJSONReport(os.Stdout, report)
+		} else {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		}
+		return &exitError{code: ExitValidation, err: err, reported: true}
+	}
+	if err != nil {
+		return failCommand(options.Output, CommandValidate, yamlFile, err)
+	}
+	if options.Output == OutputJSON {
+		writeJSONReport(os.Stdout, result.jsonReport(yamlFile))
+	} else {
+		printValidation(os.Stdout, yamlFile, result)
+	}
+	if len(result.issues) > 0 {
+		return reportedExit(ExitValidation, "%s has %d problem(s)", yamlFile, len(result.issues))
+	}
+	return nil
+}
+
+// invalidYAML reports whether err means the YAML itself is wrong, as opposed
+// to the file being unreadable.
+func invalidYAML(err error) bool {
+	if errors.Is(err, ErrYAMLTooLarge) || errors.Is(err, ErrYAMLTooDeep) {
+		return true
+	}
+	return err != nil && errorCode(err) == CodeInvalidYAML
+}
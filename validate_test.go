@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeValidateYAML(t *testing.T, content string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	yamlFile := filepath.Join(home, "servers.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(content), 0600))
+	return yamlFile
+}
+
+// TestValidateYAML YAMLの構造チェックテスト
+func TestValidateYAML(t *testing.T) {
+	yamlFile := writeValidateYAML(t, `clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: fetch
+      command: uvx
+    - name: fetch
+      command: npx
+    - command: orphan
+    - name: both
+      command: x
+      url: https://example.com
+    - name: neither
+      env: [A=1]
+    - name: nested
+      command: x
+      args: [[a]]
+  outside:
+    path: /etc/mcp.json
+    servers: none
+  mac-only:
+    when:
+      os: darwin
+    path: .cursor/mcp.json
+  empty:
+    path: .gemini/settings.json
+    servers: []
+`)
+	result, err := validateYAML(yamlFile)
+	require.NoError(t, err)
+	var messages []string
+	for _, issue := range result.issues {
+		messages = append(messages, issue.String())
+	}
+	assert.Equal(t, []string{
+		"client 'claude', server 'fetch': defined more than once; the last definition is used",
+		"client 'claude': server #3 has no name",
+		"client 'claude', server 'both': has both command and url",
+		"client 'claude', server 'neither': needs a command or a url",
+		"client 'claude', server 'neither': env must be a mapping",
+		"client 'claude', server 'nested': args must be strings, got [a]",
+		"client 'outside': access outside the home directory is not allowed: /etc/mcp.json",
+		"client 'outside': servers must be a list",
+	}, messages)
+	var warnings []string
+	for _, warning := range result.warnings {
+		warnings = append(warnings, warning.String())
+	}
+	assert.Equal(t, []string{"client 'mac-only': no servers", "client 'empty': no servers"}, warnings, "サーバーがないのは警告")
+	assert.Equal(t, 4, result.clients, "clients for other machines are checked too")
+
+	// applyは重複したサーバーのうち最後の定義を書き込む
+	servers := extractClientServers(map[string]interface{}{"servers": []interface{}{
+		map[string]interface{}{"name": "fetch", "command": "first"},
+		map[string]interface{}{"name": "fetch", "command": "last"},
+	}})
+	assert.Equal(t, map[string]interface{}{"command": "last"}, servers["fetch"])
+}
+
+// TestValidateYAML_Valid 正しいYAMLの表示テスト
+func TestValidateYAML_Valid(t *testing.T) {
+	yamlFile := writeValidateYAML(t, `clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: fetch
+      command: uvx
+      args: [mcp-server-fetch, --port, 8080]
+    - name: remote
+      url: https://example.com/mcp
+      headers:
+        Authorization: Bearer x
+`)
+	result, err := validateYAML(yamlFile)
+	require.NoError(t, err)
+	var out bytes.Buffer
+	printValidation(&out, yamlFile, result)
+	assert.Equal(t, "✓ "+yamlFile+" is valid: 1 client(s), 2 server(s)\n", out.String())
+}
+
+// TestValidateYAML_Errors 読み込めないYAMLのテスト
+func TestValidateYAML_Errors(t *testing.T) {
+	_, err := validateYAML(writeValidateYAML(t, "clients: [\n"))
+	assert.Error(t, err)
+
+}
+
+// TestValidateYAML_When when条件はクライアントの条件に関係なくすべて検証するテスト
+func TestValidateYAML_When(t *testing.T) {
+	result, err := validateYAML(writeValidateYAML(t, `clients:
+  claude:
+    when:
+      bogus: 1
+    path: .claude.json
+    servers:
+    - name: fetch
+      command: uvx
+  cursor:
+    when:
+      os: plan9
+    path: .cursor/mcp.json
+    servers:
+    - name: fetch
+      command: uvx
+      when:
+        os: [[darwin]]
+`))
+	require.NoError(t, err)
+	var messages []string
+	for _, issue := range result.issues {
+		messages = append(messages, issue.String())
+	}
+	assert.Equal(t, []string{
+		"client 'claude': invalid when condition: unsupported condition: bogus",
+		"client 'cursor', server 'fetch': invalid when condition: os: must be a string: [darwin]",
+	}, messages)
+}
+
+// TestValidateConfig_DefaultYAML TUIが作る空のYAMLは警告だけで検証に通るテスト
+func TestValidateConfig_DefaultYAML(t *testing.T) {
+	yamlFile := writeValidateYAML(t, defaultYAML)
+	result, err := validateYAML(yamlFile)
+	require.NoError(t, err)
+	assert.Empty(t, result.issues)
+	assert.Len(t, result.warnings, 3)
+	assert.NoError(t, validateConfig(yamlFile, ValidateOptions{}))
+}
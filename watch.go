@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultWatchInterval = 500 * time.Millisecond
+	WatchDebounce        = 300 * time.Millisecond
+)
+
+// WatchOptions controls `mcpyammy watch`.
+type WatchOptions struct {
+	// Reverse also watches the client files and warns when one drifts away
+	// from the YAML.
+	Reverse  bool
+	Interval time.Duration
+}
+
+// fileStamp is what polling compares to notice that a file changed.
+type fileStamp struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+func stampFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{exists: true, modTime: info.ModTime(), size: info.Size()}
+}
+
+func stampFiles(paths []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, path := range paths {
+		stamps[path] = stampFile(path)
+	}
+	return stamps
+}
+
+// changedFiles returns the paths whose stamp differs from before.
+func changedFiles(before, after map[string]fileStamp) []string {
+	var changed []string
+	for path, stamp := range after {
+		if before[path] != stamp {
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
+
+func watchConfig(yamlFile string, options WatchOptions) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return runWatch(ctx, yamlFile, options, os.Stdout)
+}
+
+// watcher polls the YAML, the files it includes and, in reverse mode, the
+// client files. There is no file notification library in the dependency
+// set, and polling a handful of small files is cheap.
+type watcher struct {
+	yamlFile      string
+	options       WatchOptions
+	out           io.Writer
+	configFiles   []string
+	configStamp   map[string]fileStamp
+	clientStamp   map[string]fileStamp
+	clients       map[string]string    // client file path → client name
+	lastDrift     map[string]string    // client file path → last drift warning
+	clientPending map[string]time.Time // client file path → when it last changed
+}
+
+// runWatch applies yamlFile whenever it or one of its includes changes, until
+// ctx is cancelled. Changes are debounced so that an editor saving several
+// files at once triggers a single apply.
+func runWatch(ctx context.Context, yamlFile string, options WatchOptions, out io.Writer) error {
+	if options.Interval <= 0 {
+		options.Interval = DefaultWatchInterval
+	}
+	w := &watcher{yamlFile: yamlFile, options: options, out: out, lastDrift: make(map[string]string), clientPending: make(map[string]time.Time)}
+	w.refreshConfigFiles()
+	if _, err := os.Stat(yamlFile); err != nil {
+		return errorf("Error reading YAML file: %v", err)
+	}
+	w.refreshClients()
+	fmt.Fprintln(out, msgf("Watching %s (%d file(s)); press Ctrl+C to stop", yamlFile, len(w.configFiles)))
+
+	ticker := time.NewTicker(options.Interval)
+	defer ticker.Stop()
+	var pendingSince time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			stamps := stampFiles(w.configFiles)
+			if len(changedFiles(w.configStamp, stamps)) > 0 {
+				w.configStamp = stamps
+				pendingSince = now
+			}
+			if !pendingSince.IsZero() && now.Sub(pendingSince) >= WatchDebounce {
+				pendingSince = time.Time{}
+				w.apply()
+			}
+			if w.options.Reverse && pendingSince.IsZero() {
+				w.checkClientDrift(now)
+			}
+		}
+	}
+}
+
+// refreshConfigFiles updates the list of files to watch. Files that were
+// already watched keep their stamp, so an edit saved while applying is still
+// picked up on the next poll.
+func (w *watcher) refreshConfigFiles() {
+	files, _ := configTreeFiles(w.yamlFile)
+	if len(files) == 0 {
+		files = []string{w.yamlFile}
+	}
+	stamps := make(map[string]fileStamp, len(files))
+	for _, path := range files {
+		if stamp, ok := w.configStamp[path]; ok {
+			stamps[path] = stamp
+		} else {
+			stamps[path] = stampFile(path)
+		}
+	}
+	w.configFiles = files
+	w.configStamp = stamps
+}
+
+// refreshClients remembers the client files and their current state, so that
+// only changes made after this point are reported as drift.
+func (w *watcher) refreshClients() {
+	w.clients = make(map[string]string)
+	yamlContent, err := loadEffectiveYAML(w.yamlFile)
+	if err != nil {
+		w.clientStamp = nil
+		return
+	}
+	homeDir, _ := os.UserHomeDir()
+	var paths []string
+	for _, entry := range clientEntries(yamlContent) {
+		config, ok := entry.config.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if path, err := validateSafePath(fmt.Sprint(config["path"]), homeDir); err == nil {
+			w.clients[path] = entry.name
+			paths = append(paths, path)
+		}
+	}
+	w.clientStamp = stampFiles(paths)
+}
+
+// apply validates the YAML and applies it to every client, printing one line
+// per client. A YAML with validation problems is not applied at all, and
+// clients without servers are skipped as apply skips them.
+func (w *watcher) apply() {
+	defer w.refreshClients()
+	// an edit may have added or removed includes; new files are stamped
+	// before they are read so that later edits are not missed
+	w.refreshConfigFiles()
+
+	stamp := time.Now().Format("15:04:05")
+	validation, err := validateYAML(w.yamlFile)
+	if err != nil {
+		fmt.Fprintln(w.out, msgf("[%s] ✗ %v (not applied)", stamp, err))
+		return
+	}
+	if len(validation.issues) > 0 {
+		fmt.Fprintln(w.out, msgf("[%s] ✗ %s has %d problem(s) (not applied)", stamp, w.yamlFile, len(validation.issues)))
+		for _, issue := range validation.issues {
+			fmt.Fprintf(w.out, "  ✗ %s\n", issue)
+		}
+		return
+	}
+
+	fmt.Fprintln(w.out, msgf("[%s] %s changed, applying", stamp, w.yamlFile))
+	results, err := applyClients(w.yamlFile, ApplyOptions{})
+	if err != nil {
+		fmt.Fprintln(w.out, msgf("  ✗ %v (not applied)", err))
+		return
+	}
+	for _, result := range results {
+		switch {
+		case result.skipped():
+			fmt.Fprintln(w.out, "  "+msgf("- %s has no servers; skipped", result.name))
+		case result.err != nil:
+			fmt.Fprintln(w.out, msgf("  ✗ %s could not be applied: %v", result.name, result.err))
+		case result.written:
+			fmt.Fprintln(w.out, msgf("  ✓ %s updated", result.name))
+		default:
+			fmt.Fprintln(w.out, msgf("  = %s up to date", result.name))
+		}
+	}
+	if err := recordResults(results); err != nil {
+		fmt.Fprintln(w.out, "  "+msgf("Warning: could not record apply state: %v", err))
+	}
+}
+
+// checkClientDrift warns when a client file changed on its own and no longer
+// matches the YAML. Changes are debounced like YAML changes so that a file
+// caught halfway through being written is not reported, and clients like
+// Claude Code rewrite their files often, so the same drift is only reported
+// once.
+func (w *watcher) checkClientDrift(now time.Time) {
+	if w.clientStamp == nil {
+		return
+	}
+	paths := make([]string, 0, len(w.clients))
+	for path := range w.clients {
+		paths = append(paths, path)
+	}
+	stamps := stampFiles(paths)
+	for _, path := range changedFiles(w.clientStamp, stamps) {
+		w.clientPending[path] = now
+	}
+	w.clientStamp = stamps
+
+	var settled []string
+	for path, since := range w.clientPending {
+		if now.Sub(since) >= WatchDebounce {
+			settled = append(settled, path)
+			delete(w.clientPending, path)
+		}
+	}
+	if len(settled) == 0 {
+		return
+	}
+
+	statuses, err := collectStatus(w.yamlFile)
+	if err != nil {
+		return
+	}
+	stamp := now.Format("15:04:05")
+	for _, cs := range statuses {
+		if !slices.Contains(settled, cs.path) {
+			continue
+		}
+		summary := driftSummary(cs)
+		if summary == w.lastDrift[cs.path] {
+			continue
+		}
+		w.lastDrift[cs.path] = summary
+		if summary != "" {
+			fmt.Fprintln(w.out, msgf("[%s] ! %s drifted from the YAML: %s", stamp, cs.name, summary))
+		}
+	}
+}
+
+// driftSummary lists how a client differs from the YAML, or is empty when it
+// matches.
+func driftSummary(cs *clientStatus) string {
+	if cs.parseErr != nil {
+		return msgf("cannot parse (%v)", cs.parseErr)
+	}
+	var parts []string
+	for _, name := range cs.missing {
+		parts = append(parts, "+"+name)
+	}
+	for _, name := range cs.modified {
+		parts = append(parts, "~"+name)
+	}
+	for _, name := range cs.extra {
+		parts = append(parts, "-"+name)
+	}
+	return strings.Join(parts, " ")
+}
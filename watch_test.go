@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer lets the test read what the watcher goroutine writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func startWatch(t *testing.T, yamlFile string, options WatchOptions) *syncBuffer {
+	t.Helper()
+	out := &syncBuffer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- runWatch(ctx, yamlFile, options, out) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+	waitForOutput(t, out, "Watching")
+	return out
+}
+
+func waitForOutput(t *testing.T, out *syncBuffer, want string) {
+	t.Helper()
+	require.Eventually(t, func() bool { return strings.Contains(out.String(), want) },
+		5*time.Second, 10*time.Millisecond, "output so far:\n%s", out)
+}
+
+// writeLater writes a file with a modification time that differs from the
+// previous write even on file systems with coarse timestamps.
+func writeLater(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	later := time.Now().Add(time.Duration(len(content)) * time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+}
+
+// TestRunWatch_AppliesOnChange YAMLとincludeの変更で自動適用するテスト
+func TestRunWatch_AppliesOnChange(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+
+	yamlFile := filepath.Join(home, "servers.yaml")
+	writeLater(t, filepath.Join(home, "team.yaml"), "clients:\n  claude:\n    path: .claude.json\n")
+	writeLater(t, yamlFile, "include: team.yaml\n")
+
+	out := startWatch(t, yamlFile, WatchOptions{Interval: 10 * time.Millisecond})
+	assert.Contains(t, out.String(), "(2 file(s))")
+
+	writeLater(t, yamlFile, "include: team.yaml\nclients:\n  claude:\n    servers:\n    - name: a\n      command: a\n")
+	waitForOutput(t, out, "✓ claude updated")
+	data, err := os.ReadFile(filepath.Join(home, ".claude.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"a"`)
+
+	// broken YAML is reported and nothing is written
+	writeLater(t, yamlFile, "clients: [\n")
+	waitForOutput(t, out, "(not applied)")
+
+	// included files are watched too
+	writeLater(t, yamlFile, "include: team.yaml\nclients:\n  claude:\n    servers:\n    - name: a\n      command: a\n")
+	waitForOutput(t, out, "= claude up to date")
+	writeLater(t, filepath.Join(home, "team.yaml"), "clients:\n  claude:\n    path: .claude.json\n    servers:\n    - name: b\n      command: b\n")
+	require.Eventually(t, func() bool {
+		data, _ := os.ReadFile(filepath.Join(home, ".claude.json"))
+		return strings.Contains(string(data), `"b"`)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// TestRunWatch_ValidatesBeforeApplying 検証エラーのYAMLは適用せず、サーバーのないクライアントはスキップするテスト
+func TestRunWatch_ValidatesBeforeApplying(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+
+	yamlFile := filepath.Join(home, "servers.yaml")
+	writeLater(t, yamlFile, "clients:\n  claude:\n    path: .claude.json\n")
+	out := startWatch(t, yamlFile, WatchOptions{Interval: 10 * time.Millisecond})
+
+	writeLater(t, yamlFile, "clients:\n  claude:\n    path: .claude.json\n    servers:\n    - name: a\n      command: a\n  cursor:\n    path: ../outside.json\n    servers:\n    - name: a\n      command: a\n")
+	waitForOutput(t, out, "has 1 problem(s) (not applied)")
+	assert.Contains(t, out.String(), "✗ client 'cursor':")
+	assert.NoFileExists(t, filepath.Join(home, ".claude.json"), "検証エラーがあるときは他のクライアントにも書き込まないべき")
+
+	writeLater(t, yamlFile, "clients:\n  claude:\n    path: .claude.json\n    servers:\n    - name: a\n      command: a\n  cursor:\n    path: .cursor/mcp.json\n")
+	waitForOutput(t, out, "✓ claude updated")
+	assert.Contains(t, out.String(), "- cursor has no servers; skipped")
+	assert.NotContains(t, out.String(), "✗ cursor")
+}
+
+// TestRunWatch_ReverseWarnsOnDrift クライアント側の変更を警告するテスト
+func TestRunWatch_ReverseWarnsOnDrift(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+
+	yamlFile := filepath.Join(home, "servers.yaml")
+	writeLater(t, yamlFile, "clients:\n  claude:\n    path: .claude.json\n    servers:\n    - name: a\n      command: a\n")
+	clientFile := filepath.Join(home, ".claude.json")
+	writeLater(t, clientFile, `{"mcpServers": {"a": {"command": "a"}}}`)
+
+	out := startWatch(t, yamlFile, WatchOptions{Reverse: true, Interval: 10 * time.Millisecond})
+
+	writeLater(t, clientFile, `{"mcpServers": {"a": {"command": "a"}, "manual": {"command": "m"}}}`)
+	waitForOutput(t, out, "! claude drifted from the YAML: -manual")
+
+	// the same drift is reported only once
+	writeLater(t, clientFile, `{"other": 1, "mcpServers": {"a": {"command": "a"}, "manual": {"command": "m"}}}`)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, strings.Count(out.String(), "drifted"))
+}
@@ -0,0 +1,560 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// yamlDocument edits servers.yaml in place. The goccy/go-yaml AST is used to
+// locate the client server lists, and only the lines of the touched entries
+// are rewritten, so comments, anchors, key order and formatting elsewhere in
+// the file survive an import. Lines are kept without their line ending,
+// which is restored from the file when it is written back.
+type yamlDocument struct {
+	lines   []string
+	newline string
+}
+
+func newYAMLDocument(src []byte) *yamlDocument {
+	newline := "\n"
+	if bytes.Contains(src, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+	text := strings.ReplaceAll(string(src), "\r\n", "\n")
+	return &yamlDocument{lines: strings.Split(text, "\n"), newline: newline}
+}
+
+func (d *yamlDocument) bytes() []byte {
+	return []byte(strings.Join(d.lines, d.newline))
+}
+
+// yamlBlock is a key whose value spans the lines (keyLine, endLine]. Line
+// numbers are 0-based indexes into yamlDocument.lines.
+type yamlBlock struct {
+	node    *ast.MappingValueNode
+	keyLine int
+	indent  int
+	endLine int
+}
+
+// serverEntry is one item of a block sequence of servers.
+type serverEntry struct {
+	name      string
+	startLine int
+	endLine   int
+}
+
+func (d *yamlDocument) parse() (*ast.File, error) {
+	file, err := parser.ParseBytes([]byte(strings.Join(d.lines, "\n")), parser.ParseComments)
+	if err != nil {
+		return nil, errorf("YAML parse error: %w", err)
+	}
+	return file, nil
+}
+
+// findBlock looks up key among the values of a mapping node.
+func (d *yamlDocument) findBlock(node ast.Node, key string) (*yamlBlock, error) {
+	mapping, isMapping := unwrapNode(node).(*ast.MappingNode)
+	for _, mv := range mappingValues(node) {
+		if mv.Key.GetToken().Value != key {
+			continue
+		}
+		if mv.IsFlowStyle || (isMapping && mapping.IsFlowStyle) {
+			return nil, errorf("'%s' in flow style cannot be edited", key)
+		}
+		keyLine := mv.Key.GetToken().Position.Line - 1
+		indent := mv.Key.GetToken().Position.Column - 1
+		return &yamlBlock{node: mv, keyLine: keyLine, indent: indent, endLine: d.blockEnd(keyLine, indent)}, nil
+	}
+	return nil, nil
+}
+
+// blockEnd returns the last content line belonging to the key on keyLine.
+// Following lines belong to it while they are indented deeper than the key,
+// or at the same depth when they are sequence items ("- ...") of its value.
+// Trailing comments and blank lines are left to whatever comes next.
+func (d *yamlDocument) blockEnd(keyLine, indent int) int {
+	end := keyLine
+	for i := keyLine + 1; i < len(d.lines); i++ {
+		line := d.lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if trimmed == "---" || trimmed == "..." {
+			break
+		}
+		lineIndent := indentOf(line)
+		if lineIndent > indent || (lineIndent == indent && isSequenceItem(trimmed)) {
+			end = i
+			continue
+		}
+		break
+	}
+	return end
+}
+
+// serverEntries lists the items of a block servers sequence together with
+// the lines they occupy.
+func (d *yamlDocument) serverEntries(servers *yamlBlock) ([]serverEntry, int, error) {
+	seq, ok := unwrapNode(servers.node.Value).(*ast.SequenceNode)
+	if !ok {
+		return nil, -1, nil
+	}
+	var starts []int
+	dashIndent := -1
+	for i := servers.keyLine + 1; i <= servers.endLine; i++ {
+		trimmed := strings.TrimSpace(d.lines[i])
+		if !isSequenceItem(trimmed) {
+			continue
+		}
+		if dashIndent < 0 {
+			dashIndent = indentOf(d.lines[i])
+		}
+		if indentOf(d.lines[i]) == dashIndent {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) != len(seq.Values) {
+		return nil, -1, errorf("could not parse the structure of 'servers' (line %d)", servers.keyLine+1)
+	}
+	file, err := d.parse()
+	if err != nil {
+		return nil, -1, err
+	}
+	anchors := anchorValues(file)
+
+	entries := make([]serverEntry, len(starts))
+	for i, start := range starts {
+		end := servers.endLine
+		if i+1 < len(starts) {
+			end = lastContentLine(d.lines, start, starts[i+1]-1)
+		}
+		entries[i] = serverEntry{name: sequenceItemName(resolveAlias(seq.Values[i], anchors)), startLine: start, endLine: end}
+	}
+	return entries, dashIndent, nil
+}
+
+// replaceServer rewrites the entry named serverName. It reports false when
+// the client has no such entry in this file. An entry written as an alias
+// (`- *fetch`) is matched by the name of the anchored value, and only the
+// alias item is rewritten; the anchored value is left as it is.
+func (d *yamlDocument) replaceServer(clientName, serverName string, entry interface{}) (bool, error) {
+	servers, err := d.editableServers(clientName)
+	if err != nil || servers == nil {
+		return false, err
+	}
+	entries, dashIndent, err := d.serverEntries(servers)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range entries {
+		if e.name != serverName {
+			continue
+		}
+		rendered, err := renderYAMLEntries([]interface{}{entry}, dashIndent)
+		if err != nil {
+			return false, err
+		}
+		d.splice(e.startLine, e.endLine, rendered)
+		return true, nil
+	}
+	return false, nil
+}
+
+// removeServer deletes the entry named serverName together with its lines.
+// It reports false when the client has no such entry in this file, for
+// example because it comes from an included file.
+func (d *yamlDocument) removeServer(clientName, serverName string) (bool, error) {
+	servers, err := d.editableServers(clientName)
+	if err != nil || servers == nil {
+		return false, err
+	}
+	entries, _, err := d.serverEntries(servers)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range entries {
+		if e.name == serverName {
+			d.splice(e.startLine, e.endLine, nil)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// appendServers adds entries to the end of clients.<clientName>.servers,
+// creating the client and the list when they are not in this file yet.
+func (d *yamlDocument) appendServers(clientName string, newEntries []interface{}) error {
+	if len(newEntries) == 0 {
+		return nil
+	}
+	if err := d.expandFlowClient(clientName); err != nil {
+		return err
+	}
+	file, err := d.parse()
+	if err != nil {
+		return err
+	}
+	body := documentBody(file)
+
+	clients, err := d.findBlock(body, "clients")
+	if err != nil {
+		return err
+	}
+	if clients == nil {
+		rendered, err := renderYAMLValue(yaml.MapSlice{{Key: "clients", Value: yaml.MapSlice{
+			{Key: clientName, Value: yaml.MapSlice{{Key: "servers", Value: newEntries}}},
+		}}}, 0)
+		if err != nil {
+			return err
+		}
+		d.insertAfter(lastContentLine(d.lines, 0, len(d.lines)-1), rendered)
+		return nil
+	}
+
+	client, err := d.findBlock(unwrapNode(clients.node.Value), clientName)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		rendered, err := renderYAMLValue(yaml.MapSlice{
+			{Key: clientName, Value: yaml.MapSlice{{Key: "servers", Value: newEntries}}},
+		}, d.childIndent(clients))
+		if err != nil {
+			return err
+		}
+		d.insertAfter(clients.endLine, rendered)
+		return nil
+	}
+
+	servers, err := d.findBlock(unwrapNode(client.node.Value), "servers")
+	if err != nil {
+		return err
+	}
+	if servers == nil {
+		rendered, err := renderYAMLValue(yaml.MapSlice{{Key: "servers", Value: newEntries}}, d.childIndent(client))
+		if err != nil {
+			return err
+		}
+		d.insertAfter(client.endLine, rendered)
+		return nil
+	}
+
+	if err := d.ensureBlockSequence(clientName, servers); err != nil {
+		return err
+	}
+	servers, err = d.locateServers(clientName)
+	if err != nil {
+		return err
+	}
+	_, dashIndent, err := d.serverEntries(servers)
+	if err != nil {
+		return err
+	}
+	if dashIndent < 0 {
+		dashIndent = servers.indent
+	}
+	rendered, err := renderYAMLEntries(newEntries, dashIndent)
+	if err != nil {
+		return err
+	}
+	d.insertAfter(servers.endLine, rendered)
+	return nil
+}
+
+func (d *yamlDocument) locateServers(clientName string) (*yamlBlock, error) {
+	file, err := d.parse()
+	if err != nil {
+		return nil, err
+	}
+	clients, err := d.findBlock(documentBody(file), "clients")
+	if err != nil || clients == nil {
+		return nil, err
+	}
+	client, err := d.findBlock(unwrapNode(clients.node.Value), clientName)
+	if err != nil || client == nil {
+		return nil, err
+	}
+	return d.findBlock(unwrapNode(client.node.Value), "servers")
+}
+
+// editableServers returns clients.<clientName>.servers as a block sequence,
+// or nil when the client has no servers in this file.
+func (d *yamlDocument) editableServers(clientName string) (*yamlBlock, error) {
+	if err := d.expandFlowClient(clientName); err != nil {
+		return nil, err
+	}
+	servers, err := d.locateServers(clientName)
+	if err != nil || servers == nil {
+		return nil, err
+	}
+	if err := d.ensureBlockSequence(clientName, servers); err != nil {
+		return nil, err
+	}
+	return d.locateServers(clientName)
+}
+
+// expandFlowClient rewrites `clients: {...}` and `<clientName>: {...}` in
+// block style so that the client can be edited line by line.
+func (d *yamlDocument) expandFlowClient(clientName string) error {
+	file, err := d.parse()
+	if err != nil {
+		return err
+	}
+	clients, err := d.findBlock(documentBody(file), "clients")
+	if err != nil || clients == nil {
+		return err
+	}
+	if expanded, err := d.expandFlow(clients); err != nil || expanded {
+		if err != nil {
+			return err
+		}
+		return d.expandFlowClient(clientName)
+	}
+	client, err := d.findBlock(unwrapNode(clients.node.Value), clientName)
+	if err != nil || client == nil {
+		return err
+	}
+	_, err = d.expandFlow(client)
+	return err
+}
+
+// expandFlow rewrites a flow mapping value of block in block style and
+// reports whether it did. An anchor on the value is kept.
+func (d *yamlDocument) expandFlow(block *yamlBlock) (bool, error) {
+	value, ok := unwrapNode(block.node.Value).(*ast.MappingNode)
+	if !ok || !value.IsFlowStyle {
+		return false, nil
+	}
+	key := block.node.Key.GetToken().Value
+	var content yaml.MapSlice
+	if err := yaml.NodeToValue(value, &content, yaml.UseOrderedMap()); err != nil {
+		return false, errorf("'%s' in flow style cannot be edited", key)
+	}
+	replacement := []string{d.keyPrefix(block)}
+	if len(content) > 0 {
+		rendered, err := renderYAMLValue(content, block.indent+2)
+		if err != nil {
+			return false, err
+		}
+		replacement = append(replacement, rendered...)
+	}
+	d.splice(block.keyLine, value.End.Position.Line-1, replacement)
+	return true, nil
+}
+
+// keyPrefix is the key line of block up to its colon, followed by the anchor
+// of its value if it has one.
+func (d *yamlDocument) keyPrefix(block *yamlBlock) string {
+	keyLine := d.lines[block.keyLine]
+	colon := block.indent + strings.Index(keyLine[block.indent:], ":")
+	prefix := keyLine[:colon+1]
+	if anchor, ok := block.node.Value.(*ast.AnchorNode); ok {
+		prefix += " &" + anchor.Name.GetToken().Value
+	}
+	return prefix
+}
+
+// ensureBlockSequence turns `servers:` written as null, as a flow sequence
+// (`servers: [...]`) or as an alias (`servers: *shared`) into an empty block
+// or a block sequence so entries can be edited line by line. An alias is
+// replaced with a copy of the list it points to, so the anchored list and the
+// other clients sharing it are left alone.
+func (d *yamlDocument) ensureBlockSequence(clientName string, servers *yamlBlock) error {
+	switch value := unwrapNode(servers.node.Value).(type) {
+	case *ast.NullNode:
+		keyLine := d.lines[servers.keyLine]
+		colon := servers.indent + strings.Index(keyLine[servers.indent:], ":")
+		comment := ""
+		if idx := strings.Index(keyLine[colon+1:], "#"); idx >= 0 {
+			comment = " " + keyLine[colon+1+idx:]
+		}
+		d.lines[servers.keyLine] = keyLine[:colon+1] + comment
+		return nil
+	case *ast.SequenceNode:
+		if !value.IsFlowStyle {
+			return nil
+		}
+		var items []interface{}
+		if err := yaml.NodeToValue(value, &items, yaml.UseOrderedMap()); err != nil {
+			return errorf("YAML parse error: %w", err)
+		}
+		return d.replaceSequence(servers, value.End.Position.Line-1, items)
+	case *ast.AliasNode:
+		items, ok, err := d.resolveServers(clientName)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errorf("'servers' must be a list (line %d)", servers.keyLine+1)
+		}
+		return d.replaceSequence(servers, servers.endLine, items)
+	case nil:
+		return nil
+	default:
+		return errorf("'servers' must be a list (line %d)", servers.keyLine+1)
+	}
+}
+
+// replaceSequence rewrites the value of block, which ends on endLine, as a
+// block sequence of items.
+func (d *yamlDocument) replaceSequence(block *yamlBlock, endLine int, items []interface{}) error {
+	replacement := []string{d.keyPrefix(block)}
+	if len(items) > 0 {
+		rendered, err := renderYAMLEntries(items, block.indent)
+		if err != nil {
+			return err
+		}
+		replacement = append(replacement, rendered...)
+	}
+	d.splice(block.keyLine, endLine, replacement)
+	return nil
+}
+
+// resolveServers decodes the whole document, which resolves aliases, and
+// returns the servers of clientName. It reports false when they are not a
+// list.
+func (d *yamlDocument) resolveServers(clientName string) ([]interface{}, bool, error) {
+	var root yaml.MapSlice
+	if err := yaml.UnmarshalWithOptions([]byte(strings.Join(d.lines, "\n")), &root, yaml.UseOrderedMap()); err != nil {
+		return nil, false, errorf("YAML parse error: %w", err)
+	}
+	clients, _ := mapSliceValue(root, "clients").(yaml.MapSlice)
+	client, _ := mapSliceValue(clients, clientName).(yaml.MapSlice)
+	servers, ok := mapSliceValue(client, "servers").([]interface{})
+	return servers, ok, nil
+}
+
+// childIndent guesses the indentation used for keys nested under block.
+func (d *yamlDocument) childIndent(block *yamlBlock) int {
+	for i := block.keyLine + 1; i <= block.endLine; i++ {
+		trimmed := strings.TrimSpace(d.lines[i])
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return indentOf(d.lines[i])
+		}
+	}
+	return block.indent + 2
+}
+
+// splice replaces lines [start, end] with replacement.
+func (d *yamlDocument) splice(start, end int, replacement []string) {
+	lines := make([]string, 0, len(d.lines)-(end-start+1)+len(replacement))
+	lines = append(lines, d.lines[:start]...)
+	lines = append(lines, replacement...)
+	lines = append(lines, d.lines[end+1:]...)
+	d.lines = lines
+}
+
+func (d *yamlDocument) insertAfter(line int, inserted []string) {
+	d.splice(line+1, line, inserted)
+}
+
+// renderYAMLEntries renders values as block sequence items whose "-" starts
+// at column indent.
+func renderYAMLEntries(values []interface{}, indent int) ([]string, error) {
+	return renderYAMLValue(values, indent)
+}
+
+func renderYAMLValue(v interface{}, indent int) ([]string, error) {
+	out, err := yaml.MarshalWithOptions(v, yaml.Indent(2), yaml.IndentSequence(false))
+	if err != nil {
+		return nil, errorf("Error generating YAML: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	prefix := strings.Repeat(" ", indent)
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return lines, nil
+}
+
+func documentBody(file *ast.File) ast.Node {
+	if len(file.Docs) == 0 {
+		return nil
+	}
+	return unwrapNode(file.Docs[0].Body)
+}
+
+// unwrapNode skips anchors and tags to reach the value they decorate.
+func unwrapNode(node ast.Node) ast.Node {
+	for {
+		switch n := node.(type) {
+		case *ast.AnchorNode:
+			node = n.Value
+		case *ast.TagNode:
+			node = n.Value
+		default:
+			return node
+		}
+	}
+}
+
+// anchorValues maps each anchor name in file to the value it decorates.
+func anchorValues(file *ast.File) map[string]ast.Node {
+	anchors := make(map[string]ast.Node)
+	for _, node := range ast.FilterFile(ast.AnchorType, file) {
+		anchor := node.(*ast.AnchorNode)
+		anchors[anchor.Name.GetToken().Value] = anchor.Value
+	}
+	return anchors
+}
+
+// resolveAlias returns the anchored value an alias points to, or node itself
+// when it is not an alias.
+func resolveAlias(node ast.Node, anchors map[string]ast.Node) ast.Node {
+	alias, ok := node.(*ast.AliasNode)
+	if !ok {
+		return node
+	}
+	if value, ok := anchors[alias.Value.GetToken().Value]; ok {
+		return value
+	}
+	return node
+}
+
+func mappingValues(node ast.Node) []*ast.MappingValueNode {
+	switch n := unwrapNode(node).(type) {
+	case *ast.MappingNode:
+		return n.Values
+	case *ast.MappingValueNode:
+		return []*ast.MappingValueNode{n}
+	default:
+		return nil
+	}
+}
+
+func sequenceItemName(node ast.Node) string {
+	for _, mv := range mappingValues(node) {
+		if mv.Key.GetToken().Value == "name" {
+			if tk := unwrapNode(mv.Value).GetToken(); tk != nil {
+				return tk.Value
+			}
+		}
+	}
+	return ""
+}
+
+func lastContentLine(lines []string, start, end int) int {
+	for i := end; i > start; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return i
+		}
+	}
+	return start
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func isSequenceItem(trimmed string) bool {
+	return trimmed == "-" || strings.HasPrefix(trimmed, "- ")
+}
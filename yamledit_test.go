@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newServerEntry(name string) yaml.MapSlice {
+	return yaml.MapSlice{{Key: "name", Value: name}, {Key: "command", Value: name}}
+}
+
+// TestYAMLDocument_AliasedServers エイリアスのserversを展開し、アンカー側は変更しないテスト
+func TestYAMLDocument_AliasedServers(t *testing.T) {
+	src := `shared: &shared
+- name: a
+  command: a
+clients:
+  claude:
+    path: .claude.json
+    servers: *shared
+  cursor:
+    path: .cursor/mcp.json
+    servers: *shared
+`
+	doc := newYAMLDocument([]byte(src))
+	require.NoError(t, doc.appendServers("claude", []interface{}{newServerEntry("b")}))
+	replaced, err := doc.replaceServer("cursor", "a", newServerEntry("c"))
+	require.NoError(t, err)
+	assert.True(t, replaced)
+
+	assert.Equal(t, `shared: &shared
+- name: a
+  command: a
+clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: a
+      command: a
+    - name: b
+      command: b
+  cursor:
+    path: .cursor/mcp.json
+    servers:
+    - name: c
+      command: c
+`, string(doc.bytes()))
+}
+
+// TestYAMLDocument_AliasedServerItem エイリアスで書いたサーバーを名前で見つけ、アンカー側は変更しないテスト
+func TestYAMLDocument_AliasedServerItem(t *testing.T) {
+	src := `fetchdef: &fetchdef
+  name: fetch
+  command: uvx
+clients:
+  claude:
+    path: .claude.json
+    servers:
+    - *fetchdef
+    - name: a
+      command: a
+`
+	doc := newYAMLDocument([]byte(src))
+	replaced, err := doc.replaceServer("claude", "fetch", newServerEntry("fetch"))
+	require.NoError(t, err)
+	assert.True(t, replaced, "エイリアスの項目もサーバー名で置き換えられるべき")
+
+	assert.Equal(t, `fetchdef: &fetchdef
+  name: fetch
+  command: uvx
+clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: fetch
+      command: fetch
+    - name: a
+      command: a
+`, string(doc.bytes()))
+}
+
+// TestYAMLDocument_CRLF CRLFのファイルは追加した行もCRLFで書くテスト
+func TestYAMLDocument_CRLF(t *testing.T) {
+	src := "# comment\r\nclients:\r\n  claude:\r\n    servers:\r\n    - name: a\r\n      command: a\r\n"
+	doc := newYAMLDocument([]byte(src))
+	require.NoError(t, doc.appendServers("claude", []interface{}{newServerEntry("b")}))
+
+	assert.Equal(t, src+"    - name: b\r\n      command: b\r\n", string(doc.bytes()))
+}
+
+// TestYAMLDocument_FlowClients フロースタイルのclientsをブロックスタイルに直して編集するテスト
+func TestYAMLDocument_FlowClients(t *testing.T) {
+	doc := newYAMLDocument([]byte("clients: {claude: {path: .claude.json, servers: []}}\n"))
+	require.NoError(t, doc.appendServers("claude", []interface{}{newServerEntry("a")}))
+	assert.Equal(t, `clients:
+  claude:
+    path: .claude.json
+    servers:
+    - name: a
+      command: a
+`, string(doc.bytes()))
+
+	doc = newYAMLDocument([]byte("clients:\n  claude: &c {path: .claude.json, servers: [{name: a, command: a}]}\n"))
+	removed, err := doc.removeServer("claude", "a")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	assert.Equal(t, "clients:\n  claude: &c\n    path: .claude.json\n    servers:\n", string(doc.bytes()))
+
+	doc = newYAMLDocument([]byte("{clients: {claude: {servers: []}}}\n"))
+	err = doc.appendServers("claude", []interface{}{newServerEntry("a")})
+	assert.EqualError(t, err, "'clients' in flow style cannot be edited")
+}